@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/db"
+)
+
+const exportUsage = `usage: inventory-service export [-format csv|ndjson|parquet] [-reservations] [-o file] [config flags]
+
+Writes every stock item, and with -reservations one row per line of each
+active reservation, to the file or to stdout. Rows are read page by page,
+so memory does not grow with the table. Logs go to stderr.
+`
+
+// exportStock corre el comando export. Si falla a mitad de camino borra el
+// archivo para no dejar un export truncado.
+func exportStock(args []string) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, exportUsage) }
+	format := fs.String("format", "", "csv, ndjson or parquet (default: from the -o extension, else csv)")
+	withReservations := fs.Bool("reservations", false, "also export active reservations")
+	path := fs.String("o", "-", "output file, - for stdout")
+	cfg, positional, err := loadCommandConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %s\n\n", strings.Join(positional, " "))
+		fs.Usage()
+		return errUsage
+	}
+	if *format == "" {
+		*format = exportFormatOf(*path)
+	}
+
+	if err := setupLoggingTo(os.Stderr, cfg); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbConn, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	exporter := application.NewStockExportService(db.NewPgStockExportRepository(dbConn), cfg.Export.PageSize)
+
+	var out io.Writer = os.Stdout
+	if *path != "-" {
+		f, createErr := os.Create(*path)
+		if createErr != nil {
+			return fail("failed to create export file", createErr)
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil && cerr != nil {
+				err = fail("stock export failed", cerr)
+			}
+			if err != nil {
+				os.Remove(*path)
+			}
+		}()
+		out = f
+	}
+	bw := bufio.NewWriter(out)
+
+	stats, err := exporter.Export(ctx, bw, *format, *withReservations)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return fail("stock export failed", err)
+	}
+	slog.Info("stock export finished",
+		"format", *format, "stockItems", stats.StockItems,
+		"reservations", stats.Reservations, "reservationLines", stats.ReservationLines)
+	return nil
+}
+
+// exportFormatOf deduce el formato por la extensión; csv si no la conoce.
+func exportFormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return application.ExportFormatNDJSON
+	case ".parquet":
+		return application.ExportFormatParquet
+	}
+	return application.ExportFormatCSV
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/db"
+)
+
+const importUsage = `usage: inventory-service import [-dry-run] [-format csv|ndjson] <file|-> [config flags]
+
+Loads stock levels from a CSV (header with sku, quantity and optional
+location) or NDJSON file. Rows of one SKU in several locations are summed.
+Nothing is written if any row is invalid. The report is printed as JSON.
+`
+
+// importStock corre el comando import. Los CatalogStockAdjusted quedan en
+// el outbox y los publica el dispatcher del servicio.
+func importStock(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, importUsage) }
+	dryRun := fs.Bool("dry-run", false, "validate and report without writing")
+	format := fs.String("format", "", "csv or ndjson (default: from the file extension)")
+	cfg, positional, err := loadCommandConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	path := positional[0]
+	if *format == "" {
+		*format = importFormatOf(path)
+	}
+	if *format == "" {
+		fmt.Fprintf(os.Stderr, "cannot tell the format of %q, use -format\n", path)
+		return errUsage
+	}
+
+	if err := setupLogging(cfg); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fail("failed to open import file", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	dbConn, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	stockRepo, _ := stockRepositories(cfg, dbConn)
+	clock, ids := domain.SystemClock{}, domain.RandomIDGenerator{}
+	outboxWriter := application.NewOutboxWriter(db.NewPgOutboxRepository(dbConn), clock, ids)
+	uow := db.NewPgUnitOfWork(dbConn)
+	importer := application.NewStockImportService(uow, stockRepo, outboxWriter, cfg.Import.ChunkSize, clock, ids)
+
+	result, err := importer.Import(ctx, in, *format, *dryRun)
+	if err != nil {
+		return fail("stock import failed", err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fail("failed to print import report", err)
+	}
+	if result.ErrorCount > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// importFormatOf deduce el formato por la extensión ("" si no la conoce).
+func importFormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return application.ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return application.ImportFormatNDJSON
+	}
+	return ""
+}
@@ -1,118 +1,174 @@
 package main
 
 import (
-	"context"
-	"database/sql"
-	"log"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"strings"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
-
-	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/api"
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
-	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/db"
-	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/messaging"
-	outboxinfra "github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/outbox"
 )
 
-func main() {
-	cfg := config.Load()
-	log.Printf("Starting inventory service on port %s", cfg.HttpPort)
+const usage = `usage: inventory-service [command] [flags]
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+commands:
+  serve                 run the service (default)
+  config print          print the effective configuration with secrets redacted
+  rebuild-projections   rewrite the stock and reservation tables from the event store
+  import                load stock levels from a CSV or NDJSON file ("import -h" for flags)
+  export                write stock and active reservations as CSV, NDJSON or Parquet ("export -h" for flags)
 
-	dbConn, err := sql.Open("pgx", cfg.PgDsn)
-	if err != nil {
-		log.Fatalf("failed to open postgres: %v", err)
-	}
-	defer dbConn.Close()
+Run "inventory-service serve -h" to list the configuration flags.
+`
 
-	if err := dbConn.PingContext(ctx); err != nil {
-		log.Fatalf("failed to ping postgres: %v", err)
-	}
+func main() {
+	os.Exit(exitCode(run(os.Args[1:])))
+}
 
-	// Repos
-	stockRepo := db.NewPgStockItemRepository(dbConn)
-	reservationRepo := db.NewPgStockReservationRepository(dbConn)
-	outboxRepo := db.NewPgOutboxRepository(dbConn)
-
-	// Event buses
-	buses := messaging.NewEventBusPair(cfg.RabbitUri, "inventory.orders-events.v1")
-	catalogBus := messaging.NewCatalogEventBus(cfg.RabbitUri, "inventory.catalog-events.v1")
-
-	// Outbox writer + dispatcher + scheduler
-	outboxWriter := application.NewOutboxWriter(outboxRepo)
-	dispatcher := outboxinfra.NewDispatcher(
-		outboxRepo,
-		buses.Producer,
-		cfg.OutboxMaxRetry,
-		cfg.OutboxBatchSize,
-	)
-	scheduler := outboxinfra.NewScheduler(dispatcher, cfg.OutboxIntervalSec)
-	scheduler.Start(ctx)
-
-	// Application services
-	reserveSvc := application.NewReserveStockService(stockRepo, reservationRepo, outboxWriter)
-	releaseSvc := application.NewReleaseReservationService(stockRepo, reservationRepo, outboxWriter)
-
-	// Handlers de eventos de Orders
-	orderPlacedHandler := application.NewOrderPlacedHandler(reserveSvc)
-	orderCancelledHandler := application.NewOrderCancelledHandler(releaseSvc)
-
-	// Handler de eventos de Catalog
-	productCreatedHandler := application.NewProductCreatedHandler(stockRepo, outboxWriter)
-
-	// Suscripciones
-	if err := messaging.RegisterOrderSubscriptions(
-		ctx,
-		buses.OrdersConsumer,
-		orderPlacedHandler,
-		orderCancelledHandler,
-	); err != nil {
-		log.Fatalf("failed to start orders subscriptions: %v", err)
+// run ejecuta el comando de args. Es el único lugar que decide el código de
+// salida (ver exitCode), así los defers de cada comando siempre corren.
+func run(args []string) error {
+	cmd := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
 	}
 
-	if err := messaging.RegisterCatalogSubscriptions(
-		ctx,
-		catalogBus,
-		productCreatedHandler,
-	); err != nil {
-		log.Fatalf("failed to start catalog subscriptions: %v", err)
+	switch cmd {
+	case "serve":
+		cfg, err := loadConfig(args)
+		if err != nil {
+			return err
+		}
+		if err := cfg.ValidateServe(); err != nil {
+			return invalidConfig(err)
+		}
+		return serve(cfg)
+	case "config":
+		if len(args) == 0 || args[0] != "print" {
+			fmt.Fprint(os.Stderr, usage)
+			return errUsage
+		}
+		cfg, err := loadConfig(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			return fail("failed to print config", err)
+		}
+		return nil
+	case "rebuild-projections":
+		cfg, err := loadConfig(args)
+		if err != nil {
+			return err
+		}
+		return rebuildProjections(cfg)
+	case "import":
+		return importStock(args)
+	case "export":
+		return exportStock(args)
+	case "help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		return errUsage
 	}
+}
+
+var (
+	// errUsage indica que el uso o la configuración ya se informaron en
+	// stderr; sale con 2.
+	errUsage = errors.New("usage error")
+	// errFailed indica que el comando ya informó por qué falló (ej. el
+	// reporte de import con filas inválidas); sale con 1.
+	errFailed = errors.New("command failed")
+)
+
+// commandError es una falla de un comando con el mensaje con que se loguea.
+type commandError struct {
+	msg string
+	err error
+}
 
-	// HTTP API
-	mux := http.NewServeMux()
-	apiServer := api.NewServer(cfg, stockRepo, reservationRepo)
-	apiServer.RegisterRoutes(mux)
+func (e *commandError) Error() string { return e.msg + ": " + e.err.Error() }
 
-	httpSrv := &http.Server{
-		Addr:    ":" + cfg.HttpPort,
-		Handler: mux,
+func (e *commandError) Unwrap() error { return e.err }
+
+func fail(msg string, err error) error {
+	return &commandError{msg: msg, err: err}
+}
+
+// exitCode loguea err si hace falta y devuelve el código de salida.
+func exitCode(err error) int {
+	var cmdErr *commandError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.As(err, &cmdErr):
+		slog.Error(cmdErr.msg, "error", cmdErr.err)
+		return 1
+	case errors.Is(err, errFailed):
+		return 1
+	default:
+		slog.Error("command failed", "error", err)
+		return 1
 	}
+}
 
-	go func() {
-		log.Printf("HTTP listening on :%s", cfg.HttpPort)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("http server error: %v", err)
+func loadConfig(args []string) (config.Config, error) {
+	return checkConfig(config.Load(args))
+}
+
+// loadCommandConfig parsea args con las flags propias del subcomando, ya
+// definidas en fs, más las de configuración. Unas y otras pueden ir antes o
+// después de los argumentos posicionales, que se devuelven en orden.
+func loadCommandConfig(fs *flag.FlagSet, args []string) (config.Config, []string, error) {
+	build := config.RegisterFlags(fs)
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			return config.Config{}, nil, err
+		}
+		if err != nil {
+			return config.Config{}, nil, errUsage // fs ya escribió el error y el uso
+		}
+		if fs.NArg() == 0 {
+			break
 		}
-	}()
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	cfg, err := checkConfig(build())
+	return cfg, positional, err
+}
+
+func checkConfig(cfg config.Config, err error) (config.Config, error) {
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return cfg, invalidConfig(err)
+	}
+	return cfg, err
+}
 
-	// Esperar señal
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-sigCh
-	log.Printf("Shutting down inventory service, signal: %s", sig.String())
+func invalidConfig(err error) error {
+	fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", indent(err.Error()))
+	return errUsage
+}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
 
-	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("http shutdown error: %v", err)
-	}
+// consumerHandler mide cada intento y acota reintentos con cuarentena.
+func consumerHandler(
+	h application.EventHandler,
+	policy application.RetryPolicy,
+	quarantine *application.QuarantineService,
+) application.EventHandler {
+	return application.NewRetryingHandler(application.NewInstrumentedHandler(h), policy, quarantine)
 }
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/api"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/grpcapi"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/db"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/messaging"
+	outboxinfra "github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/outbox"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/tracing"
+)
+
+// serve arranca las APIs HTTP y gRPC, los consumidores y el outbox hasta recibir
+// SIGINT/SIGTERM o hasta que uno de los servidores falle. Las conexiones y
+// el tracing se cierran en defers, así también se cierran si el arranque
+// falla a mitad de camino.
+func serve(cfg config.Config) error {
+	if err := setupLogging(cfg); err != nil {
+		return err
+	}
+	slog.Info("starting inventory service", "port", cfg.HTTP.Port, "storage", cfg.Storage.Mode)
+	defer slog.Info("inventory service stopped")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter, cfg.Tracing.File, "inventory-service")
+	if err != nil {
+		return fail("failed to set up tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSec)*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("tracing shutdown failed", "error", err)
+		}
+	}()
+
+	dbConn, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := dbConn.Close(); err != nil {
+			slog.Error("postgres close failed", "error", err)
+		}
+	}()
+	metrics.RegisterDBStats(dbConn)
+
+	// Repos; el de stock publica cada cambio en el feed de /api/inventory/stream
+	stockFeed := stockfeed.NewFeed(cfg.Stream.MaxSubscribers)
+	baseStockRepo, reservationRepo := stockRepositories(cfg, dbConn)
+	stockRepo := stockfeed.NewRepository(baseStockRepo, stockFeed)
+	outboxRepo := db.NewPgOutboxRepository(dbConn)
+	quarantineRepo := db.NewPgQuarantineRepository(dbConn)
+	uow := db.NewPgUnitOfWork(dbConn)
+
+	// Event buses
+	broker, err := messaging.NewBroker(cfg.Messaging)
+	if err != nil {
+		return fail("failed to create message broker", err)
+	}
+	defer func() {
+		if err := broker.Close(); err != nil {
+			slog.Error("broker close failed", "error", err)
+		}
+	}()
+	slog.Info("message broker selected", "broker", cfg.Messaging.Broker, "eventFormat", cfg.Messaging.EventFormat)
+
+	buses := messaging.NewEventBusPair(broker, cfg.Messaging)
+	catalogBus := messaging.NewCatalogEventBus(broker, cfg.Messaging)
+
+	// Ruteo del outbox: inventory.events por defecto + exchanges dedicados
+	router := outboxinfra.NewRouter(buses.Producer)
+	for eventType, bus := range messaging.NewRoutedProducers(broker, cfg.Outbox.Routes) {
+		slog.Info("outbox route", "eventType", eventType, "exchange", cfg.Outbox.Routes[eventType])
+		router.Route(eventType, bus)
+	}
+
+	// Reloj e IDs reales; los tests usan ManualClock y SequentialIDGenerator
+	clock, ids := domain.SystemClock{}, domain.RandomIDGenerator{}
+
+	// Outbox writer + dispatcher + scheduler
+	outboxWriter := application.NewOutboxWriter(outboxRepo, clock, ids)
+	dispatcher := outboxinfra.NewDispatcher(
+		outboxRepo,
+		router,
+		cfg.Outbox.MaxRetry,
+		cfg.Outbox.BatchSize,
+		clock,
+	)
+	metrics.RegisterOutboxStats(func(ctx context.Context) (int, time.Duration, error) {
+		stats, err := outboxRepo.GetPendingStats(ctx, cfg.Outbox.MaxRetry)
+		if err != nil || stats.Pending == 0 {
+			return stats.Pending, 0, err
+		}
+		return stats.Pending, clock.Now().Sub(time.Unix(stats.OldestOccurredAtUtc, 0)), nil
+	})
+	scheduler := outboxinfra.NewScheduler(dispatcher, cfg.Outbox.IntervalSec)
+	scheduler.Start(ctx)
+
+	// Application services
+	reserveSvc := application.NewReserveStockService(uow, stockRepo, reservationRepo, outboxWriter, clock, ids)
+	releaseSvc := application.NewReleaseReservationService(uow, stockRepo, reservationRepo, outboxWriter, clock, ids)
+	importSvc := application.NewStockImportService(uow, stockRepo, outboxWriter, cfg.Import.ChunkSize, clock, ids)
+	exportSvc := application.NewStockExportService(db.NewPgStockExportRepository(dbConn), cfg.Export.PageSize)
+
+	// Schemas de payloads entrantes
+	schemas := domain.NewSchemaRegistry()
+
+	// Handlers de eventos de Orders
+	orderPlacedHandler := application.NewOrderPlacedHandler(reserveSvc, schemas)
+	orderCancelledHandler := application.NewOrderCancelledHandler(releaseSvc, schemas)
+
+	// Handler de eventos de Catalog
+	productCreatedHandler := application.NewProductCreatedHandler(uow, stockRepo, outboxWriter, schemas, clock, ids)
+
+	// Cuarentena: reintentos acotados, rechazados a tabla local + dead-letter
+	deadLetters := messaging.NewDeadLetterPublisher(broker.Producer(cfg.Messaging.Exchanges.DeadLetter), clock, ids)
+	quarantine := application.NewQuarantineService(quarantineRepo, deadLetters, clock, ids).
+		Register("OrderPlacedEvent", orderPlacedHandler).
+		Register("OrderCancelledEvent", orderCancelledHandler).
+		Register("OrderRejectedEvent", orderCancelledHandler).
+		Register("ProductCreated", productCreatedHandler)
+	retryPolicy := application.RetryPolicy{
+		MaxAttempts: cfg.Consumer.MaxAttempts,
+		Backoff:     time.Duration(cfg.Consumer.RetryBackoffMs) * time.Millisecond,
+		MaxBackoff:  time.Duration(cfg.Consumer.MaxRetryBackoffMs) * time.Millisecond,
+	}
+
+	// Suscripciones
+	if err := messaging.RegisterOrderSubscriptions(
+		ctx,
+		buses.OrdersConsumer,
+		consumerHandler(orderPlacedHandler, retryPolicy, quarantine),
+		consumerHandler(orderCancelledHandler, retryPolicy, quarantine),
+	); err != nil {
+		return fail("failed to start orders subscriptions", err)
+	}
+
+	if err := messaging.RegisterCatalogSubscriptions(
+		ctx,
+		catalogBus,
+		consumerHandler(productCreatedHandler, retryPolicy, quarantine),
+	); err != nil {
+		return fail("failed to start catalog subscriptions", err)
+	}
+
+	// HTTP API
+	mux := http.NewServeMux()
+	var authn *auth.Authenticator
+	if cfg.Auth.Enabled {
+		if authn, err = auth.NewAuthenticator(cfg.Auth); err != nil {
+			return fail("failed to set up authentication", err)
+		}
+	} else {
+		slog.Warn("authentication disabled by auth.insecure, the HTTP and gRPC APIs are open")
+	}
+	apiServer := api.NewServer(cfg, api.ServerDeps{
+		StockRepo:       stockRepo,
+		ReservationRepo: reservationRepo,
+		History:         db.NewPgStockHistoryRepository(dbConn),
+		Quarantine:      quarantine,
+		Importer:        importSvc,
+		Exporter:        exportSvc,
+		Feed:            stockFeed,
+		Authn:           authn,
+	})
+	apiServer.AddReadinessCheck("postgres", dbConn.PingContext)
+	apiServer.AddReadinessCheck("broker", broker.Check)
+	apiServer.AddReadinessCheck("outbox", outboxinfra.BacklogCheck(
+		outboxRepo,
+		cfg.Outbox.MaxRetry,
+		cfg.Readiness.MaxOutboxBacklog,
+		time.Duration(cfg.Readiness.MaxOutboxAgeSec)*time.Second,
+		clock,
+	))
+	apiServer.RegisterRoutes(mux)
+
+	httpSrv := &http.Server{
+		Addr:              ":" + cfg.HTTP.Port,
+		Handler:           api.RequestLogging(mux),
+		ReadHeaderTimeout: time.Duration(cfg.HTTP.ReadHeaderTimeoutSec) * time.Second,
+		ReadTimeout:       time.Duration(cfg.HTTP.ReadTimeoutSec) * time.Second,
+		WriteTimeout:      time.Duration(cfg.HTTP.WriteTimeoutSec) * time.Second,
+		IdleTimeout:       time.Duration(cfg.HTTP.IdleTimeoutSec) * time.Second,
+	}
+
+	// Los streams SSE/WatchStock no terminan solos: cerrar el feed al apagar.
+	httpSrv.RegisterOnShutdown(stockFeed.Close)
+
+	// gRPC API
+	var grpcSrv *grpc.Server
+	var grpcLis net.Listener
+	if cfg.GRPC.Enabled {
+		if grpcLis, err = net.Listen("tcp", ":"+cfg.GRPC.Port); err != nil {
+			return fail("failed to listen for grpc", err)
+		}
+		grpcSrv = grpc.NewServer(grpcapi.ServerOptions(authn)...)
+		grpcapi.NewServer(cfg, stockRepo, reservationRepo, reserveSvc, releaseSvc, stockFeed).Register(grpcSrv)
+		if cfg.GRPC.Reflection {
+			reflection.Register(grpcSrv)
+		}
+	}
+
+	// Un servidor que se cae apaga el resto como una señal.
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("http listening", "addr", httpSrv.Addr)
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- fail("http server error", err)
+		}
+	}()
+	if grpcSrv != nil {
+		go func() {
+			slog.Info("grpc listening", "addr", grpcLis.Addr().String())
+			if err := grpcSrv.Serve(grpcLis); err != nil {
+				serverErr <- fail("grpc server error", err)
+			}
+		}()
+	}
+
+	// Esperar señal o la caída de un servidor
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	var runErr error
+	select {
+	case sig := <-sigCh:
+		slog.Info("shutting down inventory service", "signal", sig.String())
+	case runErr = <-serverErr:
+		slog.Info("shutting down inventory service", "reason", "server error")
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(cfg.ShutdownTimeoutSec)*time.Second,
+	)
+	defer shutdownCancel()
+
+	// 1. Dejar de aceptar requests y esperar las que están en curso
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http shutdown failed", "error", err)
+	}
+	if grpcSrv != nil {
+		stopGRPC(shutdownCtx, grpcSrv)
+	}
+
+	// 2. Dejar de tomar mensajes y terminar los handlers en curso
+	if err := broker.Drain(shutdownCtx); err != nil {
+		slog.Error("consumer drain failed", "error", err)
+	}
+
+	// 3. Parar el scheduler y despachar lo que escribieron los últimos handlers
+	if err := scheduler.Stop(shutdownCtx); err != nil {
+		slog.Error("outbox scheduler stop failed", "error", err)
+	}
+	if n, err := dispatcher.Flush(shutdownCtx); err != nil {
+		slog.Error("final outbox dispatch failed", "processed", n, "error", err)
+	} else {
+		slog.Info("final outbox dispatch completed", "processed", n)
+	}
+
+	// 4. Cortar lo que siga vivo; los defers cierran broker, Postgres y
+	// tracing
+	cancel()
+	return runErr
+}
+
+// stopGRPC espera las llamadas en curso hasta que venza ctx; al vencer
+// corta las que sigan abiertas.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("grpc graceful stop timed out, closing open streams")
+		srv.Stop()
+	}
+}
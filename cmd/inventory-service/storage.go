@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/db"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/eventstore"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+)
+
+// rebuildBatchSize son los SKUs por UpsertMany al reconstruir.
+const rebuildBatchSize = 500
+
+func setupLogging(cfg config.Config) error {
+	return setupLoggingTo(os.Stdout, cfg)
+}
+
+// setupLoggingTo es setupLogging hacia w, para comandos que escriben datos
+// en stdout (ej. export).
+func setupLoggingTo(w io.Writer, cfg config.Config) error {
+	logger, err := logging.New(w, cfg.Logging.Level, cfg.Logging.Format)
+	if err != nil {
+		return fail("invalid logging config", err)
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// openDB abre Postgres y aplica el schema. Si falla cierra la conexión.
+func openDB(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	dbConn, err := sql.Open("pgx", cfg.Postgres.DSN)
+	if err != nil {
+		return nil, fail("failed to open postgres", err)
+	}
+	if err := dbConn.PingContext(ctx); err != nil {
+		dbConn.Close()
+		return nil, fail("failed to ping postgres", err)
+	}
+	if err := db.EnsureSchema(ctx, dbConn); err != nil {
+		dbConn.Close()
+		return nil, fail("failed to ensure schema", err)
+	}
+	return dbConn, nil
+}
+
+// stockRepositories arma los repos de stock y reservaciones según
+// storage.mode. En event-sourced las tablas quedan como proyecciones.
+func stockRepositories(
+	cfg config.Config,
+	dbConn *sql.DB,
+) (domain.StockItemRepository, domain.StockReservationRepository) {
+	stockRepo := db.NewPgStockItemRepository(dbConn)
+	reservationRepo := db.NewPgStockReservationRepository(dbConn)
+	if cfg.Storage.Mode != "event-sourced" {
+		return stockRepo, reservationRepo
+	}
+	events := db.NewPgEventStore(dbConn)
+	return eventstore.NewStockItemRepository(events, stockRepo, cfg.Storage.SnapshotEvery),
+		eventstore.NewStockReservationRepository(events, reservationRepo)
+}
+
+// rebuildProjections reescribe inventory_stock_items y las tablas de
+// reservaciones desde inventory_events.
+func rebuildProjections(cfg config.Config) error {
+	if err := setupLogging(cfg); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbConn, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	if cfg.Storage.Mode != "event-sourced" {
+		slog.Warn("storage.mode is not event-sourced; rebuilding from whatever inventory_events holds",
+			"storage", cfg.Storage.Mode)
+	}
+	stats, err := eventstore.Rebuild(ctx,
+		db.NewPgEventStore(dbConn),
+		db.NewPgStockItemRepository(dbConn),
+		db.NewPgStockReservationRepository(dbConn),
+		rebuildBatchSize,
+	)
+	if err != nil {
+		return fail("failed to rebuild projections", err)
+	}
+	slog.Info("projections rebuilt", "stockItems", stats.StockItems, "reservations", stats.Reservations)
+	return nil
+}
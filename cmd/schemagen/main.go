@@ -0,0 +1,35 @@
+// schemagen escribe el JSON Schema de cada evento saliente de Inventory.
+// Uso: go generate ./internal/domain
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+func main() {
+	out := flag.String("out", "schemas", "output directory")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("failed to create %s: %v", *out, err)
+	}
+
+	for _, ev := range domain.OutboundEvents() {
+		data, err := json.MarshalIndent(ev.JSONSchema(), "", "  ")
+		if err != nil {
+			log.Fatalf("failed to marshal schema for %s: %v", ev.Type, err)
+		}
+		path := filepath.Join(*out, fmt.Sprintf("%s.v%d.schema.json", ev.Type, ev.Version))
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("failed to write %s: %v", path, err)
+		}
+		log.Printf("wrote %s", path)
+	}
+}
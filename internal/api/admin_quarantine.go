@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Respuesta de mensaje en cuarentena.
+type quarantinedMessageResponse struct {
+	ID               uuid.UUID `json:"id"`
+	EnvelopeID       uuid.UUID `json:"envelopeId"`
+	EventType        string    `json:"eventType"`
+	Reason           string    `json:"reason"`
+	Attempts         int       `json:"attempts"`
+	Permanent        bool      `json:"permanent"`
+	QuarantinedAtUtc string    `json:"quarantinedAtUtc"`
+	ReplayedAtUtc    *string   `json:"replayedAtUtc,omitempty"`
+	EnvelopeJSON     string    `json:"envelopeJson"`
+}
+
+func toQuarantinedResponse(m domain.QuarantinedMessage) quarantinedMessageResponse {
+	var replayed *string
+	if m.ReplayedAtUtc != nil {
+		sv := m.ReplayedAtUtc.UTC().Format("2006-01-02T15:04:05Z")
+		replayed = &sv
+	}
+	return quarantinedMessageResponse{
+		ID:               m.ID,
+		EnvelopeID:       m.EnvelopeID,
+		EventType:        m.EventType,
+		Reason:           m.Reason,
+		Attempts:         m.Attempts,
+		Permanent:        m.Permanent,
+		QuarantinedAtUtc: m.QuarantinedAtUtc.UTC().Format("2006-01-02T15:04:05Z"),
+		ReplayedAtUtc:    replayed,
+		EnvelopeJSON:     m.EnvelopeJSON,
+	}
+}
+
+// Handler GET /api/admin/quarantine?pending=true&limit=100
+func (s *Server) handleListQuarantine(w http.ResponseWriter, r *http.Request) {
+	pendingOnly := r.URL.Query().Get("pending") != "false"
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			writeProblem(w, r, CodeInvalidParameter, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	msgs, err := s.quarantine.List(r.Context(), pendingOnly, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "quarantine list failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+
+	resp := make([]quarantinedMessageResponse, 0, len(msgs))
+	for _, m := range msgs {
+		resp = append(resp, toQuarantinedResponse(m))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Handler GET /api/admin/quarantine/{id}
+func (s *Server) handleGetQuarantined(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeProblem(w, r, CodeInvalidID, err.Error())
+		return
+	}
+
+	msg, err := s.quarantine.Get(r.Context(), id)
+	if errors.Is(err, application.ErrQuarantineNotFound) {
+		writeProblem(w, r, CodeQuarantineNotFound, "")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "quarantine get failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+	writeJSON(w, http.StatusOK, toQuarantinedResponse(*msg))
+}
+
+// Handler POST /api/admin/quarantine/{id}/replay
+func (s *Server) handleReplayQuarantined(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeProblem(w, r, CodeInvalidID, err.Error())
+		return
+	}
+
+	err = s.quarantine.Replay(r.Context(), id)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, application.ErrQuarantineNotFound):
+		writeProblem(w, r, CodeQuarantineNotFound, "")
+	case errors.Is(err, application.ErrAlreadyReplayed):
+		writeProblem(w, r, CodeAlreadyReplayed, "")
+	case errors.Is(err, application.ErrNoReplayHandler), application.IsPermanent(err):
+		writeProblem(w, r, CodeReplayRejected, err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "quarantine replay failed", "error", err)
+		writeProblem(w, r, CodeReplayFailed, err.Error())
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+)
+
+// require exige un principal autenticado con al menos el rol indicado. Sin
+// Authenticator (auth.enabled=false) la ruta queda abierta.
+func (s *Server) require(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	if s.authn == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.authn.Authenticate(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "http request not authenticated", "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="inventory"`)
+			writeProblem(w, r, CodeUnauthorized, "missing or invalid bearer token or API key")
+			return
+		}
+
+		ctx := logging.With(r.Context(), "subject", p.Subject, "authMethod", p.Method)
+		if !p.Has(role) {
+			slog.WarnContext(ctx, "http request forbidden", "requiredRole", string(role))
+			writeProblem(w, r, CodeForbidden, "requires role "+string(role))
+			return
+		}
+
+		next(w, r.WithContext(auth.WithPrincipal(ctx, p)))
+	}
+}
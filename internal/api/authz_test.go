@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newAuthMux arma un Server con todas las dependencias en memoria y
+// autenticación por HS256, para que cualquier ruta que pase la autorización
+// llegue a su handler real.
+func newAuthMux(t *testing.T) (*Server, *http.ServeMux) {
+	t.Helper()
+	authn, err := auth.NewAuthenticator(config.AuthConfig{Enabled: true, HMACSecret: testSecret, RolesClaim: "roles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+	stock := memory.NewStockItemRepository(store)
+	clock := domain.NewManualClock(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Second)
+	ids := domain.NewSequentialIDGenerator(t.Name())
+	writer := application.NewOutboxWriter(memory.NewOutboxRepository(store), clock, ids)
+
+	s := NewServer(config.Default(), ServerDeps{
+		StockRepo:       stock,
+		ReservationRepo: memory.NewStockReservationRepository(store),
+		History:         memory.NewStockHistoryRepository(store),
+		Quarantine:      application.NewQuarantineService(memory.NewQuarantineRepository(store), nil, clock, ids),
+		Importer:        application.NewStockImportService(memory.NewUnitOfWork(store), stock, writer, 100, clock, ids),
+		Exporter:        application.NewStockExportService(memory.NewStockExportRepository(store), 100),
+		Feed:            stockfeed.NewFeed(0),
+		Authn:           authn,
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	return s, mux
+}
+
+func bearer(t *testing.T, roles ...auth.Role) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "tester",
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	signed, err := tok.SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+// below es el rol inmediatamente inferior al exigido ("" = sin roles).
+var below = map[auth.Role]auth.Role{
+	auth.RoleReader:            "",
+	auth.RoleWarehouseOperator: auth.RoleReader,
+	auth.RoleAdmin:             auth.RoleWarehouseOperator,
+}
+
+func TestRouteAuthorization(t *testing.T) {
+	s, mux := newAuthMux(t)
+	serve := func(op Operation, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(op.Method, pathParam.ReplaceAllString(op.Path, "x"), nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, op := range s.routes() {
+		name := op.Method + " " + op.Path
+		if op.Role == "" {
+			if rec := serve(op, ""); rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+				t.Errorf("%s (public) without credentials = %d", name, rec.Code)
+			}
+			continue
+		}
+
+		lower, ok := below[op.Role]
+		if !ok {
+			t.Fatalf("%s: unknown role %q", name, op.Role)
+		}
+		rec := serve(op, "")
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s without credentials = %d (WWW-Authenticate %q), want 401", name, rec.Code, rec.Header().Get("WWW-Authenticate"))
+		}
+		if rec := serve(op, "Bearer not-a-token"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s with an invalid token = %d, want 401", name, rec.Code)
+		}
+
+		var roles []auth.Role
+		if lower != "" {
+			roles = append(roles, lower)
+		}
+		if rec := serve(op, bearer(t, roles...)); rec.Code != http.StatusForbidden {
+			t.Errorf("%s as %v = %d, want 403", name, roles, rec.Code)
+		}
+
+		for _, role := range []auth.Role{op.Role, auth.RoleAdmin} {
+			if rec := serve(op, bearer(t, role)); rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+				t.Errorf("%s as %s = %d, want it authorized", name, role, rec.Code)
+			}
+		}
+	}
+}
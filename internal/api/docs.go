@@ -0,0 +1,60 @@
+package api
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed docs.html
+var docsHTML string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsHTML))
+
+// Copia fija de swagger-ui-dist (versión en swaggerui/NOTICE): /docs no
+// depende de un CDN ni de su disponibilidad.
+//
+//go:embed swaggerui/swagger-ui.css swaggerui/swagger-ui-bundle.js swaggerui/favicon-*.png
+var swaggerUIFiles embed.FS
+
+// docsAssetsPath es donde se sirven los assets embebidos.
+const docsAssetsPath = "/docs"
+
+// Handler GET /docs: Swagger UI sobre /swagger.json. Los assets salen de la
+// copia embebida, salvo que http.swaggerUiAssetsUrl apunte a otra base.
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	assets := strings.TrimRight(s.cfg.HTTP.SwaggerUIAssetsURL, "/")
+	if assets == "" {
+		assets = docsAssetsPath
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := docsTemplate.Execute(w, struct {
+		AssetsURL string
+		SpecURL   string
+	}{
+		AssetsURL: assets,
+		SpecURL:   "/swagger.json",
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "docs template failed", "error", err)
+	}
+}
+
+// Handler GET /docs/{file}: assets embebidos de Swagger UI.
+func (s *Server) handleDocsAsset(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("file")
+	body, err := fs.ReadFile(swaggerUIFiles, path.Join("swaggerui", name))
+	if err != nil {
+		writeProblem(w, r, CodeNotFound, "")
+		return
+	}
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = w.Write(body)
+}
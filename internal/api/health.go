@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck es un componente que debe estar sano para recibir tráfico.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Respuesta de un componente en /health/ready. El endpoint es público: el
+// error del check va al log y acá solo sale una razón fija.
+type componentHealth struct {
+	Status string `json:"status" enum:"up,down"`
+	Reason string `json:"reason,omitempty" enum:"timeout,check_failed"`
+}
+
+// Respuesta de readiness.
+type readinessResponse struct {
+	Status     string                     `json:"status" enum:"ready,not_ready"`
+	Components map[string]componentHealth `json:"components"`
+}
+
+// AddReadinessCheck registra un check para /health/ready.
+func (s *Server) AddReadinessCheck(name string, check func(ctx context.Context) error) {
+	s.readiness = append(s.readiness, HealthCheck{Name: name, Check: check})
+}
+
+// Handler GET /health/live: el proceso responde.
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Handler GET /health/ready: corre todos los checks en paralelo; 503 si
+// alguno falla.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+
+	resp := readinessResponse{
+		Status:     "ready",
+		Components: make(map[string]componentHealth, len(s.readiness)),
+	}
+
+	timeout := time.Duration(s.cfg.Readiness.CheckTimeoutSec) * time.Second
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, hc := range s.readiness {
+		wg.Add(1)
+		go func(hc HealthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			status := componentHealth{Status: "up"}
+			if err := hc.Check(ctx); err != nil {
+				status = componentHealth{Status: "down", Reason: "check_failed"}
+				if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					status.Reason = "timeout"
+				}
+				slog.WarnContext(r.Context(), "readiness check failed", "component", hc.Name, "error", err)
+			}
+
+			mu.Lock()
+			resp.Components[hc.Name] = status
+			if status.Status != "up" {
+				resp.Status = "not_ready"
+			}
+			mu.Unlock()
+		}(hc)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if resp.Status != "ready" {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, resp)
+}
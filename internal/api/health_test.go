@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadinessHidesCheckErrors(t *testing.T) {
+	s, mux := newTestMux(t)
+	s.cfg.Readiness.CheckTimeoutSec = 1
+	s.AddReadinessCheck("postgres", func(context.Context) error {
+		return errors.New("dial tcp db.internal:5432: password authentication failed for user \"inventory\"")
+	})
+	s.AddReadinessCheck("broker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	s.AddReadinessCheck("outbox", func(context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /health/ready = %d, want 503", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "db.internal") || strings.Contains(body, "inventory") {
+		t.Errorf("readiness leaks the check error: %s", body)
+	}
+
+	var resp readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]componentHealth{
+		"postgres": {Status: "down", Reason: "check_failed"},
+		"broker":   {Status: "down", Reason: "timeout"},
+		"outbox":   {Status: "up"},
+	}
+	if resp.Status != "not_ready" || len(resp.Components) != len(want) {
+		t.Fatalf("readiness = %+v", resp)
+	}
+	for name, c := range want {
+		if resp.Components[name] != c {
+			t.Errorf("%s = %+v, want %+v", name, resp.Components[name], c)
+		}
+	}
+}
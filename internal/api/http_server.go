@@ -2,14 +2,18 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
-	"strings"
+	"slices"
 
 	"github.com/google/uuid"
 
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
 )
 
 // Server agrupa deps para la capa HTTP.
@@ -17,26 +21,81 @@ type Server struct {
 	cfg             config.Config
 	stockRepo       domain.StockItemRepository
 	reservationRepo domain.StockReservationRepository
+	history         domain.StockHistoryRepository
+	quarantine      *application.QuarantineService
+	importer        *application.StockImportService
+	exporter        *application.StockExportService
+	feed            *stockfeed.Feed
+	readiness       []HealthCheck
+	authn           *auth.Authenticator
 }
 
-func NewServer(
-	cfg config.Config,
-	stockRepo domain.StockItemRepository,
-	reservationRepo domain.StockReservationRepository,
-) *Server {
+// ServerDeps son las dependencias de Server. Las que una ruta no usa pueden
+// quedar en cero; Feed no. Authn nil deja la API sin autenticación.
+type ServerDeps struct {
+	StockRepo       domain.StockItemRepository
+	ReservationRepo domain.StockReservationRepository
+	History         domain.StockHistoryRepository
+	Quarantine      *application.QuarantineService
+	Importer        *application.StockImportService
+	Exporter        *application.StockExportService
+	Feed            *stockfeed.Feed
+	Authn           *auth.Authenticator
+}
+
+func NewServer(cfg config.Config, deps ServerDeps) *Server {
 	return &Server{
 		cfg:             cfg,
-		stockRepo:       stockRepo,
-		reservationRepo: reservationRepo,
+		stockRepo:       deps.StockRepo,
+		reservationRepo: deps.ReservationRepo,
+		history:         deps.History,
+		quarantine:      deps.Quarantine,
+		importer:        deps.Importer,
+		exporter:        deps.Exporter,
+		feed:            deps.Feed,
+		authn:           deps.Authn,
 	}
 }
 
-// RegisterRoutes registra todas las rutas HTTP en el mux.
+// RegisterRoutes registra cada operación de routes() como "MÉTODO ruta" en
+// el mux, midiendo latencia con la ruta como label. Por cada ruta se agrega
+// un fallback por cada método estándar que no soporta, que responde 405, y
+// "/" responde 404, ambos en problem+json. El fallback va por método (y no
+// sin método) para no chocar con rutas de la misma forma, ej.
+// /api/inventory/stream y GET /api/inventory/{sku}.
 func (s *Server) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/api/inventory/", s.handleGetInventoryBySku)
-	mux.HandleFunc("/api/reservations/", s.handleGetReservationByOrder)
-	mux.HandleFunc("/swagger.json", s.handleSwaggerJson)
+	allowed := map[string][]string{}
+	var paths []string
+	for _, op := range s.routes() {
+		h := op.Handler
+		if op.Role != "" {
+			h = s.require(op.Role, h)
+		}
+		mux.Handle(op.Method+" "+op.Path, instrument(op.Path, h))
+
+		if _, ok := allowed[op.Path]; !ok {
+			paths = append(paths, op.Path)
+		}
+		allowed[op.Path] = append(allowed[op.Path], op.Method)
+	}
+
+	for _, path := range paths {
+		methods := allowed[path]
+		notAllowed := instrument(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methodNotAllowed(w, r, methods...)
+		}))
+		for _, m := range standardMethods {
+			if !slices.Contains(methods, m) && !(m == http.MethodHead && slices.Contains(methods, http.MethodGet)) {
+				mux.Handle(m+" "+path, notAllowed)
+			}
+		}
+	}
+	mux.Handle("/", instrument("unmatched", http.HandlerFunc(s.handleNotFound)))
+}
+
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
 }
 
 // Respuesta de health.
@@ -44,11 +103,14 @@ type healthResponse struct {
 	Status string `json:"status"`
 }
 
-// Respuesta de inventario.
+// Respuesta de inventario. ChangedAtUtc solo viene en consultas al
+// historial y AsOf solo en GET /api/inventory/{sku}?asOf=.
 type inventoryResponse struct {
-	Sku       string `json:"sku"`
-	Available int    `json:"available"`
-	Reserved  int    `json:"reserved"`
+	Sku          string `json:"sku"`
+	Available    int    `json:"available"`
+	Reserved     int    `json:"reserved"`
+	AsOf         string `json:"asOf,omitempty"`
+	ChangedAtUtc string `json:"changedAtUtc,omitempty"`
 }
 
 // Respuesta de linea de reservacion.
@@ -61,47 +123,36 @@ type reservationLineResponse struct {
 type reservationResponse struct {
 	OrderID       uuid.UUID                 `json:"orderId"`
 	UserID        uuid.UUID                 `json:"userId"`
-	Status        string                    `json:"status"`
+	Status        string                    `json:"status" enum:"ACTIVE,RELEASED"`
 	ReservedAtUtc string                    `json:"reservedAtUtc"`
 	ReleasedAtUtc *string                   `json:"releasedAtUtc,omitempty"`
 	Lines         []reservationLineResponse `json:"lines"`
 }
 
-// Handler /health
+// Handler GET /health (legacy, equivale a /health/live)
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
 }
 
-// Handler GET /api/inventory/{sku}
+// Handler GET /api/inventory/{sku}?asOf=
 func (s *Server) handleGetInventoryBySku(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	sku := r.PathValue("sku")
 
-	// Path esperado: /api/inventory/{sku}
-	path := strings.TrimPrefix(r.URL.Path, "/api/inventory/")
-	if path == "" || path == r.URL.Path {
-		http.Error(w, "sku is required", http.StatusBadRequest)
+	ctx := logging.With(r.Context(), logging.KeySku, sku)
+	if r.URL.Query().Has("asOf") {
+		s.getInventoryAsOf(w, r.WithContext(ctx), sku)
 		return
 	}
-	sku := path
-
-	ctx := r.Context()
 	itemsMap, err := s.stockRepo.GetBySkus(ctx, []string{sku})
 	if err != nil {
-		log.Printf("GetBySkus error: %v", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "GetBySkus failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
 		return
 	}
 
 	item, ok := itemsMap[sku]
 	if !ok {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeProblem(w, r, CodeSkuNotFound, "no stock item for sku "+sku)
 		return
 	}
 
@@ -115,34 +166,21 @@ func (s *Server) handleGetInventoryBySku(w http.ResponseWriter, r *http.Request)
 
 // Handler GET /api/reservations/{orderId}
 func (s *Server) handleGetReservationByOrder(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Path esperado: /api/reservations/{orderId}
-	path := strings.TrimPrefix(r.URL.Path, "/api/reservations/")
-	if path == "" || path == r.URL.Path {
-		http.Error(w, "orderId is required", http.StatusBadRequest)
-		return
-	}
-	orderIDStr := path
-
-	orderID, err := uuid.Parse(orderIDStr)
+	orderID, err := uuid.Parse(r.PathValue("orderId"))
 	if err != nil {
-		http.Error(w, "orderId is invalid", http.StatusBadRequest)
+		writeProblem(w, r, CodeInvalidOrderID, err.Error())
 		return
 	}
 
-	ctx := r.Context()
+	ctx := logging.With(r.Context(), logging.KeyOrderID, orderID.String())
 	res, err := s.reservationRepo.GetByOrderID(ctx, orderID)
 	if err != nil {
-		log.Printf("GetByOrderID error: %v", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "GetByOrderID failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
 		return
 	}
 	if res == nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeProblem(w, r, CodeReservationNotFound, "no reservation for order "+orderID.String())
 		return
 	}
 
@@ -171,178 +209,11 @@ func (s *Server) handleGetReservationByOrder(w http.ResponseWriter, r *http.Requ
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// Handler GET /swagger.json
-func (s *Server) handleSwaggerJson(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	_, _ = w.Write([]byte(openAPISpec))
-}
-
 // Util para escribir JSON
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(v); err != nil {
-		log.Printf("writeJSON error: %v", err)
+		slog.Error("writeJSON failed", "error", err)
 	}
 }
-
-// Spec OpenAPI minimal en JSON para Swagger.
-const openAPISpec = `{
-  "openapi": "3.0.0",
-  "info": {
-    "title": "Inventory Service API",
-    "version": "1.0.0"
-  },
-  "paths": {
-    "/health": {
-      "get": {
-        "summary": "Health check",
-        "responses": {
-          "200": {
-            "description": "Service is healthy",
-            "content": {
-              "application/json": {
-                "schema": {
-                  "$ref": "#/components/schemas/HealthResponse"
-                }
-              }
-            }
-          }
-        }
-      }
-    },
-    "/api/inventory/{sku}": {
-      "get": {
-        "summary": "Get inventory by sku",
-        "parameters": [
-          {
-            "name": "sku",
-            "in": "path",
-            "required": true,
-            "schema": {
-              "type": "string"
-            }
-          }
-        ],
-        "responses": {
-          "200": {
-            "description": "Inventory found",
-            "content": {
-              "application/json": {
-                "schema": {
-                  "$ref": "#/components/schemas/InventoryResponse"
-                }
-              }
-            }
-          },
-          "404": {
-            "description": "Inventory not found"
-          }
-        }
-      }
-    },
-    "/api/reservations/{orderId}": {
-      "get": {
-        "summary": "Get reservation by order id",
-        "parameters": [
-          {
-            "name": "orderId",
-            "in": "path",
-            "required": true,
-            "schema": {
-              "type": "string",
-              "format": "uuid"
-            }
-          }
-        ],
-        "responses": {
-          "200": {
-            "description": "Reservation found",
-            "content": {
-              "application/json": {
-                "schema": {
-                  "$ref": "#/components/schemas/ReservationResponse"
-                }
-              }
-            }
-          },
-          "404": {
-            "description": "Reservation not found"
-          }
-        }
-      }
-    }
-  },
-  "components": {
-    "schemas": {
-      "HealthResponse": {
-        "type": "object",
-        "properties": {
-          "status": {
-            "type": "string"
-          }
-        }
-      },
-      "InventoryResponse": {
-        "type": "object",
-        "properties": {
-          "sku": {
-            "type": "string"
-          },
-          "available": {
-            "type": "integer"
-          },
-          "reserved": {
-            "type": "integer"
-          }
-        }
-      },
-      "ReservationLineResponse": {
-        "type": "object",
-        "properties": {
-          "sku": {
-            "type": "string"
-          },
-          "quantity": {
-            "type": "integer"
-          }
-        }
-      },
-      "ReservationResponse": {
-        "type": "object",
-        "properties": {
-          "orderId": {
-            "type": "string",
-            "format": "uuid"
-          },
-          "userId": {
-            "type": "string",
-            "format": "uuid"
-          },
-          "status": {
-            "type": "string"
-          },
-          "reservedAtUtc": {
-            "type": "string",
-            "format": "date-time"
-          },
-          "releasedAtUtc": {
-            "type": "string",
-            "format": "date-time",
-            "nullable": true
-          },
-          "lines": {
-            "type": "array",
-            "items": {
-              "$ref": "#/components/schemas/ReservationLineResponse"
-            }
-          }
-        }
-      }
-    }
-  }
-}`
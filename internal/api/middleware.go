@@ -0,0 +1,88 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/tracing"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// statusRecorder guarda el status que escribe el handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap deja que http.ResponseController llegue al writer real (Flush y
+// deadlines del stream SSE).
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrument abre un span de servidor (continuando el traceparent entrante)
+// y observa la latencia HTTP por ruta (plantilla, no path real).
+func instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		metrics.ObserveHTTP(route, r.Method, rec.status, time.Since(start))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// RequestLogging propaga (o genera) X-Request-ID, lo agrega a los logs del
+// request y registra método, ruta, status y duración.
+func RequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := logging.With(r.Context(), logging.KeyRequestID, requestID)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		slog.InfoContext(ctx, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"durationMs", time.Since(start).Milliseconds(),
+		)
+	})
+}
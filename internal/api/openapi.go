@@ -0,0 +1,277 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// OpenAPI genera el spec (OpenAPI 3.0) a partir de routes() y de los tipos
+// de respuesta. Los structs con nombre van a components/schemas.
+func (s *Server) OpenAPI() map[string]any {
+	g := &specGenerator{schemas: map[string]any{}}
+	problemRef := g.schemaOf(reflect.TypeOf(problemDetails{}))
+	codes := make([]string, 0, len(ErrorCodes))
+	for code := range ErrorCodes {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	problem := g.schemas[componentName(reflect.TypeOf(problemDetails{}))].(map[string]any)
+	problem["properties"].(map[string]any)["code"] = map[string]any{"type": "string", "enum": codes}
+
+	paths := map[string]any{}
+	for _, op := range s.routes() {
+		item, ok := paths[op.Path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = g.operation(op, problemRef)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Inventory Service API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+					"description":  "JWT with roles reader, warehouse-operator or admin",
+				},
+				"apiKey": map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-API-Key",
+					"description": "Service-to-service API key",
+				},
+			},
+		},
+	}
+}
+
+// Handler GET /swagger.json
+func (s *Server) handleSwaggerJSON(w http.ResponseWriter, r *http.Request) {
+	body, err := json.MarshalIndent(s.OpenAPI(), "", "  ")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "openapi marshal failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+type specGenerator struct {
+	schemas map[string]any
+}
+
+func (g *specGenerator) operation(op Operation, problemRef map[string]any) map[string]any {
+	out := map[string]any{"summary": op.Summary}
+
+	errs := op.Errors
+	if op.Role != "" {
+		out["description"] = fmt.Sprintf("Requires role %s or higher.", op.Role)
+		out["security"] = []any{
+			map[string]any{"bearerAuth": []any{}},
+			map[string]any{"apiKey": []any{}},
+		}
+		errs = append(errs[:len(errs):len(errs)], CodeUnauthorized, CodeForbidden, CodeInternal)
+	}
+
+	if len(op.Params) > 0 {
+		params := make([]any, 0, len(op.Params))
+		for _, p := range op.Params {
+			schema := map[string]any{"type": p.Type}
+			if p.Format != "" {
+				schema["format"] = p.Format
+			}
+			param := map[string]any{
+				"name":     p.Name,
+				"in":       p.In,
+				"required": p.In == "path",
+				"schema":   schema,
+			}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+		out["parameters"] = params
+	}
+
+	if len(op.Consumes) > 0 {
+		content := map[string]any{}
+		for _, ct := range op.Consumes {
+			content[ct] = map[string]any{"schema": map[string]any{"type": "string"}}
+		}
+		out["requestBody"] = map[string]any{"required": true, "content": content}
+	}
+
+	// Varios Responses con el mismo status son content types alternativos
+	// de una misma respuesta; la descripción es la del primero.
+	responses := map[string]any{}
+	for _, resp := range op.Responses {
+		r, ok := responses[fmt.Sprint(resp.Status)].(map[string]any)
+		if !ok {
+			r = map[string]any{"description": resp.Description}
+			responses[fmt.Sprint(resp.Status)] = r
+		}
+		contentType := resp.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		var schema map[string]any
+		switch {
+		case resp.Body != nil:
+			schema = g.schemaOf(reflect.TypeOf(resp.Body))
+		case resp.ContentType != "":
+			schema = map[string]any{"type": "string"}
+		default:
+			continue
+		}
+		content, _ := r["content"].(map[string]any)
+		if content == nil {
+			content = map[string]any{}
+			r["content"] = content
+		}
+		content[contentType] = map[string]any{"schema": schema}
+	}
+
+	// Un response por status, restringiendo code a los que puede devolver.
+	byStatus := map[int][]ErrorCode{}
+	for _, code := range errs {
+		status := ErrorCodes[code].Status
+		byStatus[status] = append(byStatus[status], code)
+	}
+	for status, codes := range byStatus {
+		titles := make([]string, 0, len(codes))
+		enum := make([]any, 0, len(codes))
+		for _, code := range codes {
+			titles = append(titles, fmt.Sprintf("%s (%s)", ErrorCodes[code].Title, code))
+			enum = append(enum, string(code))
+		}
+		responses[fmt.Sprint(status)] = map[string]any{
+			"description": strings.Join(titles, "; "),
+			"content": map[string]any{
+				problemContentType: map[string]any{
+					"schema": map[string]any{
+						"allOf": []any{
+							problemRef,
+							map[string]any{"properties": map[string]any{"code": map[string]any{"enum": enum}}},
+						},
+					},
+				},
+			},
+		}
+	}
+	out["responses"] = responses
+	return out
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// schemaOf devuelve el schema del tipo; los structs con nombre se registran
+// en components y se devuelven como $ref.
+func (g *specGenerator) schemaOf(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schemaOf(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			return map[string]any{"allOf": []any{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		name := componentName(t)
+		if _, done := g.schemas[name]; !done {
+			g.schemas[name] = map[string]any{} // corta recursión
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *specGenerator) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schemaOf(f.Type)
+		if enum := f.Tag.Get("enum"); enum != "" {
+			values := []any{}
+			for _, v := range strings.Split(enum, ",") {
+				values = append(values, v)
+			}
+			prop["enum"] = values
+		}
+		props[name] = prop
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// componentName: inventoryResponse -> InventoryResponse.
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	if len(name) == 0 {
+		return "Object"
+	}
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func newTestMux(t *testing.T) (*Server, *http.ServeMux) {
+	t.Helper()
+	s := NewServer(config.Default(), ServerDeps{Feed: stockfeed.NewFeed(0)})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	return s, mux
+}
+
+// servedSpec devuelve el spec tal como lo sirve /swagger.json.
+func servedSpec(t *testing.T, mux *http.ServeMux) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /swagger.json = %d", rec.Code)
+	}
+
+	var spec map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("swagger.json is not valid JSON: %v", err)
+	}
+	return spec
+}
+
+// Cada operación del spec tiene que llegar a su handler en el mux, y cada
+// parámetro de path declarado tiene que estar en la plantilla y viceversa.
+func TestSpecOperationsAreRouted(t *testing.T) {
+	_, mux := newTestMux(t)
+	spec := servedSpec(t, mux)
+
+	paths := spec["paths"].(map[string]any)
+	if len(paths) == 0 {
+		t.Fatal("spec has no paths")
+	}
+
+	for path, item := range paths {
+		for method, rawOp := range item.(map[string]any) {
+			method = strings.ToUpper(method)
+			op := rawOp.(map[string]any)
+
+			declared := map[string]bool{}
+			if params, ok := op["parameters"].([]any); ok {
+				for _, p := range params {
+					p := p.(map[string]any)
+					if p["in"] == "path" {
+						declared[p["name"].(string)] = true
+					}
+				}
+			}
+			inTemplate := map[string]bool{}
+			for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+				inTemplate[m[1]] = true
+				if !declared[m[1]] {
+					t.Errorf("%s %s: path parameter %q is not documented", method, path, m[1])
+				}
+			}
+			for name := range declared {
+				if !inTemplate[name] {
+					t.Errorf("%s %s: documented path parameter %q is not in the route", method, path, name)
+				}
+			}
+
+			concrete := pathParam.ReplaceAllString(path, "x")
+			_, pattern := mux.Handler(httptest.NewRequest(method, concrete, nil))
+			if want := method + " " + path; pattern != want {
+				t.Errorf("%s %s is routed to %q, want %q", method, concrete, pattern, want)
+			}
+		}
+	}
+}
+
+// Cada operación registrada tiene que aparecer en el spec servido.
+func TestRoutesAreDocumented(t *testing.T) {
+	s, mux := newTestMux(t)
+	paths := servedSpec(t, mux)["paths"].(map[string]any)
+
+	var missing []string
+	for _, op := range s.routes() {
+		item, ok := paths[op.Path].(map[string]any)
+		if !ok {
+			missing = append(missing, op.Method+" "+op.Path)
+			continue
+		}
+		if _, ok := item[strings.ToLower(op.Method)]; !ok {
+			missing = append(missing, op.Method+" "+op.Path)
+		}
+		if len(op.Responses) == 0 {
+			t.Errorf("%s %s has no documented responses", op.Method, op.Path)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from spec: %v", missing)
+	}
+}
+
+// Los $ref del spec tienen que apuntar a schemas existentes.
+func TestSpecRefsResolve(t *testing.T) {
+	_, mux := newTestMux(t)
+	spec := servedSpec(t, mux)
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch vv := v.(type) {
+		case map[string]any:
+			if ref, ok := vv["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Errorf("unresolved $ref %s", ref)
+				}
+			}
+			for _, child := range vv {
+				walk(child)
+			}
+		case []any:
+			for _, child := range vv {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+}
+
+func TestUnknownMethodAndPathReturnProblems(t *testing.T) {
+	_, mux := newTestMux(t)
+
+	cases := []struct {
+		method, path string
+		status       int
+		code         ErrorCode
+	}{
+		{http.MethodDelete, "/api/inventory/SKU-1", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{http.MethodGet, "/api/admin/quarantine/x/replay", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{http.MethodGet, "/api/nope", http.StatusNotFound, CodeNotFound},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, rec.Code, tc.status)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+			t.Errorf("%s %s content type = %q", tc.method, tc.path, ct)
+		}
+		var p problemDetails
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Code != tc.code {
+			t.Errorf("%s %s problem = %+v (%v), want code %s", tc.method, tc.path, p, err, tc.code)
+		}
+	}
+}
+
+func TestDocsServesSwaggerUI(t *testing.T) {
+	_, mux := newTestMux(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /docs = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`"/docs/swagger-ui-bundle.js"`, `"/docs/swagger-ui.css"`, "swagger.json"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/docs does not reference %s", want)
+		}
+	}
+
+	assets := []struct {
+		path, contentType string
+	}{
+		{"/docs/swagger-ui-bundle.js", "text/javascript"},
+		{"/docs/swagger-ui.css", "text/css"},
+		{"/docs/favicon-32x32.png", "image/png"},
+	}
+	for _, a := range assets {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, a.path, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s = %d with %d bytes", a.path, rec.Code, rec.Body.Len())
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, a.contentType) {
+			t.Errorf("GET %s Content-Type = %q, want %s", a.path, ct, a.contentType)
+		}
+	}
+
+	for _, path := range []string{"/docs/index.html", "/docs/..%2fdocs.go", "/docs/swagger-ui.css.map"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestDocsAssetsURLOverride(t *testing.T) {
+	cfg := config.Default()
+	cfg.HTTP.SwaggerUIAssetsURL = "https://cdn.example.com/swagger-ui-dist@5.18.2/"
+	s := NewServer(cfg, ServerDeps{Feed: stockfeed.NewFeed(0)})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if want := "https://cdn.example.com/swagger-ui-dist@5.18.2/swagger-ui-bundle.js"; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("/docs does not reference %s:\n%s", want, rec.Body.String())
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// ErrorCode es el código estable que los clientes usan para decidir qué
+// hacer con un error; el texto de detail puede cambiar, el código no.
+type ErrorCode string
+
+const (
+	CodeSkuNotFound         ErrorCode = "SKU_NOT_FOUND"
+	CodeInvalidOrderID      ErrorCode = "INVALID_ORDER_ID"
+	CodeReservationNotFound ErrorCode = "RESERVATION_NOT_FOUND"
+	CodeInsufficientStock   ErrorCode = "INSUFFICIENT_STOCK"
+	CodeInvalidParameter    ErrorCode = "INVALID_PARAMETER"
+	CodeInvalidID           ErrorCode = "INVALID_ID"
+	CodeQuarantineNotFound  ErrorCode = "QUARANTINED_MESSAGE_NOT_FOUND"
+	CodeAlreadyReplayed     ErrorCode = "ALREADY_REPLAYED"
+	CodeReplayRejected      ErrorCode = "REPLAY_REJECTED"
+	CodeReplayFailed        ErrorCode = "REPLAY_FAILED"
+	CodeUnauthorized        ErrorCode = "UNAUTHORIZED"
+	CodeForbidden           ErrorCode = "FORBIDDEN"
+	CodeNotFound            ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed    ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeTooManyStreams      ErrorCode = "TOO_MANY_STREAMS"
+	CodeUnsupportedMedia    ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodePayloadTooLarge     ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// problemType es el status y título fijos de cada código.
+type problemType struct {
+	Status int
+	Title  string
+}
+
+// ErrorCodes es el catálogo de errores de la API.
+var ErrorCodes = map[ErrorCode]problemType{
+	CodeSkuNotFound:         {http.StatusNotFound, "SKU not found"},
+	CodeInvalidOrderID:      {http.StatusBadRequest, "Order ID is not a valid UUID"},
+	CodeReservationNotFound: {http.StatusNotFound, "Reservation not found"},
+	CodeInsufficientStock:   {http.StatusConflict, "Insufficient stock"},
+	CodeInvalidParameter:    {http.StatusBadRequest, "Invalid parameter"},
+	CodeInvalidID:           {http.StatusBadRequest, "ID is not a valid UUID"},
+	CodeQuarantineNotFound:  {http.StatusNotFound, "Quarantined message not found"},
+	CodeAlreadyReplayed:     {http.StatusConflict, "Quarantined message already replayed"},
+	CodeReplayRejected:      {http.StatusUnprocessableEntity, "Replay rejected by handler"},
+	CodeReplayFailed:        {http.StatusServiceUnavailable, "Replay failed, try again later"},
+	CodeUnauthorized:        {http.StatusUnauthorized, "Authentication required"},
+	CodeForbidden:           {http.StatusForbidden, "Insufficient role"},
+	CodeNotFound:            {http.StatusNotFound, "Resource not found"},
+	CodeMethodNotAllowed:    {http.StatusMethodNotAllowed, "Method not allowed"},
+	CodeTooManyStreams:      {http.StatusServiceUnavailable, "Too many open streams, try again later"},
+	CodeUnsupportedMedia:    {http.StatusUnsupportedMediaType, "Unsupported content type"},
+	CodePayloadTooLarge:     {http.StatusRequestEntityTooLarge, "Request body too large"},
+	CodeInternal:            {http.StatusInternalServerError, "Internal error"},
+}
+
+const problemContentType = "application/problem+json"
+
+// problemDetails es el cuerpo RFC 7807 más el código de la API.
+type problemDetails struct {
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Status   int       `json:"status"`
+	Detail   string    `json:"detail,omitempty"`
+	Instance string    `json:"instance,omitempty"`
+	Code     ErrorCode `json:"code"`
+}
+
+// problemTypeURI arma el type del problema a partir del código
+// (SKU_NOT_FOUND -> urn:eventshop:inventory:problem:sku-not-found).
+func problemTypeURI(code ErrorCode) string {
+	return "urn:eventshop:inventory:problem:" + strings.ToLower(strings.ReplaceAll(string(code), "_", "-"))
+}
+
+// writeProblem responde application/problem+json con el status del código.
+func writeProblem(w http.ResponseWriter, r *http.Request, code ErrorCode, detail string) {
+	pt, ok := ErrorCodes[code]
+	if !ok {
+		code, pt = CodeInternal, ErrorCodes[CodeInternal]
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(pt.Status)
+	err := json.NewEncoder(w).Encode(problemDetails{
+		Type:     problemTypeURI(code),
+		Title:    pt.Title,
+		Status:   pt.Status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "writeProblem failed", "error", err)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeProblem(w, r, CodeMethodNotAllowed, r.Method+" is not supported on this route")
+}
+
+// Handler para rutas no registradas.
+func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, CodeNotFound, "")
+}
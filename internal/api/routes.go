@@ -0,0 +1,243 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
+)
+
+// Operation es un método HTTP sobre una ruta. La misma definición registra
+// el handler en el mux y genera el spec OpenAPI, así no pueden divergir.
+type Operation struct {
+	Method  string
+	Path    string // plantilla de ServeMux/OpenAPI, ej. /api/inventory/{sku}
+	Summary string
+	Role    auth.Role // "" = pública
+	Params  []Param
+	// Consumes son los content types aceptados en el cuerpo (nil = sin
+	// cuerpo); el cuerpo se documenta como texto.
+	Consumes []string
+	// Responses exitosas; las de error salen de Errors.
+	Responses []Response
+	Errors    []ErrorCode
+	Handler   http.HandlerFunc
+}
+
+// Param es un parámetro de path o query.
+type Param struct {
+	Name        string
+	In          string // "path" o "query"
+	Type        string // "string", "integer", "boolean"
+	Format      string
+	Description string
+}
+
+// Response documenta un status exitoso. Body es un valor del tipo devuelto
+// (nil = sin cuerpo).
+type Response struct {
+	Status      int
+	Description string
+	ContentType string // default application/json
+	Body        any
+}
+
+// routes lista todas las operaciones de la API.
+func (s *Server) routes() []Operation {
+	return []Operation{
+		{
+			Method:    http.MethodGet,
+			Path:      "/health",
+			Summary:   "Health check (legacy, same as /health/live)",
+			Responses: []Response{{Status: http.StatusOK, Description: "OK", Body: healthResponse{}}},
+			Handler:   s.handleHealth,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/health/live",
+			Summary:   "Liveness probe",
+			Responses: []Response{{Status: http.StatusOK, Description: "Process is alive", Body: healthResponse{}}},
+			Handler:   s.handleLiveness,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/health/ready",
+			Summary: "Readiness probe (database, broker consumers, outbox backlog)",
+			Responses: []Response{
+				{Status: http.StatusOK, Description: "Ready to receive traffic", Body: readinessResponse{}},
+				{Status: http.StatusServiceUnavailable, Description: "At least one component is down", Body: readinessResponse{}},
+			},
+			Handler: s.handleReadiness,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/inventory/{sku}",
+			Summary: "Get stock by SKU",
+			Role:    auth.RoleReader,
+			Params: []Param{
+				{Name: "sku", In: "path", Type: "string"},
+				{Name: "asOf", In: "query", Type: "string", Format: "date-time", Description: "Return the level the SKU had at this instant (RFC 3339) instead of the current one"},
+			},
+			Responses: []Response{{Status: http.StatusOK, Description: "Stock item", Body: inventoryResponse{}}},
+			Errors:    []ErrorCode{CodeInvalidParameter, CodeSkuNotFound},
+			Handler:   s.handleGetInventoryBySku,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/inventory/report",
+			Summary: "Stock levels of every SKU at a past instant",
+			Role:    auth.RoleReader,
+			Params: []Param{
+				{Name: "asOf", In: "query", Type: "string", Format: "date-time", Description: "Instant of the report (RFC 3339), required"},
+				{Name: "sku", In: "query", Type: "string", Description: "Only these SKUs; repeat the parameter or separate with commas. Disables paging"},
+				{Name: "after", In: "query", Type: "string", Description: "Return SKUs after this one; use nextAfter of the previous page"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Max items, 1..1000 (default 1000)"},
+			},
+			Responses: []Response{{Status: http.StatusOK, Description: "Stock levels ordered by SKU", Body: stockReportResponse{}}},
+			Errors:    []ErrorCode{CodeInvalidParameter},
+			Handler:   s.handleStockReport,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/inventory/stream",
+			Summary: "Stream stock changes as Server-Sent Events",
+			Role:    auth.RoleReader,
+			Params: []Param{
+				{Name: "sku", In: "query", Type: "string", Description: "SKU to watch; repeat the parameter or separate with commas"},
+			},
+			Responses: []Response{{
+				Status:      http.StatusOK,
+				Description: "Event stream: one \"stock\" event per SKU with its current level, then one per change; comment lines as heartbeat",
+				ContentType: "text/event-stream",
+				Body:        stockEvent{},
+			}},
+			Errors:  []ErrorCode{CodeInvalidParameter, CodeTooManyStreams},
+			Handler: s.handleStockStream,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/inventory/import",
+			Summary: "Bulk load stock levels from CSV or NDJSON",
+			Role:    auth.RoleWarehouseOperator,
+			Params: []Param{
+				{Name: "dryRun", In: "query", Type: "boolean", Description: "Validate and report the changes without writing them (default false)"},
+			},
+			Consumes: []string{"text/csv", "application/x-ndjson"},
+			Responses: []Response{
+				{Status: http.StatusOK, Description: "Import applied, or validated with dryRun", Body: application.ImportResult{}},
+				{Status: http.StatusUnprocessableEntity, Description: "Rows with errors; nothing was written", Body: application.ImportResult{}},
+			},
+			Errors:  []ErrorCode{CodeInvalidParameter, CodeUnsupportedMedia, CodePayloadTooLarge},
+			Handler: s.handleImportStock,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/inventory/export",
+			Summary: "Export all stock items, and optionally active reservations, as CSV, NDJSON or Parquet",
+			Role:    auth.RoleReader,
+			Params: []Param{
+				{Name: "format", In: "query", Type: "string", Description: "csv, ndjson or parquet (default csv)"},
+				{Name: "reservations", In: "query", Type: "boolean", Description: "Also export one row per line of each active reservation (default false)"},
+			},
+			Responses: []Response{
+				{
+					Status: http.StatusOK,
+					Description: "Rows with columns kind (stock or reservation), sku, available, reserved, updated_at_utc, " +
+						"order_id, user_id, quantity and reserved_at_utc; columns of the other kind are empty",
+					ContentType: "text/csv",
+				},
+				{Status: http.StatusOK, ContentType: "application/x-ndjson"},
+				{Status: http.StatusOK, ContentType: "application/vnd.apache.parquet"},
+			},
+			Errors:  []ErrorCode{CodeInvalidParameter},
+			Handler: s.handleExportStock,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/reservations/{orderId}",
+			Summary: "Get reservation by order ID",
+			Role:    auth.RoleReader,
+			Params: []Param{
+				{Name: "orderId", In: "path", Type: "string", Format: "uuid"},
+			},
+			Responses: []Response{{Status: http.StatusOK, Description: "Reservation", Body: reservationResponse{}}},
+			Errors:    []ErrorCode{CodeInvalidOrderID, CodeReservationNotFound},
+			Handler:   s.handleGetReservationByOrder,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/admin/quarantine",
+			Summary: "List quarantined inbound messages",
+			Role:    auth.RoleAdmin,
+			Params: []Param{
+				{Name: "pending", In: "query", Type: "boolean", Description: "Only not yet replayed messages (default true)"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Max results, 1..1000 (default 100)"},
+			},
+			Responses: []Response{{Status: http.StatusOK, Description: "Quarantined messages", Body: []quarantinedMessageResponse{}}},
+			Errors:    []ErrorCode{CodeInvalidParameter},
+			Handler:   s.handleListQuarantine,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/admin/quarantine/{id}",
+			Summary: "Get a quarantined message",
+			Role:    auth.RoleAdmin,
+			Params: []Param{
+				{Name: "id", In: "path", Type: "string", Format: "uuid"},
+			},
+			Responses: []Response{{Status: http.StatusOK, Description: "Quarantined message", Body: quarantinedMessageResponse{}}},
+			Errors:    []ErrorCode{CodeInvalidID, CodeQuarantineNotFound},
+			Handler:   s.handleGetQuarantined,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/admin/quarantine/{id}/replay",
+			Summary: "Replay a quarantined message through its handler",
+			Role:    auth.RoleAdmin,
+			Params: []Param{
+				{Name: "id", In: "path", Type: "string", Format: "uuid"},
+			},
+			Responses: []Response{{Status: http.StatusNoContent, Description: "Replayed"}},
+			Errors: []ErrorCode{
+				CodeInvalidID, CodeQuarantineNotFound, CodeAlreadyReplayed, CodeReplayRejected, CodeReplayFailed,
+			},
+			Handler: s.handleReplayQuarantined,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/swagger.json",
+			Summary:   "OpenAPI spec of this API",
+			Responses: []Response{{Status: http.StatusOK, Description: "OpenAPI 3 document", Body: map[string]any{}}},
+			Handler:   s.handleSwaggerJSON,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/docs",
+			Summary:   "Swagger UI",
+			Responses: []Response{{Status: http.StatusOK, Description: "HTML page", ContentType: "text/html"}},
+			Handler:   s.handleDocs,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/docs/{file}",
+			Summary: "Swagger UI assets embedded in the binary",
+			Params:  []Param{{Name: "file", In: "path", Type: "string"}},
+			Responses: []Response{
+				{Status: http.StatusOK, Description: "Stylesheet", ContentType: "text/css"},
+				{Status: http.StatusOK, Description: "Script", ContentType: "text/javascript"},
+				{Status: http.StatusOK, Description: "Icon", ContentType: "image/png"},
+			},
+			Errors:  []ErrorCode{CodeNotFound},
+			Handler: s.handleDocsAsset,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/metrics",
+			Summary:   "Prometheus metrics",
+			Responses: []Response{{Status: http.StatusOK, Description: "Prometheus text format", ContentType: "text/plain"}},
+			Handler:   promhttp.Handler().ServeHTTP,
+		},
+	}
+}
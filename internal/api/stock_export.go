@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+)
+
+// exportContentTypes mapea el formato del export a su Content-Type.
+var exportContentTypes = map[string]string{
+	application.ExportFormatCSV:     "text/csv; charset=utf-8",
+	application.ExportFormatNDJSON:  "application/x-ndjson",
+	application.ExportFormatParquet: "application/vnd.apache.parquet",
+}
+
+// Handler GET /api/inventory/export?format=csv&reservations=true
+func (s *Server) handleExportStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = application.ExportFormatCSV
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		writeProblem(w, r, CodeInvalidParameter, "format must be csv, ndjson or parquet")
+		return
+	}
+	withReservations := false
+	if v := q.Get("reservations"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeProblem(w, r, CodeInvalidParameter, "reservations must be true or false")
+			return
+		}
+		withReservations = b
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="inventory-export.`+format+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	// El WriteTimeout del servidor cortaría un export grande: cada escritura
+	// tiene su propio plazo, como en el stream SSE.
+	out := &deadlineWriter{
+		w:       w,
+		rc:      http.NewResponseController(w),
+		timeout: time.Duration(s.cfg.HTTP.WriteTimeoutSec) * time.Second,
+	}
+	stats, err := s.exporter.Export(ctx, out, format, withReservations)
+	if err != nil && !out.written {
+		slog.ErrorContext(ctx, "stock export failed", "error", err, "format", format)
+		w.Header().Del("Content-Disposition")
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+	if err != nil {
+		// Con el cuerpo a medias ya no se puede responder un problem: cortar
+		// la conexión para que el cliente no tome el archivo como completo.
+		slog.ErrorContext(ctx, "stock export failed", "error", err, "format", format)
+		panic(http.ErrAbortHandler)
+	}
+	slog.InfoContext(ctx, "stock export finished",
+		"format", format, "stockItems", stats.StockItems,
+		"reservations", stats.Reservations, "reservationLines", stats.ReservationLines)
+}
+
+// deadlineWriter renueva el plazo de escritura de la respuesta antes de
+// cada Write.
+type deadlineWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+	written bool
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	if err := d.rc.SetWriteDeadline(time.Now().Add(d.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+	d.written = true
+	return d.w.Write(p)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+func TestExportStock(t *testing.T) {
+	store := memory.NewStore()
+	stock := memory.NewStockItemRepository(store)
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if err := stock.UpsertMany(context.Background(), []*domain.StockItem{
+		{Sku: "B", Available: 4, Reserved: 1, UpdatedAtUtc: at},
+		{Sku: "A", Available: 10, UpdatedAtUtc: at},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	exporter := application.NewStockExportService(memory.NewStockExportRepository(store), 1)
+
+	s := NewServer(config.Default(), ServerDeps{StockRepo: stock, Exporter: exporter, Feed: stockfeed.NewFeed(0)})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	export := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := export("/api/inventory/export")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="inventory-export.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "stock,A,10,0,2026-10-01T00:00:00Z") || !strings.HasPrefix(lines[2], "stock,B,4,1,") {
+		t.Errorf("csv body = %q", rec.Body.String())
+	}
+
+	rec = export("/api/inventory/export?format=ndjson&reservations=true")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("ndjson = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var skus []string
+	sc := bufio.NewScanner(rec.Body)
+	for sc.Scan() {
+		var row struct {
+			Kind string `json:"kind"`
+			Sku  string `json:"sku"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+			t.Fatal(err)
+		}
+		skus = append(skus, row.Kind+":"+row.Sku)
+	}
+	if strings.Join(skus, ",") != "stock:A,stock:B" {
+		t.Errorf("ndjson rows = %v", skus)
+	}
+
+	rec = export("/api/inventory/export?format=parquet")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "PAR1") {
+		t.Errorf("parquet = %d, %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	for _, target := range []string{"/api/inventory/export?format=xlsx", "/api/inventory/export?reservations=maybe"} {
+		if rec := export(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// maxReportLimit es el tope de items por página (y de SKUs filtrados) del
+// reporte.
+const maxReportLimit = 1000
+
+// Respuesta de GET /api/inventory/report. NextAfter viene solo si puede
+// haber más páginas.
+type stockReportResponse struct {
+	AsOf      string              `json:"asOf"`
+	Items     []inventoryResponse `json:"items"`
+	NextAfter string              `json:"nextAfter,omitempty"`
+}
+
+func toLevelResponse(level domain.StockLevel) inventoryResponse {
+	return inventoryResponse{
+		Sku:          level.Sku,
+		Available:    level.Available,
+		Reserved:     level.Reserved,
+		ChangedAtUtc: level.ChangedAtUtc.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// parseAsOf lee ?asOf= en RFC 3339 (con offset o Z).
+func parseAsOf(r *http.Request) (time.Time, error) {
+	v := r.URL.Query().Get("asOf")
+	if v == "" {
+		return time.Time{}, errors.New("asOf is required")
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.New("asOf must be an RFC 3339 timestamp, e.g. 2026-09-30T23:59:59Z")
+	}
+	return at, nil
+}
+
+// getInventoryAsOf responde GET /api/inventory/{sku}?asOf= desde el
+// historial. Un SKU sin cambios hasta asOf es 404, aunque exista hoy.
+func (s *Server) getInventoryAsOf(w http.ResponseWriter, r *http.Request, sku string) {
+	at, err := parseAsOf(r)
+	if err != nil {
+		writeProblem(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	levels, err := s.history.AsOf(r.Context(), []string{sku}, at)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "stock history AsOf failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+	level, ok := levels[sku]
+	if !ok {
+		writeProblem(w, r, CodeSkuNotFound, "no stock history for sku "+sku+" at "+at.UTC().Format(time.RFC3339))
+		return
+	}
+	resp := toLevelResponse(level)
+	resp.AsOf = at.UTC().Format(time.RFC3339)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Handler GET /api/inventory/report?asOf=&sku=&after=&limit=
+func (s *Server) handleStockReport(w http.ResponseWriter, r *http.Request) {
+	at, err := parseAsOf(r)
+	if err != nil {
+		writeProblem(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	limit := maxReportLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxReportLimit {
+			writeProblem(w, r, CodeInvalidParameter, fmt.Sprintf("limit must be between 1 and %d", maxReportLimit))
+			return
+		}
+		limit = n
+	}
+
+	resp := stockReportResponse{AsOf: at.UTC().Format(time.RFC3339), Items: []inventoryResponse{}}
+	if skus := splitSkus(query["sku"]); len(skus) > 0 {
+		if len(skus) > maxReportLimit {
+			writeProblem(w, r, CodeInvalidParameter, fmt.Sprintf("at most %d skus per report", maxReportLimit))
+			return
+		}
+		levels, err := s.history.AsOf(r.Context(), skus, at)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "stock history AsOf failed", "error", err)
+			writeProblem(w, r, CodeInternal, "")
+			return
+		}
+		sort.Strings(skus)
+		for _, sku := range skus {
+			if level, ok := levels[sku]; ok {
+				resp.Items = append(resp.Items, toLevelResponse(level))
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	levels, err := s.history.ReportAsOf(r.Context(), at, query.Get("after"), limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "stock history ReportAsOf failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+	for _, level := range levels {
+		resp.Items = append(resp.Items, toLevelResponse(level))
+	}
+	if len(levels) == limit {
+		resp.NextAfter = levels[len(levels)-1].Sku
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
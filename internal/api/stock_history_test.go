@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+// newHistoryMux sirve la API sobre un store en memoria con A: 10/0 el
+// 2026-09-15 y 7/3 el 2026-10-02, y B: 4/1 el 2026-09-20.
+func newHistoryMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	store := memory.NewStore()
+	stock := memory.NewStockItemRepository(store)
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 12, 0, 0, 0, time.UTC) }
+	for _, item := range []*domain.StockItem{
+		{Sku: "A", Available: 10, UpdatedAtUtc: day(time.September, 15)},
+		{Sku: "B", Available: 4, Reserved: 1, UpdatedAtUtc: day(time.September, 20)},
+		{Sku: "A", Available: 7, Reserved: 3, UpdatedAtUtc: day(time.October, 2)},
+	} {
+		if err := stock.UpsertMany(context.Background(), []*domain.StockItem{item}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewServer(config.Default(), ServerDeps{
+		StockRepo: stock,
+		History:   memory.NewStockHistoryRepository(store),
+		Feed:      stockfeed.NewFeed(0),
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	return mux
+}
+
+func get(t *testing.T, mux *http.ServeMux, target string, out any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if out != nil && rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("GET %s: %v", target, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestGetInventoryAsOf(t *testing.T) {
+	mux := newHistoryMux(t)
+
+	var current, monthEnd inventoryResponse
+	if code := get(t, mux, "/api/inventory/A", &current); code != http.StatusOK || current.Available != 7 || current.AsOf != "" {
+		t.Errorf("current = %d %+v, want 7/3 without asOf", code, current)
+	}
+	code := get(t, mux, "/api/inventory/A?asOf=2026-09-30T23:59:59Z", &monthEnd)
+	if code != http.StatusOK || monthEnd.Available != 10 || monthEnd.Reserved != 0 ||
+		monthEnd.AsOf != "2026-09-30T23:59:59Z" || monthEnd.ChangedAtUtc != "2026-09-15T12:00:00Z" {
+		t.Errorf("month end = %d %+v, want 10/0 since 2026-09-15", code, monthEnd)
+	}
+
+	if code := get(t, mux, "/api/inventory/A?asOf=2026-09-01T00:00:00Z", nil); code != http.StatusNotFound {
+		t.Errorf("before the first change = %d, want 404", code)
+	}
+	if code := get(t, mux, "/api/inventory/A?asOf=yesterday", nil); code != http.StatusBadRequest {
+		t.Errorf("invalid asOf = %d, want 400", code)
+	}
+}
+
+func TestStockReport(t *testing.T) {
+	mux := newHistoryMux(t)
+
+	var page stockReportResponse
+	if code := get(t, mux, "/api/inventory/report?asOf=2026-09-30T23:59:59Z&limit=1", &page); code != http.StatusOK ||
+		len(page.Items) != 1 || page.Items[0].Sku != "A" || page.Items[0].Available != 10 || page.NextAfter != "A" {
+		t.Fatalf("first page = %d %+v", code, page)
+	}
+	page = stockReportResponse{}
+	if code := get(t, mux, "/api/inventory/report?asOf=2026-09-30T23:59:59Z&limit=1&after=A", &page); code != http.StatusOK ||
+		len(page.Items) != 1 || page.Items[0].Sku != "B" || page.Items[0].Reserved != 1 {
+		t.Fatalf("second page = %d %+v", code, page)
+	}
+
+	page = stockReportResponse{}
+	if code := get(t, mux, "/api/inventory/report?asOf=2026-10-31T00:00:00Z&sku=B,A,MISSING", &page); code != http.StatusOK ||
+		len(page.Items) != 2 || page.Items[0].Sku != "A" || page.Items[0].Available != 7 || page.NextAfter != "" {
+		t.Errorf("filtered = %d %+v, want A 7/3 and B", code, page)
+	}
+
+	for _, target := range []string{"/api/inventory/report", "/api/inventory/report?asOf=2026-10-31T00:00:00Z&limit=0"} {
+		if code := get(t, mux, target, nil); code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, code)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+)
+
+// importFormats mapea el Content-Type del cuerpo al formato del import.
+var importFormats = map[string]string{
+	"text/csv":             application.ImportFormatCSV,
+	"application/x-ndjson": application.ImportFormatNDJSON,
+	"application/ndjson":   application.ImportFormatNDJSON,
+}
+
+// Handler POST /api/inventory/import?dryRun=true
+func (s *Server) handleImportStock(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := importFormats[mediaType]
+	if !ok {
+		writeProblem(w, r, CodeUnsupportedMedia, "send text/csv or application/x-ndjson")
+		return
+	}
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeProblem(w, r, CodeInvalidParameter, "dryRun must be true or false")
+			return
+		}
+		dryRun = b
+	}
+
+	// El ReadTimeout del servidor no alcanza para subir un archivo grande:
+	// cada lectura del cuerpo tiene su propio plazo, como las escrituras del
+	// export. Al terminar, la respuesta tiene un WriteTimeout entero.
+	rc := http.NewResponseController(w)
+	body := &deadlineReader{
+		r:       http.MaxBytesReader(w, r.Body, int64(s.cfg.Import.MaxBodyMB)<<20),
+		rc:      rc,
+		timeout: time.Duration(s.cfg.HTTP.ReadTimeoutSec) * time.Second,
+	}
+	result, err := s.importer.Import(r.Context(), body, format, dryRun)
+	if err := rc.SetWriteDeadline(time.Now().Add(time.Duration(s.cfg.HTTP.WriteTimeoutSec) * time.Second)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(r.Context(), "failed to extend the import write deadline", "error", err)
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeProblem(w, r, CodePayloadTooLarge, "import files are limited to "+strconv.Itoa(s.cfg.Import.MaxBodyMB)+" MB")
+		return
+	case errors.Is(err, bufio.ErrTooLong):
+		writeProblem(w, r, CodeInvalidParameter, "NDJSON lines are limited to 1 MB")
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "stock import failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+
+	status := http.StatusOK
+	if result.ErrorCount > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, result)
+}
+
+// deadlineReader renueva el plazo de lectura del cuerpo antes de cada Read.
+type deadlineReader struct {
+	r       io.Reader
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	if err := d.rc.SetReadDeadline(time.Now().Add(d.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+	return d.r.Read(p)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+func TestImportStock(t *testing.T) {
+	store := memory.NewStore()
+	stock := memory.NewStockItemRepository(store)
+	clock := domain.NewManualClock(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Second)
+	ids := domain.NewSequentialIDGenerator(t.Name())
+	writer := application.NewOutboxWriter(memory.NewOutboxRepository(store), clock, ids)
+	importer := application.NewStockImportService(memory.NewUnitOfWork(store), stock, writer, 100, clock, ids)
+	cfg := config.Default()
+	cfg.Import.MaxBodyMB = 1
+
+	s := NewServer(cfg, ServerDeps{StockRepo: stock, Importer: importer, Feed: stockfeed.NewFeed(0)})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+
+	post := func(target, contentType, body string) (*httptest.ResponseRecorder, application.ImportResult) {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var resp application.ImportResult
+		if rec.Header().Get("Content-Type") == "application/json" {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("POST %s: %v", target, err)
+			}
+		}
+		return rec, resp
+	}
+
+	rec, resp := post("/api/inventory/import?dryRun=true", "text/csv; charset=utf-8", "sku,quantity\nA,3\n")
+	if rec.Code != http.StatusOK || !resp.DryRun || resp.Applied || resp.Created != 1 || len(store.StockItems()) != 0 {
+		t.Errorf("dry run = %d %+v", rec.Code, resp)
+	}
+
+	rec, resp = post("/api/inventory/import", "application/x-ndjson", `{"sku":"A","quantity":3}`+"\n"+`{"sku":"B","quantity":-1}`)
+	if rec.Code != http.StatusUnprocessableEntity || resp.ErrorCount != 1 || resp.Errors[0].Line != 2 || resp.Errors[0].Sku != "B" {
+		t.Errorf("invalid rows = %d %+v", rec.Code, resp)
+	}
+
+	rec, resp = post("/api/inventory/import", "text/csv", "sku,quantity\nA,3\n")
+	if rec.Code != http.StatusOK || !resp.Applied || resp.Created != 1 || len(store.StockItems()) != 1 || len(store.OutboxMessages()) != 1 {
+		t.Errorf("import = %d %+v", rec.Code, resp)
+	}
+
+	if rec, _ := post("/api/inventory/import", "application/json", "[]"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("json body = %d, want 415", rec.Code)
+	}
+	big := "sku,quantity\n" + strings.Repeat("SKU-LONG-NAME,1\n", 1<<17)
+	if rec, _ := post("/api/inventory/import", "text/csv", big); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("2 MB body = %d, want 413", rec.Code)
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+// Evento "stock" del stream SSE.
+type stockEvent struct {
+	Sku       string    `json:"sku"`
+	Available int       `json:"available"`
+	Reserved  int       `json:"reserved"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Handler GET /api/inventory/stream?sku=A&sku=B: manda el nivel actual de
+// cada SKU existente y luego cada cambio. Un cliente lento recibe solo el
+// último nivel de cada SKU (ver stockfeed.Feed); uno que no lee en
+// stream.writeTimeoutSec se desconecta.
+func (s *Server) handleStockStream(w http.ResponseWriter, r *http.Request) {
+	skus, err := streamSkus(r.URL.Query()["sku"], s.cfg.Stream.MaxSkus)
+	if err != nil {
+		writeProblem(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+	ctx := logging.With(r.Context(), "skus", len(skus))
+
+	// Suscribir antes de leer el snapshot para no perder cambios en medio.
+	sub, err := s.feed.Subscribe(skus)
+	if err != nil {
+		// Lleno o apagándose: el cliente reintenta más tarde.
+		w.Header().Set("Retry-After", "5")
+		writeProblem(w, r, CodeTooManyStreams, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	items, err := s.stockRepo.GetBySkus(ctx, skus)
+	if err != nil {
+		slog.ErrorContext(ctx, "GetBySkus failed", "error", err)
+		writeProblem(w, r, CodeInternal, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // sin buffer en nginx
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	writeTimeout := time.Duration(s.cfg.Stream.WriteTimeoutSec) * time.Second
+	send := func(chunk string) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if _, err := fmt.Fprint(w, chunk); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	snapshot := make([]stockfeed.Change, 0, len(items))
+	for _, sku := range skus {
+		if item, ok := items[sku]; ok {
+			snapshot = append(snapshot, stockfeed.ChangeOf(item))
+		}
+	}
+	if err := send("retry: 3000\n\n" + stockEvents(snapshot)); err != nil {
+		slog.InfoContext(ctx, "stock stream closed", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(time.Duration(s.cfg.Stream.HeartbeatSec) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		var chunk string
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			return
+		case <-heartbeat.C:
+			chunk = ": heartbeat\n\n"
+		case <-sub.Ready():
+			chunk = stockEvents(sub.Pending())
+		}
+		if chunk == "" {
+			continue
+		}
+		if err := send(chunk); err != nil {
+			slog.InfoContext(ctx, "stock stream closed", "error", err)
+			return
+		}
+	}
+}
+
+// stockEvents arma un evento SSE "stock" por cambio.
+func stockEvents(changes []stockfeed.Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		data, _ := json.Marshal(stockEvent{
+			Sku:       c.Sku,
+			Available: c.Available,
+			Reserved:  c.Reserved,
+			UpdatedAt: c.UpdatedAt,
+		})
+		fmt.Fprintf(&b, "event: stock\ndata: %s\n\n", data)
+	}
+	return b.String()
+}
+
+// splitSkus junta los ?sku= (repetidos o separados por comas) sin
+// duplicados.
+func splitSkus(values []string) []string {
+	seen := map[string]bool{}
+	var skus []string
+	for _, v := range values {
+		for _, sku := range strings.Split(v, ",") {
+			sku = strings.TrimSpace(sku)
+			if sku == "" || seen[sku] {
+				continue
+			}
+			seen[sku] = true
+			skus = append(skus, sku)
+		}
+	}
+	return skus
+}
+
+// streamSkus valida los SKUs de splitSkus contra el máximo por stream.
+func streamSkus(values []string, max int) ([]string, error) {
+	skus := splitSkus(values)
+	if len(skus) == 0 {
+		return nil, errors.New("at least one sku is required")
+	}
+	if len(skus) > max {
+		return nil, fmt.Errorf("at most %d skus per stream", max)
+	}
+	return skus, nil
+}
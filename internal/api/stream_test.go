@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+// sseReader lee un stream SSE de a un evento (líneas hasta una en blanco).
+type sseReader struct {
+	t *testing.T
+	r *bufio.Reader
+}
+
+func (s *sseReader) next() string {
+	s.t.Helper()
+	var lines []string
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			s.t.Fatalf("reading the stream: %v (read %q)", err, lines)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "\n")
+		}
+		lines = append(lines, line)
+	}
+}
+
+// stock lee el próximo evento "stock", salteando heartbeats.
+func (s *sseReader) stock() stockEvent {
+	s.t.Helper()
+	for {
+		frame := s.next()
+		if frame == ": heartbeat" {
+			continue
+		}
+		data, ok := strings.CutPrefix(frame, "event: stock\ndata: ")
+		if !ok {
+			s.t.Fatalf("frame = %q, want a stock event", frame)
+		}
+		var ev stockEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			s.t.Fatal(err)
+		}
+		return ev
+	}
+}
+
+func TestStockStream(t *testing.T) {
+	store := memory.NewStore()
+	feed := stockfeed.NewFeed(1)
+	stock := stockfeed.NewRepository(memory.NewStockItemRepository(store), feed)
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if err := stock.UpsertMany(context.Background(), []*domain.StockItem{
+		{Sku: "A", Available: 10, UpdatedAtUtc: at},
+		{Sku: "B", Available: 4, Reserved: 1, UpdatedAtUtc: at},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Stream.MaxSkus = 3
+	cfg.Stream.HeartbeatSec = 1
+	s := NewServer(cfg, ServerDeps{StockRepo: stock, Feed: feed})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer feed.Close() // corta el stream abierto antes de srv.Close
+
+	get := func(query string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/api/inventory/stream" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := get("?sku=B,A&sku=MISSING&sku=A")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET stream = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	events := &sseReader{t: t, r: bufio.NewReader(resp.Body)}
+	if frame := events.next(); frame != "retry: 3000" {
+		t.Fatalf("first frame = %q, want the retry hint", frame)
+	}
+
+	// Primero el snapshot, en el orden pedido y sin los SKUs inexistentes...
+	want := []stockEvent{
+		{Sku: "B", Available: 4, Reserved: 1, UpdatedAt: at},
+		{Sku: "A", Available: 10, UpdatedAt: at},
+	}
+	for _, w := range want {
+		if got := events.stock(); got != w {
+			t.Errorf("snapshot event = %+v, want %+v", got, w)
+		}
+	}
+
+	// ...y después cada cambio.
+	later := at.Add(time.Hour)
+	if err := stock.UpsertMany(context.Background(), []*domain.StockItem{
+		{Sku: "A", Available: 9, Reserved: 1, UpdatedAtUtc: later},
+		{Sku: "C", Available: 1, UpdatedAtUtc: later},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, w := events.stock(), (stockEvent{Sku: "A", Available: 9, Reserved: 1, UpdatedAt: later}); got != w {
+		t.Errorf("change event = %+v, want %+v", got, w)
+	}
+
+	// Sin cambios solo llegan heartbeats.
+	if frame := events.next(); frame != ": heartbeat" {
+		t.Errorf("idle frame = %q, want a heartbeat", frame)
+	}
+
+	// El feed admite un suscriptor y ya está ocupado.
+	full := get("?sku=A")
+	full.Body.Close()
+	if full.StatusCode != http.StatusServiceUnavailable || full.Header.Get("Retry-After") == "" {
+		t.Errorf("GET stream over maxSubscribers = %d (Retry-After %q), want 503", full.StatusCode, full.Header.Get("Retry-After"))
+	}
+
+	for _, query := range []string{"", "?sku=,", "?sku=A,B,C,D"} {
+		resp := get(query)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET stream%s = %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
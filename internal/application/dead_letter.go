@@ -0,0 +1,13 @@
+package application
+
+import (
+	"context"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+)
+
+// DeadLetterWriter recibe los mensajes entrantes que no se pueden procesar
+// (payload inválido, versión desconocida, ...).
+type DeadLetterWriter interface {
+	DeadLetter(ctx context.Context, env *primitives.IntegrationEventEnvelope, reason error) error
+}
@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+// fixtureStart es la hora inicial del reloj de los fixtures.
+var fixtureStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fixture arma los servicios sobre repositorios en memoria, con reloj e IDs
+// deterministas: dos fixtures con el mismo stock y las mismas operaciones
+// producen exactamente el mismo outbox.
+type fixture struct {
+	store        *memory.Store
+	uow          *memory.UnitOfWork
+	stock        *memory.StockItemRepository
+	reservations *memory.StockReservationRepository
+	outbox       *memory.OutboxRepository
+	clock        *domain.ManualClock
+	ids          *domain.SequentialIDGenerator
+	writer       OutboxWriter
+	reserve      *ReserveStockService
+	release      *ReleaseReservationService
+}
+
+// newItem crea un StockItem nuevo con el reloj y los ids del fixture.
+func (f *fixture) newItem(t *testing.T, sku string, available int) *domain.StockItem {
+	t.Helper()
+	item, err := domain.NewStockItem(f.ids.NewID(), sku, available, f.clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func newFixture(t *testing.T, stock map[string]int) *fixture {
+	t.Helper()
+	store := memory.NewStore()
+	f := &fixture{
+		store:        store,
+		uow:          memory.NewUnitOfWork(store),
+		stock:        memory.NewStockItemRepository(store),
+		reservations: memory.NewStockReservationRepository(store),
+		outbox:       memory.NewOutboxRepository(store),
+		clock:        domain.NewManualClock(fixtureStart, time.Second),
+		ids:          domain.NewSequentialIDGenerator(t.Name()),
+	}
+	f.writer = NewOutboxWriter(f.outbox, f.clock, f.ids)
+	f.reserve = NewReserveStockService(f.uow, f.stock, f.reservations, f.writer, f.clock, f.ids)
+	f.release = NewReleaseReservationService(f.uow, f.stock, f.reservations, f.writer, f.clock, f.ids)
+
+	skus := make([]string, 0, len(stock))
+	for sku := range stock {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+	items := make([]*domain.StockItem, 0, len(stock))
+	for _, sku := range skus {
+		items = append(items, f.newItem(t, sku, stock[sku]))
+	}
+	if err := f.stock.UpsertMany(context.Background(), items); err != nil {
+		t.Fatalf("seed stock: %v", err)
+	}
+	return f
+}
+
+// levels es available/reserved por SKU.
+type levels map[string][2]int
+
+func (f *fixture) levels() levels {
+	out := levels{}
+	for _, item := range f.store.StockItems() {
+		out[item.Sku] = [2]int{item.Available, item.Reserved}
+	}
+	return out
+}
+
+// eventTypes devuelve los tipos encolados en el outbox, ordenados.
+func (f *fixture) eventTypes() []string {
+	var types []string
+	for _, m := range f.store.OutboxMessages() {
+		types = append(types, m.Type)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (f *fixture) assertState(t *testing.T, wantLevels levels, wantEvents []string) {
+	t.Helper()
+	got := f.levels()
+	if len(got) != len(wantLevels) {
+		t.Errorf("stock = %v, want %v", got, wantLevels)
+	}
+	for sku, want := range wantLevels {
+		if got[sku] != want {
+			t.Errorf("stock[%s] = %v, want %v", sku, got[sku], want)
+		}
+	}
+
+	sort.Strings(wantEvents)
+	gotEvents := f.eventTypes()
+	if len(gotEvents) != len(wantEvents) {
+		t.Errorf("outbox = %v, want %v", gotEvents, wantEvents)
+		return
+	}
+	for i := range wantEvents {
+		if gotEvents[i] != wantEvents[i] {
+			t.Errorf("outbox = %v, want %v", gotEvents, wantEvents)
+			return
+		}
+	}
+}
@@ -2,14 +2,13 @@ package application
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 
 	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
 
-	"github.com/google/uuid"
-
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
 )
 
 type EventHandler interface {
@@ -20,30 +19,29 @@ type EventHandler interface {
 
 type OrderPlacedHandler struct {
 	service *ReserveStockService
+	schemas *domain.SchemaRegistry
 }
 
-func NewOrderPlacedHandler(s *ReserveStockService) *OrderPlacedHandler {
-	return &OrderPlacedHandler{service: s}
+func NewOrderPlacedHandler(s *ReserveStockService, schemas *domain.SchemaRegistry) *OrderPlacedHandler {
+	return &OrderPlacedHandler{service: s, schemas: schemas}
 }
 
 func (h *OrderPlacedHandler) Handle(ctx context.Context, ev primitives.Event) error {
 	env, ok := ev.(*primitives.IntegrationEventEnvelope)
 	if !ok {
-		log.Printf("OrderPlacedHandler: invalid event type %T", ev)
-		return nil
+		return Permanent(fmt.Errorf("OrderPlacedHandler: invalid event type %T", ev))
 	}
 	if env.Type != "OrderPlacedEvent" {
 		return nil
 	}
 
-	var payload domain.OrderPlacedPayload
-	if err := json.Unmarshal([]byte(env.PayloadJSON), &payload); err != nil {
-		log.Printf("OrderPlacedHandler: failed to unmarshal payload: %v", err)
-		return nil
+	payload, err := h.schemas.DecodeOrderPlaced(env.Type, env.PayloadJSON)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("OrderPlacedHandler: received orderId=%s userId=%s",
-		payload.OrderID.String(), payload.UserID.String())
+	ctx = logging.With(ctx, logging.KeyOrderID, payload.OrderID.String())
+	slog.InfoContext(ctx, "OrderPlaced received", "userId", payload.UserID.String(), "lines", len(payload.Lines))
 
 	return h.service.HandleOrderPlaced(ctx, payload)
 }
@@ -52,33 +50,28 @@ func (h *OrderPlacedHandler) Handle(ctx context.Context, ev primitives.Event) er
 
 type OrderCancelledHandler struct {
 	service *ReleaseReservationService
+	schemas *domain.SchemaRegistry
 }
 
-func NewOrderCancelledHandler(s *ReleaseReservationService) *OrderCancelledHandler {
-	return &OrderCancelledHandler{service: s}
+func NewOrderCancelledHandler(s *ReleaseReservationService, schemas *domain.SchemaRegistry) *OrderCancelledHandler {
+	return &OrderCancelledHandler{service: s, schemas: schemas}
 }
 
 func (h *OrderCancelledHandler) Handle(ctx context.Context, ev primitives.Event) error {
 	env, ok := ev.(*primitives.IntegrationEventEnvelope)
 	if !ok {
-		log.Printf("OrderCancelledHandler: invalid event type %T", ev)
-		return nil
+		return Permanent(fmt.Errorf("OrderCancelledHandler: invalid event type %T", ev))
 	}
 	if env.Type != "OrderCancelledEvent" && env.Type != "OrderRejectedEvent" {
 		return nil
 	}
 
-	var payload domain.OrderCancelledPayload
-	if err := json.Unmarshal([]byte(env.PayloadJSON), &payload); err != nil {
-		log.Printf("OrderCancelledHandler: failed to unmarshal payload: %v", err)
-		return nil
-	}
-
-	if payload.OrderID == uuid.Nil {
-		log.Printf("OrderCancelledHandler: missing orderId")
-		return nil
+	payload, err := h.schemas.DecodeOrderCancelled(env.Type, env.PayloadJSON)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("OrderCancelledHandler: releasing reservation for orderId=%s", payload.OrderID.String())
+	ctx = logging.With(ctx, logging.KeyOrderID, payload.OrderID.String())
+	slog.InfoContext(ctx, "releasing reservation")
 	return h.service.HandleOrderCancelled(ctx, payload.OrderID)
 }
@@ -2,65 +2,85 @@ package application
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
 	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
 )
 
 type ProductCreatedHandler struct {
+	uow       domain.UnitOfWork
 	stockRepo domain.StockItemRepository
 	outbox    OutboxWriter
+	schemas   *domain.SchemaRegistry
+	clock     domain.Clock
+	ids       domain.IDGenerator
 }
 
 func NewProductCreatedHandler(
+	uow domain.UnitOfWork,
 	stockRepo domain.StockItemRepository,
 	outbox OutboxWriter,
+	schemas *domain.SchemaRegistry,
+	clock domain.Clock,
+	ids domain.IDGenerator,
 ) *ProductCreatedHandler {
 	return &ProductCreatedHandler{
+		uow:       uow,
 		stockRepo: stockRepo,
 		outbox:    outbox,
+		schemas:   schemas,
+		clock:     clock,
+		ids:       ids,
 	}
 }
 
 func (h *ProductCreatedHandler) Handle(ctx context.Context, ev primitives.Event) error {
 	env, ok := ev.(*primitives.IntegrationEventEnvelope)
 	if !ok {
-		log.Printf("ProductCreatedHandler: invalid event type %T", ev)
-		return nil
+		return Permanent(fmt.Errorf("ProductCreatedHandler: invalid event type %T", ev))
 	}
 	if env.Type != "ProductCreated" {
 		return nil
 	}
 
-	var payload domain.ProductCreatedPayload
-	if err := json.Unmarshal([]byte(env.PayloadJSON), &payload); err != nil {
-		log.Printf("ProductCreatedHandler: failed to unmarshal payload: %v", err)
-		return nil
+	payload, err := h.schemas.DecodeProductCreated(env.Type, env.PayloadJSON)
+	if err != nil {
+		return err
 	}
 
-	if payload.Sku == "" {
-		log.Printf("ProductCreatedHandler: missing sku")
-		return nil
-	}
+	ctx = logging.With(ctx, logging.KeySku, payload.Sku)
+	slog.InfoContext(ctx, "ProductCreated received", "stockQuantity", payload.StockQuantity)
 
-	log.Printf("ProductCreatedHandler: received ProductCreated for sku=%s qty=%d",
-		payload.Sku, payload.StockQuantity)
+	return h.uow.Do(ctx, func(ctx context.Context) error {
+		return h.setInitialStock(ctx, payload)
+	})
+}
 
+// setInitialStock crea el item (o pisa su available) y encola el ajuste.
+func (h *ProductCreatedHandler) setInitialStock(ctx context.Context, payload domain.ProductCreatedPayload) error {
 	skus := []string{payload.Sku}
 	existing, err := h.stockRepo.GetBySkus(ctx, skus)
 	if err != nil {
 		return err
 	}
 
+	now := h.clock.Now()
 	var item *domain.StockItem
 	if current, ok := existing[payload.Sku]; ok {
 		// si ya existe, sobreescribimos available por el inicial (policy)
-		current.Available = payload.StockQuantity
+		if err := current.SetAvailable(payload.StockQuantity, now); err != nil {
+			return Permanent(err)
+		}
 		item = current
 	} else {
-		item = domain.NewStockItem(payload.Sku, payload.StockQuantity)
+		created, err := domain.NewStockItem(h.ids.NewID(), payload.Sku, payload.StockQuantity, now)
+		if err != nil {
+			return Permanent(err)
+		}
+		item = created
 	}
 
 	if err := h.stockRepo.UpsertMany(ctx, []*domain.StockItem{item}); err != nil {
@@ -68,6 +88,7 @@ func (h *ProductCreatedHandler) Handle(ctx context.Context, ev primitives.Event)
 	}
 
 	adjEv := domain.NewCatalogStockAdjustedEvent(
+		domain.NewEventMessage(h.ids.NewID(), now),
 		item.Sku,
 		item.Available,
 		item.Reserved,
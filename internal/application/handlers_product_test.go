@@ -0,0 +1,83 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+func TestProductCreatedHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		eventType     string
+		payload       any
+		wantErr       bool
+		wantPermanent bool
+		wantLevels    levels
+		wantEvents    []string
+	}{
+		{
+			name:       "creates stock for a new sku",
+			eventType:  "ProductCreated",
+			payload:    map[string]any{"sku": "NEW", "stockQuantity": 7},
+			wantLevels: levels{"OLD": {3, 2}, "NEW": {7, 0}},
+			wantEvents: []string{"CatalogStockAdjusted"},
+		},
+		{
+			name:       "overwrites available and keeps reserved for an existing sku",
+			eventType:  "ProductCreated",
+			payload:    map[string]any{"sku": "OLD", "stockQuantity": 20},
+			wantLevels: levels{"OLD": {20, 2}},
+			wantEvents: []string{"CatalogStockAdjusted"},
+		},
+		{
+			name:       "ignores other event types",
+			eventType:  "ProductUpdated",
+			payload:    map[string]any{"sku": "NEW", "stockQuantity": 7},
+			wantLevels: levels{"OLD": {3, 2}},
+		},
+		{
+			name:          "missing sku is a permanent error",
+			eventType:     "ProductCreated",
+			payload:       map[string]any{"stockQuantity": 7},
+			wantErr:       true,
+			wantPermanent: true,
+			wantLevels:    levels{"OLD": {3, 2}},
+		},
+		{
+			name:          "negative quantity is a permanent error",
+			eventType:     "ProductCreated",
+			payload:       map[string]any{"sku": "NEW", "stockQuantity": -1},
+			wantErr:       true,
+			wantPermanent: true,
+			wantLevels:    levels{"OLD": {3, 2}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t, nil)
+			old := f.newItem(t, "OLD", 3)
+			old.Reserved = 2
+			if err := f.stock.UpsertMany(context.Background(), []*domain.StockItem{old}); err != nil {
+				t.Fatal(err)
+			}
+			h := NewProductCreatedHandler(f.uow, f.stock, f.writer, domain.NewSchemaRegistry(), f.clock, f.ids)
+
+			data, _ := json.Marshal(tc.payload)
+			env := primitives.NewIntegrationEventEnvelope(tc.eventType, string(data))
+			err := h.Handle(context.Background(), &env)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if IsPermanent(err) != tc.wantPermanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, IsPermanent(err), tc.wantPermanent)
+			}
+			f.assertState(t, tc.wantLevels, tc.wantEvents)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/tracing"
+)
+
+// InstrumentedHandler abre un span por intento y mide su duración por tipo
+// de evento y resultado.
+type InstrumentedHandler struct {
+	inner EventHandler
+}
+
+func NewInstrumentedHandler(inner EventHandler) *InstrumentedHandler {
+	return &InstrumentedHandler{inner: inner}
+}
+
+func (h *InstrumentedHandler) Handle(ctx context.Context, ev primitives.Event) error {
+	eventType := typeNameOf(ev)
+	if env, ok := ev.(*primitives.IntegrationEventEnvelope); ok {
+		eventType = env.Type
+	}
+
+	ctx, span := tracing.Start(ctx, "handle "+eventType,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.message.id", ev.GetMessage().ID.String()),
+			attribute.String("handler", fmt.Sprintf("%T", h.inner)),
+		),
+	)
+
+	start := time.Now()
+	err := h.inner.Handle(ctx, ev)
+	metrics.ObserveHandler(eventType, time.Since(start), err)
+	tracing.End(span, err)
+	return err
+}
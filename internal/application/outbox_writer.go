@@ -4,12 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"reflect"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
 
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/tracing"
 )
 
 type OutboxWriter interface {
@@ -17,11 +16,13 @@ type OutboxWriter interface {
 }
 
 type outboxWriter struct {
-	repo domain.OutboxRepository
+	repo  domain.OutboxRepository
+	clock domain.Clock
+	ids   domain.IDGenerator
 }
 
-func NewOutboxWriter(repo domain.OutboxRepository) OutboxWriter {
-	return &outboxWriter{repo: repo}
+func NewOutboxWriter(repo domain.OutboxRepository, clock domain.Clock, ids domain.IDGenerator) OutboxWriter {
+	return &outboxWriter{repo: repo, clock: clock, ids: ids}
 }
 
 func (w *outboxWriter) Enqueue(ctx context.Context, ev primitives.Event) error {
@@ -35,14 +36,15 @@ func (w *outboxWriter) Enqueue(ctx context.Context, ev primitives.Event) error {
 		eventType = typeNameOf(ev)
 	}
 
-	now := time.Now().UTC().Unix()
+	now := w.clock.Now().Unix()
 	msg := domain.OutboxMessage{
-		ID:             uuid.New(),
+		ID:             w.ids.NewID(),
 		Type:           eventType,
 		PayloadJSON:    string(payload),
 		OccurredAtUtc:  now,
 		RetryCount:     0,
 		ProcessedAtUtc: nil,
+		TraceContext:   tracing.Inject(ctx),
 	}
 	return w.repo.Insert(ctx, msg)
 }
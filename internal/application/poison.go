@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// ErrPermanent marca errores que no se arreglan reintentando.
+var ErrPermanent = errors.New("permanent failure")
+
+// Permanent envuelve err como error permanente.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// IsPermanent clasifica el error: payloads inválidos y errores marcados con
+// Permanent son permanentes; todo lo demás (DB, red, ...) es transitorio.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrPermanent) || errors.Is(err, domain.ErrInvalidPayload)
+}
+
+// RetryPolicy acota los reintentos de un mensaje con backoff exponencial.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff << (attempt - 1)
+	if d <= 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// RetryingHandler reintenta los errores transitorios hasta MaxAttempts y
+// manda a cuarentena los permanentes o los que agotan los intentos. Solo
+// devuelve error si ctx se cancela o la cuarentena falla; en los dos casos
+// el bus vuelve a entregar el mensaje.
+type RetryingHandler struct {
+	inner      EventHandler
+	policy     RetryPolicy
+	quarantine *QuarantineService
+}
+
+func NewRetryingHandler(
+	inner EventHandler,
+	policy RetryPolicy,
+	quarantine *QuarantineService,
+) *RetryingHandler {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetryingHandler{inner: inner, policy: policy, quarantine: quarantine}
+}
+
+func (h *RetryingHandler) Handle(ctx context.Context, ev primitives.Event) error {
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		err = h.inner.Handle(ctx, ev)
+		if err == nil {
+			return nil
+		}
+		if IsPermanent(err) || attempt >= h.policy.MaxAttempts {
+			break
+		}
+
+		slog.WarnContext(ctx, "handler failed, retrying",
+			"handler", fmt.Sprintf("%T", h.inner), "attempt", attempt, "maxAttempts", h.policy.MaxAttempts, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(h.policy.delay(attempt)):
+		}
+	}
+
+	return h.quarantine.Reject(ctx, ev, err, attempt)
+}
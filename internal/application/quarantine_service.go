@@ -0,0 +1,156 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+)
+
+var (
+	ErrQuarantineNotFound = errors.New("quarantined message not found")
+	ErrAlreadyReplayed    = errors.New("quarantined message already replayed")
+	ErrNoReplayHandler    = errors.New("no handler registered for event type")
+)
+
+// QuarantineService guarda los mensajes rechazados en la tabla local de
+// cuarentena (y los avisa al dead-letter) y permite re-procesarlos.
+type QuarantineService struct {
+	repo        domain.QuarantineRepository
+	deadLetters DeadLetterWriter
+	handlers    map[string]EventHandler
+	clock       domain.Clock
+	ids         domain.IDGenerator
+}
+
+// deadLetters puede ser nil si no se quiere publicar al exchange de dead-letter.
+func NewQuarantineService(
+	repo domain.QuarantineRepository,
+	deadLetters DeadLetterWriter,
+	clock domain.Clock,
+	ids domain.IDGenerator,
+) *QuarantineService {
+	return &QuarantineService{
+		repo:        repo,
+		deadLetters: deadLetters,
+		handlers:    make(map[string]EventHandler),
+		clock:       clock,
+		ids:         ids,
+	}
+}
+
+// Register indica qué handler re-procesa los mensajes de un tipo.
+func (s *QuarantineService) Register(eventType string, h EventHandler) *QuarantineService {
+	s.handlers[eventType] = h
+	return s
+}
+
+// Reject pone el mensaje en cuarentena.
+func (s *QuarantineService) Reject(ctx context.Context, ev primitives.Event, reason error, attempts int) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	msg := domain.QuarantinedMessage{
+		ID:               s.ids.NewID(),
+		EventType:        typeNameOf(ev),
+		EnvelopeJSON:     string(body),
+		Reason:           reason.Error(),
+		Attempts:         attempts,
+		Permanent:        IsPermanent(reason),
+		QuarantinedAtUtc: s.clock.Now(),
+	}
+	env, isEnvelope := ev.(*primitives.IntegrationEventEnvelope)
+	if isEnvelope {
+		msg.EnvelopeID = env.ID
+		msg.EventType = env.Type
+	}
+
+	slog.WarnContext(ctx, "message quarantined",
+		"quarantineId", msg.ID.String(), "attempts", attempts, "permanent", msg.Permanent, "error", reason)
+	if err := s.repo.Insert(ctx, msg); err != nil {
+		return err
+	}
+	metrics.MessageQuarantined(msg.EventType, msg.Permanent)
+
+	if s.deadLetters != nil && isEnvelope {
+		if err := s.deadLetters.DeadLetter(ctx, env, reason); err != nil {
+			// Ya quedó en cuarentena local; no lo devolvemos al bus.
+			slog.ErrorContext(ctx, "dead-letter publish failed", "quarantineId", msg.ID.String(), "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *QuarantineService) List(ctx context.Context, pendingOnly bool, limit int) ([]domain.QuarantinedMessage, error) {
+	return s.repo.List(ctx, pendingOnly, limit)
+}
+
+func (s *QuarantineService) Get(ctx context.Context, id uuid.UUID) (*domain.QuarantinedMessage, error) {
+	msg, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, ErrQuarantineNotFound
+	}
+	return msg, nil
+}
+
+// Replay vuelve a pasar el envelope por su handler. Antes de correrlo marca
+// el mensaje como re-procesado con ClaimReplay, así dos replays concurrentes
+// no lo procesan dos veces; si el handler falla se desmarca y sigue en
+// cuarentena.
+func (s *QuarantineService) Replay(ctx context.Context, id uuid.UUID) (err error) {
+	msg, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if msg.ReplayedAtUtc != nil {
+		return ErrAlreadyReplayed
+	}
+
+	h, ok := s.handlers[msg.EventType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoReplayHandler, msg.EventType)
+	}
+
+	var env primitives.IntegrationEventEnvelope
+	if err := json.Unmarshal([]byte(msg.EnvelopeJSON), &env); err != nil {
+		return Permanent(fmt.Errorf("stored envelope is not valid: %w", err))
+	}
+
+	claimedAt := s.clock.Now()
+	claimed, err := s.repo.ClaimReplay(ctx, msg.ID, claimedAt)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrAlreadyReplayed
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if uerr := s.repo.UnclaimReplay(context.WithoutCancel(ctx), msg.ID, claimedAt); uerr != nil {
+			slog.ErrorContext(ctx, "failed to release replay claim", "quarantineId", msg.ID.String(), "error", uerr)
+		}
+	}()
+
+	ctx = logging.With(ctx,
+		logging.KeyEnvelopeID, msg.EnvelopeID.String(),
+		logging.KeyEventType, msg.EventType,
+		logging.KeyCorrelationID, env.CorrelationID,
+	)
+	slog.InfoContext(ctx, "replaying quarantined message", "quarantineId", msg.ID.String())
+	return h.Handle(ctx, &env)
+}
@@ -0,0 +1,250 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+// scriptedHandler devuelve los errores de errs en orden (nil cuando se
+// acaban) y cuenta las llamadas.
+type scriptedHandler struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (h *scriptedHandler) Handle(ctx context.Context, ev primitives.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if len(h.errs) == 0 {
+		return nil
+	}
+	err := h.errs[0]
+	h.errs = h.errs[1:]
+	return err
+}
+
+type recordingDeadLetters struct {
+	envelopes []*primitives.IntegrationEventEnvelope
+}
+
+func (d *recordingDeadLetters) DeadLetter(ctx context.Context, env *primitives.IntegrationEventEnvelope, reason error) error {
+	d.envelopes = append(d.envelopes, env)
+	return nil
+}
+
+type quarantineFixture struct {
+	store       *memory.Store
+	repo        *memory.QuarantineRepository
+	deadLetters *recordingDeadLetters
+	service     *QuarantineService
+}
+
+func newQuarantineFixture(t *testing.T) *quarantineFixture {
+	t.Helper()
+	store := memory.NewStore()
+	f := &quarantineFixture{
+		store:       store,
+		repo:        memory.NewQuarantineRepository(store),
+		deadLetters: &recordingDeadLetters{},
+	}
+	f.service = NewQuarantineService(f.repo, f.deadLetters,
+		domain.NewManualClock(fixtureStart, time.Second), domain.NewSequentialIDGenerator(t.Name()))
+	return f
+}
+
+func (f *quarantineFixture) quarantined(t *testing.T) []domain.QuarantinedMessage {
+	t.Helper()
+	msgs, err := f.repo.List(context.Background(), false, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msgs
+}
+
+func orderPlacedEnvelope() *primitives.IntegrationEventEnvelope {
+	env := primitives.NewIntegrationEventEnvelope("OrderPlacedEvent", `{"orderId":"00000000-0000-0000-0000-000000000001"}`)
+	return &env
+}
+
+var errTransient = errors.New("connection reset")
+
+func TestRetryingHandler(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	cases := []struct {
+		name          string
+		errs          []error
+		wantCalls     int
+		wantAttempts  int // 0 = no queda en cuarentena
+		wantPermanent bool
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "transient then success", errs: []error{errTransient, errTransient}, wantCalls: 3},
+		{name: "transient exhausts attempts", errs: []error{errTransient, errTransient, errTransient}, wantCalls: 3, wantAttempts: 3},
+		{name: "permanent is not retried", errs: []error{Permanent(errors.New("bad state"))}, wantCalls: 1, wantAttempts: 1, wantPermanent: true},
+		{
+			name:      "invalid payload is permanent",
+			errs:      []error{errTransient, &domain.PayloadError{EventType: "OrderPlacedEvent", Reason: "orderId is required"}},
+			wantCalls: 2, wantAttempts: 2, wantPermanent: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newQuarantineFixture(t)
+			inner := &scriptedHandler{errs: tc.errs}
+			h := NewRetryingHandler(inner, policy, f.service)
+
+			if err := h.Handle(context.Background(), orderPlacedEnvelope()); err != nil {
+				t.Fatalf("Handle = %v, want nil (failures go to quarantine)", err)
+			}
+			if inner.calls != tc.wantCalls {
+				t.Errorf("calls = %d, want %d", inner.calls, tc.wantCalls)
+			}
+
+			msgs := f.quarantined(t)
+			if tc.wantAttempts == 0 {
+				if len(msgs) != 0 || len(f.deadLetters.envelopes) != 0 {
+					t.Errorf("quarantined %d, dead-lettered %d; want none", len(msgs), len(f.deadLetters.envelopes))
+				}
+				return
+			}
+			if len(msgs) != 1 || len(f.deadLetters.envelopes) != 1 {
+				t.Fatalf("quarantined %d, dead-lettered %d; want 1 each", len(msgs), len(f.deadLetters.envelopes))
+			}
+			m := msgs[0]
+			if m.Attempts != tc.wantAttempts || m.Permanent != tc.wantPermanent || m.EventType != "OrderPlacedEvent" || m.ReplayedAtUtc != nil {
+				t.Errorf("quarantined = %+v", m)
+			}
+		})
+	}
+}
+
+func TestRetryingHandlerStopsOnCancel(t *testing.T) {
+	f := newQuarantineFixture(t)
+	inner := &scriptedHandler{errs: []error{errTransient, errTransient}}
+	h := NewRetryingHandler(inner, RetryPolicy{MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}, f.service)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := h.Handle(ctx, orderPlacedEnvelope()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Handle = %v, want the context error", err)
+	}
+	if inner.calls != 1 || len(f.quarantined(t)) != 0 {
+		t.Errorf("calls = %d, quarantined = %d; want 1 and 0", inner.calls, len(f.quarantined(t)))
+	}
+}
+
+func TestRetryingHandlerReturnsQuarantineFailure(t *testing.T) {
+	f := newQuarantineFixture(t)
+	f.store.FailOn("QuarantineRepository.Insert", errors.New("disk full"))
+	h := NewRetryingHandler(&scriptedHandler{errs: []error{Permanent(errors.New("bad"))}}, RetryPolicy{MaxAttempts: 1}, f.service)
+
+	// Si no se puede guardar en cuarentena el error vuelve al bus, que
+	// reentrega el mensaje: no se pierde en silencio.
+	if err := h.Handle(context.Background(), orderPlacedEnvelope()); err == nil {
+		t.Error("Handle = nil, want the quarantine error")
+	}
+}
+
+func TestQuarantineReplay(t *testing.T) {
+	ctx := context.Background()
+	f := newQuarantineFixture(t)
+	replayHandler := &scriptedHandler{errs: []error{errTransient}}
+	f.service.Register("OrderPlacedEvent", replayHandler)
+
+	if err := f.service.Reject(ctx, orderPlacedEnvelope(), errTransient, 3); err != nil {
+		t.Fatal(err)
+	}
+	id := f.quarantined(t)[0].ID
+
+	// Un replay que falla deja el mensaje pendiente.
+	if err := f.service.Replay(ctx, id); !errors.Is(err, errTransient) {
+		t.Fatalf("Replay = %v, want the handler error", err)
+	}
+	if msg, _ := f.service.Get(ctx, id); msg.ReplayedAtUtc != nil {
+		t.Fatalf("replayedAt = %v after a failed replay, want nil", msg.ReplayedAtUtc)
+	}
+
+	if err := f.service.Replay(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := f.service.Get(ctx, id); msg.ReplayedAtUtc == nil {
+		t.Error("replayedAt = nil after a successful replay")
+	}
+	if err := f.service.Replay(ctx, id); !errors.Is(err, ErrAlreadyReplayed) {
+		t.Errorf("second Replay = %v, want ErrAlreadyReplayed", err)
+	}
+	if replayHandler.calls != 2 {
+		t.Errorf("handler calls = %d, want 2", replayHandler.calls)
+	}
+}
+
+func TestQuarantineReplayErrors(t *testing.T) {
+	ctx := context.Background()
+	f := newQuarantineFixture(t)
+	if err := f.service.Replay(ctx, domain.NewSequentialIDGenerator("x").NewID()); !errors.Is(err, ErrQuarantineNotFound) {
+		t.Errorf("Replay(unknown) = %v, want ErrQuarantineNotFound", err)
+	}
+
+	if err := f.service.Reject(ctx, orderPlacedEnvelope(), errTransient, 1); err != nil {
+		t.Fatal(err)
+	}
+	id := f.quarantined(t)[0].ID
+	if err := f.service.Replay(ctx, id); !errors.Is(err, ErrNoReplayHandler) {
+		t.Errorf("Replay without handler = %v, want ErrNoReplayHandler", err)
+	}
+	if msg, _ := f.service.Get(ctx, id); msg.ReplayedAtUtc != nil {
+		t.Error("a replay without handler claimed the message")
+	}
+}
+
+// blockingHandler espera a release; sirve para tener dos replays a la vez.
+type blockingHandler struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, ev primitives.Event) error {
+	h.calls.Add(1)
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+func TestQuarantineConcurrentReplayRunsOnce(t *testing.T) {
+	ctx := context.Background()
+	f := newQuarantineFixture(t)
+	h := &blockingHandler{started: make(chan struct{}, 2), release: make(chan struct{})}
+	f.service.Register("OrderPlacedEvent", h)
+	if err := f.service.Reject(ctx, orderPlacedEnvelope(), errTransient, 1); err != nil {
+		t.Fatal(err)
+	}
+	id := f.quarantined(t)[0].ID
+
+	first := make(chan error, 1)
+	go func() { first <- f.service.Replay(ctx, id) }()
+	<-h.started
+
+	// Mientras el primero corre, el segundo encuentra el mensaje tomado.
+	if err := f.service.Replay(ctx, id); !errors.Is(err, ErrAlreadyReplayed) {
+		t.Errorf("concurrent Replay = %v, want ErrAlreadyReplayed", err)
+	}
+	close(h.release)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	if n := h.calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
@@ -2,41 +2,92 @@ package application
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
 )
 
 type ReleaseReservationService struct {
+	uow             domain.UnitOfWork
 	stockRepo       domain.StockItemRepository
 	reservationRepo domain.StockReservationRepository
 	outbox          OutboxWriter
+	clock           domain.Clock
+	ids             domain.IDGenerator
 }
 
 func NewReleaseReservationService(
+	uow domain.UnitOfWork,
 	stockRepo domain.StockItemRepository,
 	reservationRepo domain.StockReservationRepository,
 	outbox OutboxWriter,
+	clock domain.Clock,
+	ids domain.IDGenerator,
 ) *ReleaseReservationService {
 	return &ReleaseReservationService{
+		uow:             uow,
 		stockRepo:       stockRepo,
 		reservationRepo: reservationRepo,
 		outbox:          outbox,
+		clock:           clock,
+		ids:             ids,
 	}
 }
 
+// ReleaseResult es el desenlace de Release. Reservation es nil si la orden
+// no tenía reservación.
+type ReleaseResult struct {
+	Reservation     *domain.StockReservation
+	AlreadyReleased bool
+}
+
 func (s *ReleaseReservationService) HandleOrderCancelled(
 	ctx context.Context,
 	orderID uuid.UUID,
 ) error {
+	_, err := s.Release(ctx, orderID)
+	return err
+}
+
+// Release devuelve el stock reservado por la orden y encola los eventos, en
+// una unidad de trabajo. Lo usan el handler de OrderCancelled/OrderRejected
+// y la API gRPC.
+func (s *ReleaseReservationService) Release(
+	ctx context.Context,
+	orderID uuid.UUID,
+) (ReleaseResult, error) {
+	var res ReleaseResult
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.release(ctx, orderID)
+		return err
+	})
+	if err != nil {
+		return ReleaseResult{}, err
+	}
+	if res.Reservation != nil && !res.AlreadyReleased {
+		metrics.ReservationReleased()
+	}
+	return res, nil
+}
+
+func (s *ReleaseReservationService) release(
+	ctx context.Context,
+	orderID uuid.UUID,
+) (ReleaseResult, error) {
 	res, err := s.reservationRepo.GetByOrderID(ctx, orderID)
 	if err != nil {
-		return err
+		return ReleaseResult{}, err
+	}
+	if res == nil {
+		return ReleaseResult{}, nil
 	}
-	if res == nil || res.Status == domain.ReservationReleased {
+	if res.Status == domain.ReservationReleased {
 		// idempotente
-		return nil
+		return ReleaseResult{Reservation: res, AlreadyReleased: true}, nil
 	}
 
 	// Cargar stock items
@@ -47,44 +98,47 @@ func (s *ReleaseReservationService) HandleOrderCancelled(
 
 	stockMap, err := s.stockRepo.GetBySkus(ctx, skus)
 	if err != nil {
-		return err
+		return ReleaseResult{}, err
 	}
 
-	// Liberar a inventario
+	now := s.clock.Now()
+
+	// Liberar a inventario. Si el stock no cuadra con la reservación es un
+	// bug de contabilidad: no se persiste nada y el mensaje va a cuarentena.
 	for _, l := range res.Lines {
 		item, ok := stockMap[l.Sku]
 		if !ok {
 			continue
 		}
-		item.Release(l.Quantity)
+		if err := item.Release(l.Quantity, now); err != nil {
+			return ReleaseResult{}, Permanent(fmt.Errorf("release reservation of order %s: %w", orderID, err))
+		}
 	}
 
-	items := make([]*domain.StockItem, 0, len(stockMap))
-	for _, it := range stockMap {
-		items = append(items, it)
-	}
+	items := sortedItems(stockMap)
 
 	if err := s.stockRepo.UpsertMany(ctx, items); err != nil {
-		return err
+		return ReleaseResult{}, err
 	}
 
-	res.MarkReleased()
+	res.MarkReleased(now)
 	if err := s.reservationRepo.Update(ctx, res); err != nil {
-		return err
+		return ReleaseResult{}, err
 	}
 
 	// Emitir CatalogStockAdjusted por cada SKU afectado
 	for _, item := range items {
 		adjEv := domain.NewCatalogStockAdjustedEvent(
+			domain.NewEventMessage(s.ids.NewID(), now),
 			item.Sku,
 			item.Available,
 			item.Reserved,
 			"ORDER_RELEASED",
 		)
 		if err := s.outbox.Enqueue(ctx, adjEv); err != nil {
-			return err
+			return ReleaseResult{}, err
 		}
 	}
 
-	return nil
+	return ReleaseResult{Reservation: res}, nil
 }
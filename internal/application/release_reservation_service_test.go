@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+func TestRelease(t *testing.T) {
+	cases := []struct {
+		name string
+		// reserved son las líneas que se reservan antes de liberar; nil =
+		// la orden no tiene reservación.
+		reserved     []domain.OrderPlacedLine
+		releaseTwice bool
+		failOn       string
+		wantErr      bool
+		wantFound    bool
+		wantAlready  bool
+		wantLevels   levels
+		wantEvents   []string
+	}{
+		{
+			name:       "returns reserved stock",
+			reserved:   []domain.OrderPlacedLine{{Sku: "A", Quantity: 4}, {Sku: "B", Quantity: 1}},
+			wantFound:  true,
+			wantLevels: levels{"A": {10, 0}, "B": {5, 0}},
+			wantEvents: []string{
+				"StockReserved", "CatalogStockAdjusted", "CatalogStockAdjusted",
+				"CatalogStockAdjusted", "CatalogStockAdjusted",
+			},
+		},
+		{
+			name:         "second release is a no-op",
+			reserved:     []domain.OrderPlacedLine{{Sku: "A", Quantity: 4}},
+			releaseTwice: true,
+			wantFound:    true,
+			wantAlready:  true,
+			wantLevels:   levels{"A": {10, 0}, "B": {5, 0}},
+			wantEvents:   []string{"StockReserved", "CatalogStockAdjusted", "CatalogStockAdjusted"},
+		},
+		{
+			name:       "order without reservation",
+			wantLevels: levels{"A": {10, 0}, "B": {5, 0}},
+		},
+		{
+			name:       "stock write failure keeps the reservation active",
+			reserved:   []domain.OrderPlacedLine{{Sku: "A", Quantity: 4}},
+			failOn:     "StockItemRepository.UpsertMany",
+			wantErr:    true,
+			wantLevels: levels{"A": {6, 4}, "B": {5, 0}},
+			wantEvents: []string{"StockReserved", "CatalogStockAdjusted"},
+		},
+		{
+			name:       "reservation update failure rolls back the stock",
+			reserved:   []domain.OrderPlacedLine{{Sku: "A", Quantity: 4}},
+			failOn:     "StockReservationRepository.Update",
+			wantErr:    true,
+			wantLevels: levels{"A": {6, 4}, "B": {5, 0}},
+			wantEvents: []string{"StockReserved", "CatalogStockAdjusted"},
+		},
+		{
+			name:       "outbox failure rolls back the stock and the reservation",
+			reserved:   []domain.OrderPlacedLine{{Sku: "A", Quantity: 4}},
+			failOn:     "OutboxRepository.Insert",
+			wantErr:    true,
+			wantLevels: levels{"A": {6, 4}, "B": {5, 0}},
+			wantEvents: []string{"StockReserved", "CatalogStockAdjusted"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t, map[string]int{"A": 10, "B": 5})
+			orderID := uuid.New()
+			if tc.reserved != nil {
+				res, err := f.reserve.Reserve(context.Background(), domain.OrderPlacedPayload{OrderID: orderID, Lines: tc.reserved})
+				if err != nil || res.Reservation == nil {
+					t.Fatalf("reserve = %+v, %v", res, err)
+				}
+			}
+			if tc.releaseTwice {
+				if _, err := f.release.Release(context.Background(), orderID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.failOn != "" {
+				f.store.FailOn(tc.failOn, errors.New("boom"))
+			}
+
+			res, err := f.release.Release(context.Background(), orderID)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if (res.Reservation != nil) != tc.wantFound || res.AlreadyReleased != tc.wantAlready {
+				t.Errorf("result = %+v, want found %v already %v", res, tc.wantFound, tc.wantAlready)
+			}
+			if tc.wantFound && res.Reservation.Status != domain.ReservationReleased {
+				t.Errorf("status = %s, want RELEASED", res.Reservation.Status)
+			}
+
+			f.store.FailOn(tc.failOn, nil)
+			if tc.wantErr && tc.reserved != nil {
+				stored, _ := f.reservations.GetByOrderID(context.Background(), orderID)
+				if stored.Status != domain.ReservationActive {
+					t.Errorf("stored status = %s, want ACTIVE", stored.Status)
+				}
+			}
+			f.assertState(t, tc.wantLevels, tc.wantEvents)
+		})
+	}
+}
+
+// Una línea cuyo SKU ya no existe se salta; el resto se libera.
+func TestReleaseSkipsMissingSku(t *testing.T) {
+	f := newFixture(t, nil)
+	seedStock(t, f, "A", 8, 2)
+	orderID := insertReservation(t, f, domain.ReservationLine{Sku: "A", Quantity: 2}, domain.ReservationLine{Sku: "GONE", Quantity: 1})
+
+	if _, err := f.release.Release(context.Background(), orderID); err != nil {
+		t.Fatal(err)
+	}
+	f.assertState(t, levels{"A": {10, 0}}, []string{"CatalogStockAdjusted"})
+}
+
+// Liberar más de lo reservado es un error de contabilidad: permanente y
+// sin tocar nada.
+func TestReleaseExceedingReservedIsPermanent(t *testing.T) {
+	f := newFixture(t, nil)
+	seedStock(t, f, "A", 8, 1)
+	orderID := insertReservation(t, f, domain.ReservationLine{Sku: "A", Quantity: 2})
+
+	_, err := f.release.Release(context.Background(), orderID)
+	if !IsPermanent(err) || !errors.Is(err, domain.ErrReleaseExceedsReserved) {
+		t.Fatalf("err = %v, want permanent ErrReleaseExceedsReserved", err)
+	}
+	stored, _ := f.reservations.GetByOrderID(context.Background(), orderID)
+	if stored.Status != domain.ReservationActive {
+		t.Errorf("stored status = %s, want ACTIVE", stored.Status)
+	}
+	f.assertState(t, levels{"A": {8, 1}}, nil)
+}
+
+func seedStock(t *testing.T, f *fixture, sku string, available, reserved int) {
+	t.Helper()
+	item := f.newItem(t, sku, available)
+	item.Reserved = reserved
+	if err := f.stock.UpsertMany(context.Background(), []*domain.StockItem{item}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func insertReservation(t *testing.T, f *fixture, lines ...domain.ReservationLine) uuid.UUID {
+	t.Helper()
+	orderID := uuid.New()
+	err := f.reservations.Insert(context.Background(), &domain.StockReservation{
+		OrderID: orderID,
+		Status:  domain.ReservationActive,
+		Lines:   lines,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orderID
+}
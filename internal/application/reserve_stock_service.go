@@ -4,48 +4,101 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
 )
 
 type ReserveStockService struct {
+	uow             domain.UnitOfWork
 	stockRepo       domain.StockItemRepository
 	reservationRepo domain.StockReservationRepository
 	outbox          OutboxWriter
+	clock           domain.Clock
+	ids             domain.IDGenerator
 }
 
 func NewReserveStockService(
+	uow domain.UnitOfWork,
 	stockRepo domain.StockItemRepository,
 	reservationRepo domain.StockReservationRepository,
 	outbox OutboxWriter,
+	clock domain.Clock,
+	ids domain.IDGenerator,
 ) *ReserveStockService {
 	return &ReserveStockService{
+		uow:             uow,
 		stockRepo:       stockRepo,
 		reservationRepo: reservationRepo,
 		outbox:          outbox,
+		clock:           clock,
+		ids:             ids,
 	}
 }
 
+// ReserveResult es el desenlace de Reserve. Si no se pudo reservar,
+// Reservation es nil y FailureReason trae el motivo (metrics.Reason*); el
+// StockReservationFailed ya quedó en el outbox.
+type ReserveResult struct {
+	Reservation     *domain.StockReservation
+	AlreadyReserved bool
+	FailureReason   string
+	FailureDetail   string
+}
+
 func (s *ReserveStockService) HandleOrderPlaced(
 	ctx context.Context,
 	payload domain.OrderPlacedPayload,
 ) error {
+	_, err := s.Reserve(ctx, payload)
+	return err
+}
+
+// Reserve valida disponibilidad, reserva y encola los eventos, todo en una
+// unidad de trabajo. Lo usan el handler de OrderPlaced y la API gRPC.
+func (s *ReserveStockService) Reserve(
+	ctx context.Context,
+	payload domain.OrderPlacedPayload,
+) (ReserveResult, error) {
 	if payload.OrderID == uuid.Nil {
-		return errors.New("missing orderId")
+		return ReserveResult{}, Permanent(errors.New("missing orderId"))
+	}
+
+	var res ReserveResult
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.reserve(ctx, payload)
+		return err
+	})
+	if err != nil {
+		return ReserveResult{}, err
 	}
 
+	switch {
+	case res.FailureReason != "":
+		metrics.ReservationFailed(res.FailureReason)
+	case !res.AlreadyReserved:
+		metrics.ReservationSucceeded()
+	}
+	return res, nil
+}
+
+func (s *ReserveStockService) reserve(
+	ctx context.Context,
+	payload domain.OrderPlacedPayload,
+) (ReserveResult, error) {
 	// Idempotencia: si ya tenemos reservación, no hacemos nada
 	if existing, _ := s.reservationRepo.GetByOrderID(ctx, payload.OrderID); existing != nil {
-		return nil
+		return ReserveResult{Reservation: existing, AlreadyReserved: true}, nil
 	}
 
+	now := s.clock.Now()
 	if len(payload.Lines) == 0 {
-		reason := "No lines in order"
-		ev := domain.NewStockReservationFailedEvent(payload.OrderID, payload.UserID, reason)
-		return s.outbox.Enqueue(ctx, ev)
+		return s.fail(ctx, payload, now, metrics.ReasonNoLines, "No lines in order")
 	}
 
 	skus := make([]string, 0, len(payload.Lines))
@@ -55,63 +108,44 @@ func (s *ReserveStockService) HandleOrderPlaced(
 
 	stockMap, err := s.stockRepo.GetBySkus(ctx, skus)
 	if err != nil {
-		return err
+		return ReserveResult{}, err
 	}
 
-	// Validar disponibilidad
+	// Validar y reservar en memoria en una sola pasada: si un SKU aparece
+	// en varias líneas, cada una ve lo que dejaron las anteriores. Nada se
+	// persiste hasta que todas las líneas pasan.
 	for _, line := range payload.Lines {
 		item, ok := stockMap[line.Sku]
 		if !ok {
-			reason := fmt.Sprintf("SKU %s not found", line.Sku)
-			ev := domain.NewStockReservationFailedEvent(payload.OrderID, payload.UserID, reason)
-			return s.outbox.Enqueue(ctx, ev)
+			return s.fail(ctx, payload, now, metrics.ReasonSkuNotFound, fmt.Sprintf("SKU %s not found", line.Sku))
 		}
-		if !item.CanReserve(line.Quantity) {
-			reason := fmt.Sprintf("Not enough stock for sku %s", line.Sku)
-			ev := domain.NewStockReservationFailedEvent(payload.OrderID, payload.UserID, reason)
-			return s.outbox.Enqueue(ctx, ev)
+		if err := item.Reserve(line.Quantity, now); err != nil {
+			if errors.Is(err, domain.ErrInvalidQuantity) {
+				return s.fail(ctx, payload, now, metrics.ReasonInvalidQuantity, fmt.Sprintf("Invalid quantity %d for sku %s", line.Quantity, line.Sku))
+			}
+			return s.fail(ctx, payload, now, metrics.ReasonInsufficientStock, fmt.Sprintf("Not enough stock for sku %s", line.Sku))
 		}
 	}
 
-	// Aplicar reservas en memoria
-	for _, line := range payload.Lines {
-		item := stockMap[line.Sku]
-		item.Reserve(line.Quantity)
-	}
-
 	// Construir agregados de reservación
 	resLines := make([]domain.ReservationLine, 0, len(payload.Lines))
-	resID := uuid.New()
 	for _, line := range payload.Lines {
 		resLines = append(resLines, domain.ReservationLine{
-			ID:            uuid.New(),
-			ReservationID: resID,
-			Sku:           line.Sku,
-			Quantity:      line.Quantity,
+			ID:       s.ids.NewID(),
+			Sku:      line.Sku,
+			Quantity: line.Quantity,
 		})
 	}
-
-	reservation := &domain.StockReservation{
-		ID:            resID,
-		OrderID:       payload.OrderID,
-		UserID:        payload.UserID,
-		Status:        domain.ReservationActive,
-		ReservedAtUtc: time.Now().UTC(),
-		ReleasedAtUtc: nil,
-		Lines:         resLines,
-	}
+	reservation := domain.NewStockReservation(s.ids.NewID(), payload.OrderID, payload.UserID, resLines, now)
 
 	// Persistir stock + reservación
-	items := make([]*domain.StockItem, 0, len(stockMap))
-	for _, item := range stockMap {
-		items = append(items, item)
-	}
+	items := sortedItems(stockMap)
 
 	if err := s.stockRepo.UpsertMany(ctx, items); err != nil {
-		return err
+		return ReserveResult{}, err
 	}
 	if err := s.reservationRepo.Insert(ctx, reservation); err != nil {
-		return err
+		return ReserveResult{}, err
 	}
 
 	// Evento StockReserved
@@ -122,23 +156,49 @@ func (s *ReserveStockService) HandleOrderPlaced(
 			Quantity: l.Quantity,
 		})
 	}
-	reservedEv := domain.NewStockReservedEvent(payload.OrderID, payload.UserID, evLines)
+	reservedEv := domain.NewStockReservedEvent(domain.NewEventMessage(s.ids.NewID(), now), payload.OrderID, payload.UserID, evLines)
 	if err := s.outbox.Enqueue(ctx, reservedEv); err != nil {
-		return err
+		return ReserveResult{}, err
 	}
 
 	// Eventos CatalogStockAdjusted por cada SKU afectado
 	for _, item := range items {
 		adjEv := domain.NewCatalogStockAdjustedEvent(
+			domain.NewEventMessage(s.ids.NewID(), now),
 			item.Sku,
 			item.Available,
 			item.Reserved,
 			"ORDER_RESERVED",
 		)
 		if err := s.outbox.Enqueue(ctx, adjEv); err != nil {
-			return err
+			return ReserveResult{}, err
 		}
 	}
 
-	return nil
+	return ReserveResult{Reservation: reservation}, nil
+}
+
+// fail encola StockReservationFailed.
+func (s *ReserveStockService) fail(
+	ctx context.Context,
+	payload domain.OrderPlacedPayload,
+	now time.Time,
+	reason, detail string,
+) (ReserveResult, error) {
+	ev := domain.NewStockReservationFailedEvent(domain.NewEventMessage(s.ids.NewID(), now), payload.OrderID, payload.UserID, detail)
+	if err := s.outbox.Enqueue(ctx, ev); err != nil {
+		return ReserveResult{}, err
+	}
+	return ReserveResult{FailureReason: reason, FailureDetail: detail}, nil
+}
+
+// sortedItems devuelve los items ordenados por SKU, para que los eventos
+// salgan siempre en el mismo orden.
+func sortedItems(stockMap map[string]*domain.StockItem) []*domain.StockItem {
+	items := make([]*domain.StockItem, 0, len(stockMap))
+	for _, item := range stockMap {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Sku < items[j].Sku })
+	return items
 }
@@ -0,0 +1,208 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+)
+
+func TestReserve(t *testing.T) {
+	orderID := uuid.New()
+	line := func(sku string, qty int) domain.OrderPlacedLine {
+		return domain.OrderPlacedLine{Sku: sku, Quantity: qty}
+	}
+
+	cases := []struct {
+		name       string
+		stock      map[string]int
+		lines      []domain.OrderPlacedLine
+		failOn     string // operación del store que falla
+		wantErr    bool
+		wantReason string
+		wantLevels levels
+		wantEvents []string
+	}{
+		{
+			name:       "reserves every line",
+			stock:      map[string]int{"A": 10, "B": 3},
+			lines:      []domain.OrderPlacedLine{line("A", 4), line("B", 3)},
+			wantLevels: levels{"A": {6, 4}, "B": {0, 3}},
+			wantEvents: []string{"StockReserved", "CatalogStockAdjusted", "CatalogStockAdjusted"},
+		},
+		{
+			name:       "no lines",
+			stock:      map[string]int{"A": 10},
+			wantReason: metrics.ReasonNoLines,
+			wantLevels: levels{"A": {10, 0}},
+			wantEvents: []string{"StockReservationFailed"},
+		},
+		{
+			name:       "unknown sku rejects the whole order",
+			stock:      map[string]int{"A": 10},
+			lines:      []domain.OrderPlacedLine{line("A", 1), line("X", 1)},
+			wantReason: metrics.ReasonSkuNotFound,
+			wantLevels: levels{"A": {10, 0}},
+			wantEvents: []string{"StockReservationFailed"},
+		},
+		{
+			name:       "insufficient stock rejects the whole order",
+			stock:      map[string]int{"A": 10, "B": 1},
+			lines:      []domain.OrderPlacedLine{line("A", 5), line("B", 2)},
+			wantReason: metrics.ReasonInsufficientStock,
+			wantLevels: levels{"A": {10, 0}, "B": {1, 0}},
+			wantEvents: []string{"StockReservationFailed"},
+		},
+		{
+			name:       "repeated sku lines share the available stock",
+			stock:      map[string]int{"A": 5},
+			lines:      []domain.OrderPlacedLine{line("A", 3), line("A", 3)},
+			wantReason: metrics.ReasonInsufficientStock,
+			wantLevels: levels{"A": {5, 0}},
+			wantEvents: []string{"StockReservationFailed"},
+		},
+		{
+			name:       "zero quantity is invalid",
+			stock:      map[string]int{"A": 10},
+			lines:      []domain.OrderPlacedLine{line("A", 0)},
+			wantReason: metrics.ReasonInvalidQuantity,
+			wantLevels: levels{"A": {10, 0}},
+			wantEvents: []string{"StockReservationFailed"},
+		},
+		{
+			name:       "stock write failure leaves nothing behind",
+			stock:      map[string]int{"A": 10},
+			lines:      []domain.OrderPlacedLine{line("A", 1)},
+			failOn:     "StockItemRepository.UpsertMany",
+			wantErr:    true,
+			wantLevels: levels{"A": {10, 0}},
+		},
+		{
+			name:       "reservation write failure rolls back the stock",
+			stock:      map[string]int{"A": 10},
+			lines:      []domain.OrderPlacedLine{line("A", 1)},
+			failOn:     "StockReservationRepository.Insert",
+			wantErr:    true,
+			wantLevels: levels{"A": {10, 0}},
+		},
+		{
+			name:       "outbox failure rolls back the stock and the reservation",
+			stock:      map[string]int{"A": 10},
+			lines:      []domain.OrderPlacedLine{line("A", 1)},
+			failOn:     "OutboxRepository.Insert",
+			wantErr:    true,
+			wantLevels: levels{"A": {10, 0}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t, tc.stock)
+			if tc.failOn != "" {
+				f.store.FailOn(tc.failOn, errors.New("boom"))
+			}
+
+			res, err := f.reserve.Reserve(context.Background(), domain.OrderPlacedPayload{
+				OrderID: orderID,
+				UserID:  uuid.New(),
+				Lines:   tc.lines,
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if res.FailureReason != tc.wantReason {
+				t.Errorf("reason = %q, want %q", res.FailureReason, tc.wantReason)
+			}
+
+			f.store.FailOn(tc.failOn, nil)
+			stored, _ := f.reservations.GetByOrderID(context.Background(), orderID)
+			succeeded := !tc.wantErr && tc.wantReason == ""
+			if succeeded != (stored != nil) {
+				t.Errorf("stored reservation = %v, want one: %v", stored, succeeded)
+			}
+			if succeeded {
+				if res.Reservation == nil || res.Reservation.Status != domain.ReservationActive {
+					t.Errorf("reservation = %+v, want active", res.Reservation)
+				}
+				if len(stored.Lines) != len(tc.lines) {
+					t.Errorf("stored lines = %d, want %d", len(stored.Lines), len(tc.lines))
+				}
+			}
+			f.assertState(t, tc.wantLevels, tc.wantEvents)
+		})
+	}
+}
+
+func TestReserveIsIdempotentPerOrder(t *testing.T) {
+	f := newFixture(t, map[string]int{"A": 10})
+	payload := domain.OrderPlacedPayload{
+		OrderID: uuid.New(),
+		Lines:   []domain.OrderPlacedLine{{Sku: "A", Quantity: 3}},
+	}
+
+	first, err := f.reserve.Reserve(context.Background(), payload)
+	if err != nil || first.AlreadyReserved {
+		t.Fatalf("first reserve = %+v, %v", first, err)
+	}
+	second, err := f.reserve.Reserve(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.AlreadyReserved || second.Reservation.ID != first.Reservation.ID {
+		t.Errorf("second reserve = %+v, want the first reservation", second)
+	}
+	f.assertState(t, levels{"A": {7, 3}}, []string{"StockReserved", "CatalogStockAdjusted"})
+}
+
+func TestReserveWithoutOrderIDIsPermanent(t *testing.T) {
+	f := newFixture(t, map[string]int{"A": 10})
+
+	_, err := f.reserve.Reserve(context.Background(), domain.OrderPlacedPayload{
+		Lines: []domain.OrderPlacedLine{{Sku: "A", Quantity: 1}},
+	})
+	if !IsPermanent(err) {
+		t.Fatalf("err = %v, want permanent", err)
+	}
+	f.assertState(t, levels{"A": {10, 0}}, nil)
+}
+
+func TestReserveAndReleaseAreReproducible(t *testing.T) {
+	orderID := uuid.NewSHA1(uuid.NameSpaceOID, []byte("order"))
+	run := func() []domain.OutboxMessage {
+		f := newFixture(t, map[string]int{"A": 10, "B": 5, "C": 1})
+		ctx := context.Background()
+		if _, err := f.reserve.Reserve(ctx, domain.OrderPlacedPayload{
+			OrderID: orderID,
+			Lines: []domain.OrderPlacedLine{
+				{Sku: "C", Quantity: 1}, {Sku: "A", Quantity: 2}, {Sku: "B", Quantity: 5},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.release.Release(ctx, orderID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.reserve.Reserve(ctx, domain.OrderPlacedPayload{
+			OrderID: uuid.NewSHA1(uuid.NameSpaceOID, []byte("other")),
+			Lines:   []domain.OrderPlacedLine{{Sku: "X", Quantity: 1}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return f.store.OutboxMessages()
+	}
+
+	first, second := run(), run()
+	if len(first) != 8 || len(first) != len(second) {
+		t.Fatalf("outbox sizes = %d and %d, want 8", len(first), len(second))
+	}
+	for i := range first {
+		a, b := first[i], second[i]
+		if a.ID != b.ID || a.Type != b.Type || a.PayloadJSON != b.PayloadJSON || a.OccurredAtUtc != b.OccurredAtUtc {
+			t.Errorf("outbox[%d] differs:\n%+v\n%+v", i, a, b)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Los SKUs del escenario; "D" no existe hasta que un ajuste lo crea.
+var accountingSkus = []string{"A", "B", "C", "D"}
+
+// runAccounting interpreta ops como eventos place/cancel/adjust contra los
+// servicios reales sobre repos en memoria, y verifica después de cada uno:
+//   - available y reserved nunca son negativos;
+//   - reserved de cada SKU es la suma de las reservaciones activas;
+//   - on-hand (available+reserved) solo cambia con un ajuste.
+//
+// Cada evento consume 4 bytes: tipo, y tres bytes de parámetros.
+func runAccounting(t *testing.T, ops []byte) {
+	ctx := context.Background()
+	f := newFixture(t, map[string]int{"A": 10, "B": 3, "C": 0})
+	products := NewProductCreatedHandler(f.uow, f.stock, f.writer, domain.NewSchemaRegistry(), f.clock, f.ids)
+
+	onHand := map[string]int{"A": 10, "B": 3, "C": 0}
+	var orders []uuid.UUID
+
+	for i := 0; i+3 < len(ops); i += 4 {
+		kind, p1, p2, p3 := ops[i]%3, int(ops[i+1]), int(ops[i+2]), int(ops[i+3])
+		var step string
+		var err error
+
+		switch kind {
+		case 0: // OrderPlaced con 1..3 líneas (el SKU se puede repetir), cantidades 0..5
+			payload := domain.OrderPlacedPayload{OrderID: uuid.New(), UserID: uuid.New()}
+			for n := 0; n <= p1%3; n++ {
+				payload.Lines = append(payload.Lines, domain.OrderPlacedLine{
+					Sku:      accountingSkus[(p2>>(2*n))%len(accountingSkus)],
+					Quantity: (p3 + n) % 6,
+				})
+			}
+			step = fmt.Sprintf("place %v", payload.Lines)
+			var res ReserveResult
+			res, err = f.reserve.Reserve(ctx, payload)
+			if err == nil && res.Reservation != nil {
+				orders = append(orders, payload.OrderID)
+			}
+		case 1: // OrderCancelled de una orden previa (a veces repetida) o desconocida
+			orderID := uuid.New()
+			if len(orders) > 0 && p1%4 != 0 {
+				orderID = orders[p2%len(orders)]
+			}
+			step = fmt.Sprintf("cancel %s", orderID)
+			_, err = f.release.Release(ctx, orderID)
+		case 2: // ProductCreated: fija el disponible
+			sku := accountingSkus[p1%len(accountingSkus)]
+			qty := p2 % 20
+			step = fmt.Sprintf("adjust %s to %d", sku, qty)
+			data, _ := json.Marshal(map[string]any{"sku": sku, "stockQuantity": qty})
+			env := primitives.NewIntegrationEventEnvelope("ProductCreated", string(data))
+			if err = products.Handle(ctx, &env); err == nil {
+				reserved := 0
+				if items, _ := f.stock.GetBySkus(ctx, []string{sku}); items[sku] != nil {
+					reserved = items[sku].Reserved
+				}
+				onHand[sku] = qty + reserved
+			}
+		}
+		if err != nil {
+			t.Fatalf("event %d (%s): %v", i/4, step, err)
+		}
+
+		reserved := map[string]int{}
+		for _, id := range orders {
+			res, err := f.reservations.GetByOrderID(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status == domain.ReservationActive {
+				for _, l := range res.Lines {
+					reserved[l.Sku] += l.Quantity
+				}
+			}
+		}
+
+		for _, item := range f.store.StockItems() {
+			if item.Available < 0 || item.Reserved < 0 {
+				t.Fatalf("after event %d (%s): negative stock %+v", i/4, step, item)
+			}
+			if item.Reserved != reserved[item.Sku] {
+				t.Fatalf("after event %d (%s): %s reserved = %d, active reservations hold %d",
+					i/4, step, item.Sku, item.Reserved, reserved[item.Sku])
+			}
+			if item.OnHand() != onHand[item.Sku] {
+				t.Fatalf("after event %d (%s): %s on-hand = %d, want %d",
+					i/4, step, item.Sku, item.OnHand(), onHand[item.Sku])
+			}
+		}
+	}
+}
+
+func FuzzStockAccounting(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 4, 0, 1, 0, 4, 1, 1, 0, 0, 1, 1, 0, 0})
+	f.Add([]byte{0, 2, 0, 3, 2, 0, 1, 0, 1, 1, 0, 0, 0, 0, 3, 2})
+	f.Add([]byte{0, 0, 1, 3, 0, 0, 1, 1, 2, 1, 0, 0, 1, 2, 0, 0})
+	f.Fuzz(runAccounting)
+}
+
+// Corre secuencias aleatorias con semillas fijas, así los casos son
+// reproducibles sin -fuzz.
+func TestStockAccountingRandomSequences(t *testing.T) {
+	for seed := int64(1); seed <= 200; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		ops := make([]byte, 4*(10+rng.Intn(50)))
+		rng.Read(ops)
+		t.Run(fmt.Sprint(seed), func(t *testing.T) { runAccounting(t, ops) })
+	}
+}
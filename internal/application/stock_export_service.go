@@ -0,0 +1,279 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Formatos de StockExportService.Export.
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+)
+
+// exportRowGroupSize acota las filas que el writer de Parquet junta en
+// memoria antes de escribir un row group.
+const exportRowGroupSize = 50_000
+
+// ExportStats cuenta lo que escribió Export.
+type ExportStats struct {
+	StockItems       int
+	Reservations     int
+	ReservationLines int
+}
+
+// exportRow es una fila del export: un item de stock (kind "stock") o una
+// línea de una reservación activa (kind "reservation"). Los campos del otro
+// tipo quedan vacíos (ausentes en NDJSON).
+type exportRow struct {
+	Kind          string     `json:"kind"`
+	Sku           string     `json:"sku"`
+	Available     *int64     `json:"available,omitempty"`
+	Reserved      *int64     `json:"reserved,omitempty"`
+	UpdatedAtUtc  *time.Time `json:"updatedAtUtc,omitempty"`
+	OrderID       string     `json:"orderId,omitempty"`
+	UserID        string     `json:"userId,omitempty"`
+	Quantity      *int64     `json:"quantity,omitempty"`
+	ReservedAtUtc *time.Time `json:"reservedAtUtc,omitempty"`
+}
+
+// parquetRow es exportRow con el schema de Parquet; los campos vacíos son
+// null. parquet-go no acepta punteros como timestamp: los tiempos van como
+// milisegundos Unix y el 0 se escribe como null.
+type parquetRow struct {
+	Kind          string `parquet:"kind"`
+	Sku           string `parquet:"sku"`
+	Available     *int64 `parquet:"available,optional"`
+	Reserved      *int64 `parquet:"reserved,optional"`
+	UpdatedAtUtc  int64  `parquet:"updated_at_utc,optional,timestamp(millisecond)"`
+	OrderID       string `parquet:"order_id,optional"`
+	UserID        string `parquet:"user_id,optional"`
+	Quantity      *int64 `parquet:"quantity,optional"`
+	ReservedAtUtc int64  `parquet:"reserved_at_utc,optional,timestamp(millisecond)"`
+}
+
+// exportColumns es el encabezado del CSV, con los nombres de las columnas
+// de Parquet.
+var exportColumns = []string{
+	"kind", "sku", "available", "reserved", "updated_at_utc",
+	"order_id", "user_id", "quantity", "reserved_at_utc",
+}
+
+// StockExportService escribe el stock (y opcionalmente las reservaciones
+// activas) en CSV, NDJSON o Parquet, leyendo de a pageSize filas: la memoria
+// no depende del tamaño de las tablas.
+type StockExportService struct {
+	repo     domain.StockExportRepository
+	pageSize int
+}
+
+func NewStockExportService(repo domain.StockExportRepository, pageSize int) *StockExportService {
+	return &StockExportService{repo: repo, pageSize: pageSize}
+}
+
+// Export escribe primero los items ordenados por SKU y después, si
+// withReservations, una fila por línea de cada reservación activa, ordenadas
+// por orden. Cada página se lee en su propia consulta, así que el export no
+// es un snapshot consistente si hay escrituras mientras corre.
+func (s *StockExportService) Export(
+	ctx context.Context,
+	w io.Writer,
+	format string,
+	withReservations bool,
+) (ExportStats, error) {
+	var stats ExportStats
+	out, err := newExportWriter(w, format)
+	if err != nil {
+		return stats, err
+	}
+
+	after := ""
+	for {
+		items, err := s.repo.StockItemsAfter(ctx, after, s.pageSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, item := range items {
+			updated := item.UpdatedAtUtc.UTC()
+			if err := out.write(exportRow{
+				Kind:         "stock",
+				Sku:          item.Sku,
+				Available:    int64Ptr(item.Available),
+				Reserved:     int64Ptr(item.Reserved),
+				UpdatedAtUtc: &updated,
+			}); err != nil {
+				return stats, err
+			}
+		}
+		stats.StockItems += len(items)
+		if err := out.flush(); err != nil {
+			return stats, err
+		}
+		if len(items) < s.pageSize {
+			break
+		}
+		after = items[len(items)-1].Sku
+	}
+
+	for orderAfter := uuid.Nil; withReservations; {
+		page, err := s.repo.ActiveReservationsAfter(ctx, orderAfter, s.pageSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, res := range page {
+			reserved := res.ReservedAtUtc.UTC()
+			for _, line := range res.Lines {
+				if err := out.write(exportRow{
+					Kind:          "reservation",
+					Sku:           line.Sku,
+					OrderID:       res.OrderID.String(),
+					UserID:        res.UserID.String(),
+					Quantity:      int64Ptr(line.Quantity),
+					ReservedAtUtc: &reserved,
+				}); err != nil {
+					return stats, err
+				}
+			}
+			stats.ReservationLines += len(res.Lines)
+		}
+		stats.Reservations += len(page)
+		if err := out.flush(); err != nil {
+			return stats, err
+		}
+		if len(page) < s.pageSize {
+			break
+		}
+		orderAfter = page[len(page)-1].OrderID
+	}
+
+	return stats, out.close()
+}
+
+func int64Ptr(v int) *int64 {
+	n := int64(v)
+	return &n
+}
+
+// exportWriter escribe filas en un formato; flush se llama al terminar cada
+// página y close una vez al final.
+type exportWriter interface {
+	write(row exportRow) error
+	flush() error
+	close() error
+}
+
+func newExportWriter(w io.Writer, format string) (exportWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{w: cw}, nil
+	case ExportFormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonExportWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case ExportFormatParquet:
+		return &parquetExportWriter{
+			w: parquet.NewGenericWriter[parquetRow](w, parquet.MaxRowsPerRowGroup(exportRowGroupSize)),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q, want %s, %s or %s",
+			format, ExportFormatCSV, ExportFormatNDJSON, ExportFormatParquet)
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) write(row exportRow) error {
+	return c.w.Write([]string{
+		row.Kind, row.Sku, formatInt(row.Available), formatInt(row.Reserved), formatTime(row.UpdatedAtUtc),
+		row.OrderID, row.UserID, formatInt(row.Quantity), formatTime(row.ReservedAtUtc),
+	})
+}
+
+func (c *csvExportWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) close() error { return c.flush() }
+
+func formatInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+type ndjsonExportWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonExportWriter) write(row exportRow) error { return n.enc.Encode(row) }
+func (n *ndjsonExportWriter) flush() error              { return n.w.Flush() }
+func (n *ndjsonExportWriter) close() error              { return n.w.Flush() }
+
+// parquetExportWriter junta las filas de una página y las escribe en flush;
+// los row groups los corta exportRowGroupSize, no las páginas.
+type parquetExportWriter struct {
+	w    *parquet.GenericWriter[parquetRow]
+	rows []parquetRow
+}
+
+func (p *parquetExportWriter) write(row exportRow) error {
+	p.rows = append(p.rows, parquetRow{
+		Kind:          row.Kind,
+		Sku:           row.Sku,
+		Available:     row.Available,
+		Reserved:      row.Reserved,
+		UpdatedAtUtc:  unixMilli(row.UpdatedAtUtc),
+		OrderID:       row.OrderID,
+		UserID:        row.UserID,
+		Quantity:      row.Quantity,
+		ReservedAtUtc: unixMilli(row.ReservedAtUtc),
+	})
+	return nil
+}
+
+func unixMilli(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func (p *parquetExportWriter) flush() error {
+	_, err := p.w.Write(p.rows)
+	p.rows = p.rows[:0]
+	return err
+}
+
+func (p *parquetExportWriter) close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
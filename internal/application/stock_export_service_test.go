@@ -0,0 +1,120 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+// newExportFixture tiene A 7/3, B 5/0 y C 0/0, una reservación activa de 3
+// A y otra ya liberada.
+func newExportFixture(t *testing.T) (*fixture, *StockExportService) {
+	t.Helper()
+	f := newFixture(t, map[string]int{"A": 10, "B": 5, "C": 0})
+	ctx := context.Background()
+	active := f.ids.NewID()
+	if _, err := f.reserve.Reserve(ctx, domain.OrderPlacedPayload{
+		OrderID: active, UserID: f.ids.NewID(), Lines: []domain.OrderPlacedLine{{Sku: "A", Quantity: 3}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	released := f.ids.NewID()
+	if _, err := f.reserve.Reserve(ctx, domain.OrderPlacedPayload{
+		OrderID: released, UserID: f.ids.NewID(), Lines: []domain.OrderPlacedLine{{Sku: "B", Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.release.Release(ctx, released); err != nil {
+		t.Fatal(err)
+	}
+	// páginas de 2 para pasar por más de una consulta
+	return f, NewStockExportService(memory.NewStockExportRepository(f.store), 2)
+}
+
+func TestStockExportCSV(t *testing.T) {
+	_, svc := newExportFixture(t)
+	var buf bytes.Buffer
+	stats, err := svc.Export(context.Background(), &buf, ExportFormatCSV, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (ExportStats{StockItems: 3, Reservations: 1, ReservationLines: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || lines[0] != strings.Join(exportColumns, ",") {
+		t.Fatalf("csv = %q", buf.String())
+	}
+	for i, prefix := range []string{"stock,A,7,3,", "stock,B,5,0,", "stock,C,0,0,", "reservation,A,,,,"} {
+		if !strings.HasPrefix(lines[i+1], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i+2, lines[i+1], prefix)
+		}
+	}
+	if !strings.Contains(lines[4], ",3,2024-01-01T") {
+		t.Errorf("reservation line = %q, want quantity 3 and reserved time", lines[4])
+	}
+}
+
+func TestStockExportNDJSONWithoutReservations(t *testing.T) {
+	_, svc := newExportFixture(t)
+	var buf bytes.Buffer
+	if _, err := svc.Export(context.Background(), &buf, ExportFormatNDJSON, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 3 || rows[2]["sku"] != "C" || rows[2]["available"] != float64(0) || rows[2]["kind"] != "stock" {
+		t.Fatalf("rows = %v, want 3 stock rows with C 0/0 last", rows)
+	}
+	if _, ok := rows[0]["orderId"]; ok {
+		t.Errorf("stock row has orderId: %v", rows[0])
+	}
+}
+
+func TestStockExportParquet(t *testing.T) {
+	_, svc := newExportFixture(t)
+	var buf bytes.Buffer
+	if _, err := svc.Export(context.Background(), &buf, ExportFormatParquet, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("rows = %+v, want 4", rows)
+	}
+	c, res := rows[2], rows[3]
+	if c.Sku != "C" || c.Available == nil || *c.Available != 0 || c.UpdatedAtUtc == 0 || c.Quantity != nil || c.ReservedAtUtc != 0 {
+		t.Errorf("C = %+v, want available 0 and no quantity", c)
+	}
+	if res.Kind != "reservation" || res.Quantity == nil || *res.Quantity != 3 || res.Available != nil ||
+		res.UpdatedAtUtc != 0 || res.ReservedAtUtc != fixtureStart.Add(3*time.Second).UnixMilli() {
+		t.Errorf("reservation = %+v", res)
+	}
+}
+
+func TestStockExportRejectsUnknownFormat(t *testing.T) {
+	_, svc := newExportFixture(t)
+	var buf bytes.Buffer
+	if _, err := svc.Export(context.Background(), &buf, "xlsx", false); err == nil || buf.Len() != 0 {
+		t.Errorf("Export(xlsx) = %v, wrote %d bytes", err, buf.Len())
+	}
+}
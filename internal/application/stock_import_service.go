@@ -0,0 +1,364 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Formatos de StockImportService.Import.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// maxImportErrors es el tope de errores por fila que se devuelven; el resto
+// solo se cuenta en ErrorCount.
+const maxImportErrors = 1000
+
+// ImportRowError es un error de validación de una fila (Line empieza en 1 e
+// incluye el encabezado del CSV).
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Sku   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportResult resume una importación (el comando import lo imprime como
+// JSON): Rows son las filas válidas (sin contar las duplicadas, que son
+// errores) y Skus los SKUs distintos entre ellas. Con errores de fila no se escribe nada,
+// aunque no sea dry-run.
+type ImportResult struct {
+	DryRun     bool             `json:"dryRun"`
+	Applied    bool             `json:"applied"`
+	Rows       int              `json:"rows"`
+	Skus       int              `json:"skus"`
+	Created    int              `json:"created"`
+	Updated    int              `json:"updated"`
+	Unchanged  int              `json:"unchanged"`
+	ErrorCount int              `json:"errorCount"`
+	Errors     []ImportRowError `json:"errors"`
+}
+
+// importRow es una fila válida del archivo.
+type importRow struct {
+	line     int
+	sku      string
+	quantity int
+	location string
+}
+
+// StockImportService carga niveles de stock desde CSV o NDJSON con columnas
+// sku, quantity y location (opcional). El servicio lleva stock por SKU, no
+// por ubicación: las filas de un SKU en varias ubicaciones se suman, y la
+// suma reemplaza el available del SKU (como ProductCreated). El reserved no
+// se toca.
+type StockImportService struct {
+	uow       domain.UnitOfWork
+	stockRepo domain.StockItemRepository
+	outbox    OutboxWriter
+	chunkSize int
+	clock     domain.Clock
+	ids       domain.IDGenerator
+}
+
+func NewStockImportService(
+	uow domain.UnitOfWork,
+	stockRepo domain.StockItemRepository,
+	outbox OutboxWriter,
+	chunkSize int,
+	clock domain.Clock,
+	ids domain.IDGenerator,
+) *StockImportService {
+	return &StockImportService{
+		uow:       uow,
+		stockRepo: stockRepo,
+		outbox:    outbox,
+		chunkSize: chunkSize,
+		clock:     clock,
+		ids:       ids,
+	}
+}
+
+// Import valida todo el archivo antes de escribir: si alguna fila tiene
+// errores devuelve el reporte sin tocar el stock. Si no, guarda los SKUs
+// que cambian de a chunkSize por UpsertMany y encola un
+// CatalogStockAdjusted por cada uno; con dryRun solo calcula el resultado.
+//
+// Cada chunk se lee, se guarda y encola sus mensajes en una unidad de
+// trabajo, así un Reserve o Release concurrente espera y su reserved no se
+// pisa. Un error de escritura deja aplicados los chunks anteriores;
+// reimportar el mismo archivo es seguro porque los SKUs ya cargados quedan
+// sin cambios.
+func (s *StockImportService) Import(
+	ctx context.Context,
+	r io.Reader,
+	format string,
+	dryRun bool,
+) (ImportResult, error) {
+	result := ImportResult{DryRun: dryRun, Errors: []ImportRowError{}}
+	rowErr := func(line int, sku, msg string) {
+		result.ErrorCount++
+		if len(result.Errors) < maxImportErrors {
+			result.Errors = append(result.Errors, ImportRowError{Line: line, Sku: sku, Error: msg})
+		}
+	}
+
+	quantities := map[string]int{}
+	seen := map[string]int{} // sku + "\x00" + location -> línea
+	err := readImportRows(r, format, rowErr, func(row importRow) {
+		key := row.sku + "\x00" + row.location
+		if first, ok := seen[key]; ok {
+			rowErr(row.line, row.sku, fmt.Sprintf("duplicate sku and location, first seen on line %d", first))
+			return
+		}
+		seen[key] = row.line
+		result.Rows++
+		quantities[row.sku] += row.quantity
+	})
+	if err != nil {
+		return result, err
+	}
+	if result.ErrorCount > 0 {
+		return result, nil
+	}
+
+	skus := make([]string, 0, len(quantities))
+	for sku := range quantities {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+	result.Skus = len(skus)
+
+	for start := 0; start < len(skus); start += s.chunkSize {
+		chunk := skus[start:min(start+s.chunkSize, len(skus))]
+		if dryRun {
+			err = s.importChunk(ctx, chunk, quantities, dryRun, &result)
+		} else {
+			err = s.uow.Do(ctx, func(ctx context.Context) error {
+				return s.importChunk(ctx, chunk, quantities, dryRun, &result)
+			})
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	result.Applied = !dryRun
+	slog.InfoContext(ctx, "stock import finished",
+		"dryRun", dryRun, "rows", result.Rows, "skus", result.Skus,
+		"created", result.Created, "updated", result.Updated, "unchanged", result.Unchanged)
+	return result, nil
+}
+
+func (s *StockImportService) importChunk(
+	ctx context.Context,
+	skus []string,
+	quantities map[string]int,
+	dryRun bool,
+	result *ImportResult,
+) error {
+	existing, err := s.stockRepo.GetBySkus(ctx, skus)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	var changed []*domain.StockItem
+	for _, sku := range skus {
+		qty := quantities[sku]
+		item, ok := existing[sku]
+		switch {
+		case !ok:
+			var err error
+			if item, err = domain.NewStockItem(s.ids.NewID(), sku, qty, now); err != nil {
+				return err
+			}
+			result.Created++
+		case item.Available == qty:
+			result.Unchanged++
+			continue
+		default:
+			if err := item.SetAvailable(qty, now); err != nil {
+				return err
+			}
+			result.Updated++
+		}
+		changed = append(changed, item)
+	}
+	if dryRun || len(changed) == 0 {
+		return nil
+	}
+
+	if err := s.stockRepo.UpsertMany(ctx, changed); err != nil {
+		return err
+	}
+	for _, item := range changed {
+		adjEv := domain.NewCatalogStockAdjustedEvent(
+			domain.NewEventMessage(s.ids.NewID(), now),
+			item.Sku,
+			item.Available,
+			item.Reserved,
+			"IMPORT",
+		)
+		if err := s.outbox.Enqueue(ctx, adjEv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readImportRows llama a row por cada fila válida y a rowErr por cada
+// inválida. Solo devuelve error si no se puede leer r o el formato no
+// existe.
+func readImportRows(
+	r io.Reader,
+	format string,
+	rowErr func(line int, sku, msg string),
+	row func(importRow),
+) error {
+	switch format {
+	case ImportFormatCSV:
+		return readImportCSV(r, rowErr, row)
+	case ImportFormatNDJSON:
+		return readImportNDJSON(r, rowErr, row)
+	default:
+		return fmt.Errorf("unknown import format %q, want %s or %s", format, ImportFormatCSV, ImportFormatNDJSON)
+	}
+}
+
+// readImportCSV espera un encabezado con sku y quantity (location es
+// opcional); el orden de las columnas es libre y las demás se ignoran.
+func readImportCSV(r io.Reader, rowErr func(int, string, string), row func(importRow)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return importReadError(err, rowErr)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	skuCol, hasSku := cols["sku"]
+	qtyCol, hasQty := cols["quantity"]
+	locCol, hasLoc := cols["location"]
+	if !hasSku || !hasQty {
+		rowErr(1, "", "header must have sku and quantity columns")
+		return nil
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if err := importReadError(err, rowErr); err != nil {
+				return err
+			}
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(i int) string {
+			if i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		in := importRow{line: line, sku: field(skuCol)}
+		if hasLoc {
+			in.location = field(locCol)
+		}
+		qty, err := strconv.Atoi(field(qtyCol))
+		if err != nil {
+			rowErr(line, in.sku, fmt.Sprintf("quantity %q is not an integer", field(qtyCol)))
+			continue
+		}
+		in.quantity = qty
+		if msg := validateImportRow(in); msg != "" {
+			rowErr(line, in.sku, msg)
+			continue
+		}
+		row(in)
+	}
+}
+
+// importReadError reporta un error de parseo del CSV en su línea; los de
+// lectura de r se devuelven.
+func importReadError(err error, rowErr func(int, string, string)) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		rowErr(parseErr.StartLine, "", parseErr.Err.Error())
+		return nil
+	}
+	return err
+}
+
+// ndjsonRow es una línea de NDJSON; quantity es json.Number para rechazar
+// decimales.
+type ndjsonRow struct {
+	Sku      string      `json:"sku"`
+	Quantity json.Number `json:"quantity"`
+	Location string      `json:"location"`
+}
+
+func readImportNDJSON(r io.Reader, rowErr func(int, string, string), row func(importRow)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var in ndjsonRow
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+		if err := dec.Decode(&in); err != nil {
+			rowErr(line, "", "invalid JSON: "+err.Error())
+			continue
+		}
+		qty, err := strconv.Atoi(in.Quantity.String())
+		if err != nil {
+			rowErr(line, in.Sku, fmt.Sprintf("quantity %q is not an integer", in.Quantity.String()))
+			continue
+		}
+		parsed := importRow{
+			line:     line,
+			sku:      strings.TrimSpace(in.Sku),
+			quantity: qty,
+			location: strings.TrimSpace(in.Location),
+		}
+		if msg := validateImportRow(parsed); msg != "" {
+			rowErr(line, parsed.sku, msg)
+			continue
+		}
+		row(parsed)
+	}
+	return sc.Err()
+}
+
+func validateImportRow(row importRow) string {
+	switch {
+	case row.sku == "":
+		return "sku is required"
+	case row.quantity < 0:
+		return "quantity must be >= 0"
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+func TestStockImport(t *testing.T) {
+	cases := []struct {
+		name       string
+		format     string
+		input      string
+		dryRun     bool
+		want       ImportResult
+		wantErrors []ImportRowError
+		wantLevels levels
+		wantEvents []string
+	}{
+		{
+			name:   "csv sums locations, skips unchanged skus and keeps reserved",
+			format: ImportFormatCSV,
+			input: "location,sku,quantity\n" +
+				"WH-1,OLD,10\n" +
+				"WH-2,OLD,10\n" +
+				"WH-1,SAME,5\n" +
+				"WH-1,NEW,4\n",
+			want:       ImportResult{Applied: true, Rows: 4, Skus: 3, Created: 1, Updated: 1, Unchanged: 1},
+			wantLevels: levels{"OLD": {20, 2}, "SAME": {5, 0}, "NEW": {4, 0}},
+			wantEvents: []string{"CatalogStockAdjusted", "CatalogStockAdjusted"},
+		},
+		{
+			name:   "ndjson without location",
+			format: ImportFormatNDJSON,
+			input: `{"sku": "NEW", "quantity": 4}` + "\n\n" +
+				`{"sku": "OLD", "quantity": 0}` + "\n",
+			want:       ImportResult{Applied: true, Rows: 2, Skus: 2, Created: 1, Updated: 1},
+			wantLevels: levels{"OLD": {0, 2}, "SAME": {5, 0}, "NEW": {4, 0}},
+			wantEvents: []string{"CatalogStockAdjusted", "CatalogStockAdjusted"},
+		},
+		{
+			name:       "dry run reports the changes without writing",
+			format:     ImportFormatCSV,
+			input:      "sku,quantity\nOLD,1\nNEW,4\n",
+			dryRun:     true,
+			want:       ImportResult{DryRun: true, Rows: 2, Skus: 2, Created: 1, Updated: 1},
+			wantLevels: levels{"OLD": {3, 2}, "SAME": {5, 0}},
+		},
+		{
+			name:   "any invalid row rejects the whole file",
+			format: ImportFormatCSV,
+			input: "sku,quantity,location\n" +
+				"NEW,4,WH-1\n" +
+				",1,WH-1\n" +
+				"OLD,-1,WH-1\n" +
+				"OLD,x,WH-1\n" +
+				"NEW,2,WH-1\n",
+			want: ImportResult{Rows: 1, ErrorCount: 4},
+			wantErrors: []ImportRowError{
+				{Line: 3, Error: "sku is required"},
+				{Line: 4, Sku: "OLD", Error: "quantity must be >= 0"},
+				{Line: 5, Sku: "OLD", Error: `quantity "x" is not an integer`},
+				{Line: 6, Sku: "NEW", Error: "duplicate sku and location, first seen on line 2"},
+			},
+			wantLevels: levels{"OLD": {3, 2}, "SAME": {5, 0}},
+		},
+		{
+			name:       "csv without a quantity column",
+			format:     ImportFormatCSV,
+			input:      "sku,qty\nNEW,4\n",
+			want:       ImportResult{ErrorCount: 1},
+			wantErrors: []ImportRowError{{Line: 1, Error: "header must have sku and quantity columns"}},
+			wantLevels: levels{"OLD": {3, 2}, "SAME": {5, 0}},
+		},
+		{
+			name:   "ndjson with bad lines",
+			format: ImportFormatNDJSON,
+			input:  `{"sku": "NEW", "quantity": 1.5}` + "\n" + `not json` + "\n",
+			want:   ImportResult{ErrorCount: 2},
+			wantErrors: []ImportRowError{
+				{Line: 1, Sku: "NEW", Error: `quantity "1.5" is not an integer`},
+				{Line: 2, Error: "invalid JSON: invalid character 'o' in literal null (expecting 'u')"},
+			},
+			wantLevels: levels{"OLD": {3, 2}, "SAME": {5, 0}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t, map[string]int{"OLD": 5, "SAME": 5})
+			item, err := f.stock.GetBySkus(context.Background(), []string{"OLD"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := item["OLD"].Reserve(2, f.clock.Now()); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.stock.UpsertMany(context.Background(), []*domain.StockItem{item["OLD"]}); err != nil {
+				t.Fatal(err)
+			}
+
+			// chunk de 2 para pasar por más de un UpsertMany
+			svc := NewStockImportService(f.uow, f.stock, f.writer, 2, f.clock, f.ids)
+			got, err := svc.Import(context.Background(), strings.NewReader(tc.input), tc.format, tc.dryRun)
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			errs := got.Errors
+			got.Errors = nil
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("result = %+v, want %+v", got, tc.want)
+			}
+			if len(errs) != len(tc.wantErrors) {
+				t.Fatalf("errors = %+v, want %+v", errs, tc.wantErrors)
+			}
+			for i := range errs {
+				if errs[i] != tc.wantErrors[i] {
+					t.Errorf("errors[%d] = %+v, want %+v", i, errs[i], tc.wantErrors[i])
+				}
+			}
+			f.assertState(t, tc.wantLevels, tc.wantEvents)
+		})
+	}
+}
+
+func TestStockImportRejectsUnknownFormat(t *testing.T) {
+	f := newFixture(t, nil)
+	svc := NewStockImportService(f.uow, f.stock, f.writer, 10, f.clock, f.ids)
+	if _, err := svc.Import(context.Background(), strings.NewReader("{}"), "xml", false); err == nil {
+		t.Error("Import with format xml succeeded")
+	}
+}
+
+// Si falla el outbox, el chunk tampoco deja stock escrito.
+func TestStockImportOutboxFailureDiscardsChunk(t *testing.T) {
+	f := newFixture(t, map[string]int{"A": 1})
+	f.store.FailOn("OutboxRepository.Insert", errors.New("boom"))
+	svc := NewStockImportService(f.uow, f.stock, f.writer, 10, f.clock, f.ids)
+	if _, err := svc.Import(context.Background(), strings.NewReader("sku,quantity\nA,5\nB,3\n"), ImportFormatCSV, false); err == nil {
+		t.Fatal("Import succeeded with a failing outbox")
+	}
+	f.store.FailOn("OutboxRepository.Insert", nil)
+	f.assertState(t, levels{"A": {1, 0}}, nil)
+}
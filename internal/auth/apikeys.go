@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// apiKey es una entrada del archivo de API keys. Se puede guardar el key en
+// claro (key) o solo su SHA-256 en hex (sha256), que es lo recomendado.
+type apiKey struct {
+	Name   string `yaml:"name"`
+	Key    string `yaml:"key"`
+	SHA256 string `yaml:"sha256"`
+	Roles  []Role `yaml:"roles"`
+}
+
+// LoadAPIKeys lee el archivo (YAML o JSON) y devuelve los principals por
+// SHA-256 del key, para no tener los keys en claro en memoria.
+func LoadAPIKeys(path string) (map[string]Principal, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("api keys: %w", err)
+	}
+
+	var entries []apiKey
+	if err := yaml.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("api keys %s: %w", path, err)
+	}
+
+	keys := make(map[string]Principal, len(entries))
+	for i, e := range entries {
+		if e.Name == "" {
+			return nil, fmt.Errorf("api keys %s: entry %d has no name", path, i)
+		}
+		for _, r := range e.Roles {
+			if _, ok := roleLevel[r]; !ok {
+				return nil, fmt.Errorf("api keys %s: %s: unknown role %q", path, e.Name, r)
+			}
+		}
+
+		var hash string
+		switch {
+		case e.SHA256 != "":
+			hash = strings.ToLower(e.SHA256)
+			if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("api keys %s: %s: invalid sha256", path, e.Name)
+			}
+		case e.Key != "":
+			hash = hashAPIKey(e.Key)
+		default:
+			return nil, fmt.Errorf("api keys %s: %s: key or sha256 is required", path, e.Name)
+		}
+
+		keys[hash] = Principal{Subject: e.Name, Roles: e.Roles, Method: "apikey"}
+	}
+	return keys, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
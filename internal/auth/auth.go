@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Role es un rol de la API. Los roles son jerárquicos: admin incluye a
+// warehouse-operator y warehouse-operator incluye a reader.
+type Role string
+
+const (
+	RoleReader            Role = "reader"
+	RoleWarehouseOperator Role = "warehouse-operator"
+	RoleAdmin             Role = "admin"
+)
+
+var roleLevel = map[Role]int{
+	RoleReader:            1,
+	RoleWarehouseOperator: 2,
+	RoleAdmin:             3,
+}
+
+var (
+	// ErrUnauthenticated: no hay credenciales o no son válidas.
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+// Principal es quien hace el request: el sub del JWT o el nombre del API key.
+type Principal struct {
+	Subject string
+	Roles   []Role
+	Method  string // "jwt" o "apikey"
+}
+
+// Has indica si algún rol del principal alcanza el rol pedido.
+func (p Principal) Has(role Role) bool {
+	want := roleLevel[role]
+	for _, r := range p.Roles {
+		if lvl, ok := roleLevel[r]; ok && lvl >= want {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext devuelve el principal autenticado, si lo hay.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// Authenticator valida bearer JWTs contra claves locales (JWKS y/o secreto
+// HMAC, sin salir a la red) y API keys para llamadas entre servicios.
+type Authenticator struct {
+	jwks       map[string]any
+	hmacSecret []byte
+	apiKeys    map[string]Principal
+	rolesClaim string
+	parser     *jwt.Parser
+}
+
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		rolesClaim: cfg.RolesClaim,
+		apiKeys:    map[string]Principal{},
+	}
+	if cfg.HMACSecret != "" {
+		a.hmacSecret = []byte(cfg.HMACSecret)
+	}
+
+	var err error
+	if cfg.JWKSFile != "" {
+		if a.jwks, err = LoadJWKS(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.APIKeysFile != "" {
+		if a.apiKeys, err = LoadAPIKeys(cfg.APIKeysFile); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{
+			"RS256", "RS384", "RS512", "PS256", "PS384", "PS512",
+			"ES256", "ES384", "ES512", "EdDSA",
+			"HS256", "HS384", "HS512",
+		}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Duration(cfg.ClockSkewSec) * time.Second),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+// Authenticate toma el principal de "Authorization: Bearer <jwt>" o de
+// X-API-Key. Cualquier falla se reporta como ErrUnauthenticated.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	return a.AuthenticateCredentials(r.Header.Get("Authorization"), r.Header.Get(apiKeyHeader))
+}
+
+// AuthenticateCredentials es Authenticate para transportes sin
+// http.Request (ej. metadata gRPC): authorization es el valor completo
+// "Bearer <jwt>" y apiKey el del header x-api-key.
+func (a *Authenticator) AuthenticateCredentials(authorization, apiKey string) (Principal, error) {
+	if apiKey != "" {
+		p, ok := a.apiKeys[hashAPIKey(apiKey)]
+		if !ok {
+			return Principal{}, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+		}
+		return p, nil
+	}
+
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, fmt.Errorf("%w: missing bearer token", ErrUnauthenticated)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.keyFor); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	sub, _ := claims.GetSubject()
+	return Principal{
+		Subject: sub,
+		Roles:   rolesFrom(claims, a.rolesClaim),
+		Method:  "jwt",
+	}, nil
+}
+
+// keyFor elige la clave por kid. Los tokens HMAC solo se aceptan con una
+// clave simétrica, así un token firmado con la clave pública no pasa.
+func (a *Authenticator) keyFor(t *jwt.Token) (any, error) {
+	_, isHMAC := t.Method.(*jwt.SigningMethodHMAC)
+
+	var key any
+	if kid, _ := t.Header["kid"].(string); kid != "" {
+		k, ok := a.jwks[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		key = k
+	} else if isHMAC && a.hmacSecret != nil {
+		key = a.hmacSecret
+	} else if len(a.jwks) == 1 {
+		for _, k := range a.jwks {
+			key = k
+		}
+	} else {
+		return nil, fmt.Errorf("token has no kid")
+	}
+
+	if _, symmetric := key.([]byte); symmetric != isHMAC {
+		return nil, fmt.Errorf("algorithm %s does not match key type", t.Method.Alg())
+	}
+	return key, nil
+}
+
+// rolesFrom lee los roles del claim configurado. Acepta rutas con punto
+// (ej. "realm_access.roles") y tanto un arreglo como un string separado por
+// espacios.
+func rolesFrom(claims jwt.MapClaims, claim string) []Role {
+	var v any = map[string]any(claims)
+	for _, part := range strings.Split(claim, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+
+	var roles []Role
+	switch vv := v.(type) {
+	case []any:
+		for _, r := range vv {
+			if s, ok := r.(string); ok {
+				roles = append(roles, Role(s))
+			}
+		}
+	case string:
+		for _, s := range strings.Fields(vv) {
+			roles = append(roles, Role(s))
+		}
+	}
+	return roles
+}
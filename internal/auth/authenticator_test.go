@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// testKeys son las claves privadas de las que el JWKS del test publica la
+// parte pública.
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	ed      ed25519.PrivateKey
+	oct     []byte
+	rsaDER  []byte // clave pública RSA en DER, para el ataque de confusión
+	jwksDir string
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{
+		rsa:     rsaKey,
+		ec:      ecKey,
+		ed:      edKey,
+		oct:     []byte("oct-secret-oct-secret-oct-secret"),
+		rsaDER:  der,
+		jwksDir: t.TempDir(),
+	}
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// writeJWKS escribe las claves públicas con los kid pedidos y devuelve la
+// ruta del archivo.
+func (k *testKeys) writeJWKS(t *testing.T, kids ...string) string {
+	t.Helper()
+	all := map[string]map[string]string{
+		"rsa": {"kty": "RSA", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		"ec":  {"kty": "EC", "crv": "P-256", "x": b64(k.ec.X.FillBytes(make([]byte, 32))), "y": b64(k.ec.Y.FillBytes(make([]byte, 32)))},
+		"ed":  {"kty": "OKP", "crv": "Ed25519", "x": b64(k.ed.Public().(ed25519.PublicKey))},
+		"oct": {"kty": "oct", "k": b64(k.oct)},
+	}
+	var keys []map[string]string
+	for _, kid := range kids {
+		jwk := all[kid]
+		jwk["kid"] = kid
+		keys = append(keys, jwk)
+	}
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(k.jwksDir, strings.Join(kids, "-")+".json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	signed, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "alice",
+		"iss":   "https://idp.test",
+		"aud":   "inventory",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"reader"},
+	}
+}
+
+func without(claims jwt.MapClaims, key string) jwt.MapClaims {
+	delete(claims, key)
+	return claims
+}
+
+func with(claims jwt.MapClaims, key string, v any) jwt.MapClaims {
+	claims[key] = v
+	return claims
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	k := newTestKeys(t)
+	base := config.AuthConfig{
+		Enabled:    true,
+		JWKSFile:   k.writeJWKS(t, "rsa", "ec", "ed", "oct"),
+		HMACSecret: testSecret,
+		Issuer:     "https://idp.test",
+		Audience:   "inventory",
+		RolesClaim: "roles",
+	}
+
+	cases := []struct {
+		name  string
+		cfg   func(config.AuthConfig) config.AuthConfig
+		token string
+		ok    bool
+	}{
+		{name: "HS256 with the shared secret", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), validClaims()), ok: true},
+		{name: "RS256 by kid", token: sign(t, jwt.SigningMethodRS256, "rsa", k.rsa, validClaims()), ok: true},
+		{name: "ES256 by kid", token: sign(t, jwt.SigningMethodES256, "ec", k.ec, validClaims()), ok: true},
+		{name: "EdDSA by kid", token: sign(t, jwt.SigningMethodEdDSA, "ed", k.ed, validClaims()), ok: true},
+		{name: "HS256 with an oct key by kid", token: sign(t, jwt.SigningMethodHS256, "oct", k.oct, validClaims()), ok: true},
+		{
+			// El ataque clásico: firmar HS256 usando la clave pública como
+			// secreto.
+			name:  "HS256 signed with the RSA public key",
+			token: sign(t, jwt.SigningMethodHS256, "rsa", k.rsaDER, validClaims()),
+		},
+		{
+			name: "HS256 without kid against a single public key",
+			cfg: func(c config.AuthConfig) config.AuthConfig {
+				c.JWKSFile = k.writeJWKS(t, "rsa")
+				c.HMACSecret = ""
+				return c
+			},
+			token: sign(t, jwt.SigningMethodHS256, "", k.rsaDER, validClaims()),
+		},
+		{name: "RS256 pointing at an oct key", token: sign(t, jwt.SigningMethodRS256, "oct", k.rsa, validClaims())},
+		{name: "unknown kid", token: sign(t, jwt.SigningMethodRS256, "other", k.rsa, validClaims())},
+		{name: "wrong secret", token: sign(t, jwt.SigningMethodHS256, "", []byte(strings.Repeat("x", 32)), validClaims())},
+		{name: "missing exp", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), without(validClaims(), "exp"))},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), with(validClaims(), "exp", time.Now().Add(-time.Hour).Unix()))},
+		{
+			name:  "expired within the clock skew",
+			cfg:   func(c config.AuthConfig) config.AuthConfig { c.ClockSkewSec = 60; return c },
+			token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), with(validClaims(), "exp", time.Now().Add(-30*time.Second).Unix())),
+			ok:    true,
+		},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), with(validClaims(), "iss", "https://evil.test"))},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), with(validClaims(), "aud", "billing"))},
+		{name: "alg none", token: sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, validClaims())},
+		{name: "not a bearer token", token: "Basic YWxpY2U6c2VjcmV0"},
+		{name: "empty bearer", token: "Bearer "},
+		{name: "no credentials"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base
+			if tc.cfg != nil {
+				cfg = tc.cfg(cfg)
+			}
+			a, err := NewAuthenticator(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := a.AuthenticateCredentials(tc.token, "")
+			if !tc.ok {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("AuthenticateCredentials = %+v, %v; want ErrUnauthenticated", p, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthenticateCredentials: %v", err)
+			}
+			want := Principal{Subject: "alice", Roles: []Role{RoleReader}, Method: "jwt"}
+			if !reflect.DeepEqual(p, want) {
+				t.Errorf("principal = %+v, want %+v", p, want)
+			}
+		})
+	}
+}
+
+func TestRolesFrom(t *testing.T) {
+	claims := jwt.MapClaims{
+		"roles":        []any{"reader", "admin", 7},
+		"scope":        "reader  warehouse-operator",
+		"realm_access": map[string]any{"roles": []any{"admin"}},
+		"flat":         "x",
+	}
+	cases := []struct {
+		claim string
+		want  []Role
+	}{
+		{"roles", []Role{RoleReader, RoleAdmin}},
+		{"scope", []Role{RoleReader, RoleWarehouseOperator}},
+		{"realm_access.roles", []Role{RoleAdmin}},
+		{"realm_access.missing", nil},
+		{"missing.roles", nil},
+		{"flat.roles", nil},
+	}
+	for _, tc := range cases {
+		if got := rolesFrom(claims, tc.claim); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("rolesFrom(%q) = %v, want %v", tc.claim, got, tc.want)
+		}
+	}
+}
+
+func TestPrincipalHas(t *testing.T) {
+	cases := []struct {
+		roles []Role
+		want  map[Role]bool
+	}{
+		{nil, map[Role]bool{RoleReader: false, RoleWarehouseOperator: false, RoleAdmin: false}},
+		{[]Role{"auditor"}, map[Role]bool{RoleReader: false, RoleWarehouseOperator: false, RoleAdmin: false}},
+		{[]Role{RoleReader}, map[Role]bool{RoleReader: true, RoleWarehouseOperator: false, RoleAdmin: false}},
+		{[]Role{RoleWarehouseOperator}, map[Role]bool{RoleReader: true, RoleWarehouseOperator: true, RoleAdmin: false}},
+		{[]Role{RoleReader, RoleAdmin}, map[Role]bool{RoleReader: true, RoleWarehouseOperator: true, RoleAdmin: true}},
+	}
+	for _, tc := range cases {
+		p := Principal{Roles: tc.roles}
+		for role, want := range tc.want {
+			if got := p.Has(role); got != want {
+				t.Errorf("%v.Has(%s) = %v, want %v", tc.roles, role, got, want)
+			}
+		}
+	}
+}
+
+func writeAPIKeys(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAPIKeys(t *testing.T) {
+	sum := sha256.Sum256([]byte("orders-key"))
+	path := writeAPIKeys(t, `
+- name: warehouse
+  key: plain-key
+  roles: [warehouse-operator]
+- name: orders
+  sha256: `+strings.ToUpper(hex.EncodeToString(sum[:]))+`
+  roles: [reader]
+`)
+	a, err := NewAuthenticator(config.AuthConfig{Enabled: true, APIKeysFile: path, RolesClaim: "roles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hash := range a.apiKeys {
+		if strings.Contains(hash, "plain-key") {
+			t.Error("api keys are kept in clear")
+		}
+	}
+
+	cases := []struct {
+		key  string
+		want Principal
+	}{
+		{"plain-key", Principal{Subject: "warehouse", Roles: []Role{RoleWarehouseOperator}, Method: "apikey"}},
+		{"orders-key", Principal{Subject: "orders", Roles: []Role{RoleReader}, Method: "apikey"}},
+	}
+	for _, tc := range cases {
+		p, err := a.AuthenticateCredentials("", tc.key)
+		if err != nil || !reflect.DeepEqual(p, tc.want) {
+			t.Errorf("key %q = %+v, %v; want %+v", tc.key, p, err, tc.want)
+		}
+	}
+	// Un API key inválido no cae al bearer token.
+	bearer := sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), validClaims())
+	if _, err := a.AuthenticateCredentials(bearer, "wrong"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("unknown key = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestLoadAPIKeysErrors(t *testing.T) {
+	cases := map[string]string{
+		"no name":        "- key: k\n  roles: [reader]\n",
+		"unknown role":   "- name: a\n  key: k\n  roles: [root]\n",
+		"bad sha256":     "- name: a\n  sha256: abc\n",
+		"no key":         "- name: a\n  roles: [reader]\n",
+		"not a sequence": "name: a\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadAPIKeys(writeAPIKeys(t, content)); err == nil {
+				t.Error("LoadAPIKeys = nil error")
+			}
+		})
+	}
+	if _, err := LoadAPIKeys(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadAPIKeys of a missing file = nil error")
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// field es una hoja de Config (ej. "messaging.prefetch").
+type field struct {
+	Key    string
+	Env    []string
+	Secret bool
+	Value  reflect.Value
+}
+
+// fields recorre Config y devuelve sus hojas en orden de declaración.
+func fields(v reflect.Value, prefix string) []field {
+	var out []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		key := prefix + name
+
+		if sf.Type.Kind() == reflect.Struct {
+			out = append(out, fields(v.Field(i), key+".")...)
+			continue
+		}
+
+		f := field{Key: key, Secret: sf.Tag.Get("secret") == "true", Value: v.Field(i)}
+		if env := sf.Tag.Get("env"); env != "" {
+			f.Env = strings.Split(env, ",")
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// flagValue guarda lo que llegó por flag para aplicarlo al final, después
+// del archivo y del entorno.
+type flagValue struct {
+	key    string
+	def    string
+	isBool bool
+	set    map[string]string
+}
+
+func (f *flagValue) String() string { return f.def }
+
+// IsBoolFlag permite "-auth.enabled" sin valor.
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+func (f *flagValue) Set(s string) error {
+	f.set[f.key] = s
+	return nil
+}
+
+// Load arma la configuración a partir de args (sin el nombre del programa).
+// El archivo se indica con -config o CONFIG_FILE y su formato sale de la
+// extensión (.yaml, .yml o .toml). Devuelve flag.ErrHelp si se pidió -h.
+func Load(args []string) (Config, error) {
+	fs := flag.NewFlagSet("inventory-service", flag.ContinueOnError)
+	build := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return Default(), err
+	}
+	if fs.NArg() > 0 {
+		return Default(), fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return build()
+}
+
+// RegisterFlags agrega -config y una flag por clave a fs, para que un
+// subcomando acepte la configuración junto a sus propias flags. La función
+// devuelta arma la configuración como Load una vez parseado fs.
+func RegisterFlags(fs *flag.FlagSet) func() (Config, error) {
+	defaults := Default()
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "config file (YAML or TOML); env CONFIG_FILE")
+
+	set := make(map[string]string)
+	for _, f := range fields(reflect.ValueOf(&defaults).Elem(), "") {
+		usage := f.Key
+		if len(f.Env) > 0 {
+			usage += "; env " + strings.Join(f.Env, ", ")
+		}
+		def := formatValue(f.Value)
+		if f.Secret {
+			def = ""
+		}
+		isBool := f.Value.Kind() == reflect.Bool
+		fs.Var(&flagValue{key: f.Key, def: def, isBool: isBool, set: set}, f.Key, usage)
+	}
+
+	return func() (Config, error) {
+		cfg := Default()
+		if *configFile != "" {
+			if err := loadFile(*configFile, &cfg); err != nil {
+				return cfg, err
+			}
+		}
+
+		var errs []error
+		for _, f := range fields(reflect.ValueOf(&cfg).Elem(), "") {
+			for _, env := range f.Env {
+				v := os.Getenv(env)
+				if v == "" {
+					continue
+				}
+				if err := setValue(f.Value, v); err != nil {
+					errs = append(errs, fmt.Errorf("env %s: %w", env, err))
+				}
+				break
+			}
+			if v, ok := set[f.Key]; ok {
+				if err := setValue(f.Value, v); err != nil {
+					errs = append(errs, fmt.Errorf("flag -%s: %w", f.Key, err))
+				}
+			}
+		}
+		if len(errs) > 0 {
+			return cfg, errors.Join(errs...)
+		}
+
+		return cfg, cfg.Validate()
+	}
+}
+
+// loadFile pisa cfg con el contenido del archivo. Las claves desconocidas son
+// error para no ignorar typos. Las rutas del outbox del archivo reemplazan a
+// las default en lugar de mezclarse.
+func loadFile(path string, cfg *Config) error {
+	routes := cfg.Outbox.Routes
+	cfg.Outbox.Routes = nil
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("config file: %w", err)
+		}
+		defer f.Close()
+
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.DecodeFile(path, cfg)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config file %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension (use .yaml, .yml or .toml)", path)
+	}
+
+	if cfg.Outbox.Routes == nil {
+		cfg.Outbox.Routes = routes
+	}
+	return nil
+}
+
+// setValue interpreta s según el tipo del campo. Listas y mapas van separados
+// por comas: "a,b" y "Tipo=exchange,Tipo2=exchange2".
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := make(map[string]string)
+		for _, pair := range strings.Split(s, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			k, val = strings.TrimSpace(k), strings.TrimSpace(val)
+			if !ok || k == "" || val == "" {
+				return fmt.Errorf("invalid entry %q, expected key=value", pair)
+			}
+			m[k] = val
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue es la inversa de setValue, para los defaults de -h.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	case reflect.Map:
+		m := v.Interface().(map[string]string)
+		pairs := make([]string, 0, len(m))
+		for k, val := range m {
+			pairs = append(pairs, k+"="+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// dsnPassword cubre los DSN con formato "key=value" de libpq.
+var dsnPassword = regexp.MustCompile(`(password=)\S+`)
+
+// Redacted devuelve una copia con los campos secret ocultos. En las URLs solo
+// se ocultan las contraseñas (de userinfo y de parámetros como ?password=),
+// para que host y usuario sigan visibles.
+func (c Config) Redacted() Config {
+	out := c
+	for _, f := range fields(reflect.ValueOf(&out).Elem(), "") {
+		if f.Secret && f.Value.Kind() == reflect.String && f.Value.String() != "" {
+			f.Value.SetString(redact(f.Value.String()))
+		}
+	}
+	return out
+}
+
+func redact(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		if u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+			}
+		}
+		q := u.Query()
+		changed := false
+		for key := range q {
+			if strings.Contains(strings.ToLower(key), "password") {
+				q.Set(key, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	if dsnPassword.MatchString(s) {
+		return dsnPassword.ReplaceAllString(s, "${1}"+redacted)
+	}
+	return redacted
+}
+
+// Print escribe la configuración efectiva en YAML, con secretos redactados.
+func Print(w io.Writer, c Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Validate devuelve todos los problemas de la configuración juntos, para
+// corregirlos de una sola vez al arrancar.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		check(false, key, "%q is not one of %v", value, allowed)
+	}
+
+	port, err := strconv.Atoi(c.HTTP.Port)
+	check(err == nil && port > 0 && port <= 65535, "http.port", "%q is not a valid TCP port", c.HTTP.Port)
+	check(c.HTTP.ReadHeaderTimeoutSec > 0, "http.readHeaderTimeoutSec", "must be > 0")
+	check(c.HTTP.ReadTimeoutSec > 0, "http.readTimeoutSec", "must be > 0")
+	check(c.HTTP.WriteTimeoutSec > 0, "http.writeTimeoutSec", "must be > 0")
+	check(c.HTTP.IdleTimeoutSec > 0, "http.idleTimeoutSec", "must be > 0")
+
+	if c.GRPC.Enabled {
+		grpcPort, err := strconv.Atoi(c.GRPC.Port)
+		check(err == nil && grpcPort > 0 && grpcPort <= 65535, "grpc.port", "%q is not a valid TCP port", c.GRPC.Port)
+		check(c.GRPC.Port != c.HTTP.Port, "grpc.port", "must differ from http.port")
+		check(c.GRPC.MaxSkusPerRequest > 0, "grpc.maxSkusPerRequest", "must be > 0")
+	}
+
+	check(c.Postgres.DSN != "", "postgres.dsn", "is required")
+
+	m := c.Messaging
+	oneOf("messaging.broker", m.Broker, "rabbitmq", "kafka", "memory")
+	switch m.Broker {
+	case "rabbitmq":
+		check(m.RabbitURI != "", "messaging.rabbitUri", "is required for the rabbitmq broker")
+	case "kafka":
+		check(len(m.KafkaBrokers) > 0, "messaging.kafkaBrokers", "is required for the kafka broker")
+	}
+	oneOf("messaging.eventFormat", m.EventFormat, "envelope", "cloudevents")
+	check(m.EventFormat != "cloudevents" || m.CloudEventsSource != "",
+		"messaging.cloudEventsSource", "is required for the cloudevents format")
+	check(m.Prefetch >= 0, "messaging.prefetch", "must be >= 0")
+	check(m.Exchanges.Orders != "", "messaging.exchanges.orders", "is required")
+	check(m.Exchanges.Catalog != "", "messaging.exchanges.catalog", "is required")
+	check(m.Exchanges.Inventory != "", "messaging.exchanges.inventory", "is required")
+	check(m.Exchanges.DeadLetter != "", "messaging.exchanges.deadLetter", "is required")
+	check(m.Queues.Orders != "", "messaging.queues.orders", "is required")
+	check(m.Queues.Catalog != "", "messaging.queues.catalog", "is required")
+	check(m.Queues.Producer != "", "messaging.queues.producer", "is required")
+
+	check(c.Outbox.BatchSize > 0, "outbox.batchSize", "must be > 0")
+	check(c.Outbox.MaxRetry > 0, "outbox.maxRetry", "must be > 0")
+	check(c.Outbox.IntervalSec > 0, "outbox.intervalSec", "must be > 0")
+	for eventType, exchange := range c.Outbox.Routes {
+		check(eventType != "" && exchange != "", "outbox.routes", "empty event type or exchange in %q=%q", eventType, exchange)
+	}
+
+	check(c.Consumer.MaxAttempts > 0, "consumer.maxAttempts", "must be > 0")
+	check(c.Consumer.RetryBackoffMs >= 0, "consumer.retryBackoffMs", "must be >= 0")
+	check(c.Consumer.MaxRetryBackoffMs >= c.Consumer.RetryBackoffMs,
+		"consumer.maxRetryBackoffMs", "must be >= consumer.retryBackoffMs")
+
+	oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+	oneOf("logging.format", c.Logging.Format, "json", "text")
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "file", "otlp")
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file", "is required for the file exporter")
+
+	check(c.Readiness.MaxOutboxBacklog >= 0, "readiness.maxOutboxBacklog", "must be >= 0")
+	check(c.Readiness.MaxOutboxAgeSec >= 0, "readiness.maxOutboxAgeSec", "must be >= 0")
+	check(c.Readiness.CheckTimeoutSec > 0, "readiness.checkTimeoutSec", "must be > 0")
+
+	if c.Auth.Enabled {
+		check(c.Auth.JWKSFile != "" || c.Auth.HMACSecret != "" || c.Auth.APIKeysFile != "",
+			"auth", "enabled but none of jwksFile, hmacSecret or apiKeysFile is set")
+		check(c.Auth.RolesClaim != "", "auth.rolesClaim", "is required")
+	}
+	check(c.Auth.HMACSecret == "" || len(c.Auth.HMACSecret) >= 32, "auth.hmacSecret", "must be at least 32 bytes")
+	check(c.Auth.ClockSkewSec >= 0, "auth.clockSkewSec", "must be >= 0")
+
+	check(c.Stream.MaxSubscribers >= 0, "stream.maxSubscribers", "must be >= 0")
+	check(c.Stream.MaxSkus > 0, "stream.maxSkus", "must be > 0")
+	check(c.Stream.HeartbeatSec > 0, "stream.heartbeatSec", "must be > 0")
+	check(c.Stream.WriteTimeoutSec > 0, "stream.writeTimeoutSec", "must be > 0")
+
+	oneOf("storage.mode", c.Storage.Mode, "state", "event-sourced")
+	check(c.Storage.SnapshotEvery > 0, "storage.snapshotEvery", "must be > 0")
+
+	check(c.Import.ChunkSize > 0, "import.chunkSize", "must be > 0")
+	check(c.Import.MaxBodyMB > 0, "import.maxBodyMB", "must be > 0")
+
+	check(c.Export.PageSize > 0, "export.pageSize", "must be > 0")
+
+	check(c.ShutdownTimeoutSec > 0, "shutdownTimeoutSec", "must be > 0")
+
+	return errors.Join(errs...)
+}
+
+// ValidateServe es lo que además exige serve, el único comando que expone
+// las APIs: no arrancan abiertas sin pedirlo con auth.insecure.
+func (c Config) ValidateServe() error {
+	if !c.Auth.Enabled && !c.Auth.Insecure {
+		return errors.New("auth: disabled; set auth.enabled, or auth.insecure to run without authentication")
+	}
+	return nil
+}
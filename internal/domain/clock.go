@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+)
+
+// Clock da la hora actual. El dominio no llama time.Now: recibe la hora de
+// quien lo usa, y los servicios la sacan de un Clock inyectado.
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator genera los IDs de entidades y eventos.
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
+
+// SystemClock es el reloj real, en UTC.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now().UTC() }
+
+// RandomIDGenerator genera UUIDs v4.
+type RandomIDGenerator struct{}
+
+func (RandomIDGenerator) NewID() uuid.UUID { return uuid.New() }
+
+// ManualClock es un reloj determinista para tests, simulaciones y replays:
+// cada Now devuelve la hora actual y la avanza step.
+type ManualClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func NewManualClock(start time.Time, step time.Duration) *ManualClock {
+	return &ManualClock{now: start.UTC(), step: step}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Advance mueve el reloj d hacia adelante.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SequentialIDGenerator genera UUIDs v5 a partir de una semilla y un
+// contador: la misma semilla da siempre la misma secuencia.
+type SequentialIDGenerator struct {
+	mu   sync.Mutex
+	seed string
+	n    uint64
+}
+
+func NewSequentialIDGenerator(seed string) *SequentialIDGenerator {
+	return &SequentialIDGenerator{seed: seed}
+}
+
+func (g *SequentialIDGenerator) NewID() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n++
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%s/%d", g.seed, g.n)))
+}
+
+// NewEventMessage arma el Message de un evento con ID y hora explícitos.
+func NewEventMessage(id uuid.UUID, at time.Time) primitives.Message {
+	return primitives.NewMessageWith(id, "", "", at)
+}
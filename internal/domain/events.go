@@ -44,6 +44,9 @@ type OrderCancelledPayload struct {
 
 // =========== Eventos salientes Inventory -> otros ===========
 
+// Los JSON Schema de estos eventos viven en /schemas y se regeneran con:
+//go:generate go run ../../cmd/schemagen -out ../../schemas
+
 // StockReserved (ya lo teníamos)
 type StockReservedLine struct {
 	Sku      string `json:"sku"`
@@ -52,18 +55,23 @@ type StockReservedLine struct {
 
 type StockReservedEvent struct {
 	primitives.BaseEvent
+	SchemaVersion int                 `json:"schemaVersion"`
 	OrderID       uuid.UUID           `json:"orderId"`
 	UserID        uuid.UUID           `json:"userId"`
 	ReservedAtUtc time.Time           `json:"reservedAtUtc"`
 	Lines         []StockReservedLine `json:"lines"`
 }
 
-func NewStockReservedEvent(orderID, userID uuid.UUID, lines []StockReservedLine) *StockReservedEvent {
+// Los constructores de eventos reciben el Message (ver NewEventMessage);
+// la hora del evento es la del Message.
+
+func NewStockReservedEvent(msg primitives.Message, orderID, userID uuid.UUID, lines []StockReservedLine) *StockReservedEvent {
 	ev := &StockReservedEvent{
-		BaseEvent:     primitives.NewBaseEvent(),
+		BaseEvent:     primitives.NewBaseEventWith(msg, ""),
+		SchemaVersion: StockReservedSchemaVersion,
 		OrderID:       orderID,
 		UserID:        userID,
-		ReservedAtUtc: time.Now().UTC(),
+		ReservedAtUtc: msg.OccurredOnUtc,
 		Lines:         lines,
 	}
 	ev.SetRoutingKey("StockReserved")
@@ -72,19 +80,21 @@ func NewStockReservedEvent(orderID, userID uuid.UUID, lines []StockReservedLine)
 
 type StockReservationFailedEvent struct {
 	primitives.BaseEvent
-	OrderID     uuid.UUID `json:"orderId"`
-	UserID      uuid.UUID `json:"userId"`
-	Reason      string    `json:"reason"`
-	FailedAtUtc time.Time `json:"failedAtUtc"`
+	SchemaVersion int       `json:"schemaVersion"`
+	OrderID       uuid.UUID `json:"orderId"`
+	UserID        uuid.UUID `json:"userId"`
+	Reason        string    `json:"reason"`
+	FailedAtUtc   time.Time `json:"failedAtUtc"`
 }
 
-func NewStockReservationFailedEvent(orderID, userID uuid.UUID, reason string) *StockReservationFailedEvent {
+func NewStockReservationFailedEvent(msg primitives.Message, orderID, userID uuid.UUID, reason string) *StockReservationFailedEvent {
 	ev := &StockReservationFailedEvent{
-		BaseEvent:   primitives.NewBaseEvent(),
-		OrderID:     orderID,
-		UserID:      userID,
-		Reason:      reason,
-		FailedAtUtc: time.Now().UTC(),
+		BaseEvent:     primitives.NewBaseEventWith(msg, ""),
+		SchemaVersion: StockReservationFailedSchemaVersion,
+		OrderID:       orderID,
+		UserID:        userID,
+		Reason:        reason,
+		FailedAtUtc:   msg.OccurredOnUtc,
 	}
 	ev.SetRoutingKey("StockReservationFailed")
 	return ev
@@ -93,6 +103,7 @@ func NewStockReservationFailedEvent(orderID, userID uuid.UUID, reason string) *S
 // CatalogStockAdjusted (evento para Catalog, Search, etc.)
 type CatalogStockAdjustedEvent struct {
 	primitives.BaseEvent
+	SchemaVersion     int       `json:"schemaVersion"`
 	Sku               string    `json:"sku"`
 	AvailableQuantity int       `json:"availableQuantity"`
 	ReservedQuantity  int       `json:"reservedQuantity"`
@@ -101,17 +112,19 @@ type CatalogStockAdjustedEvent struct {
 }
 
 func NewCatalogStockAdjustedEvent(
+	msg primitives.Message,
 	sku string,
 	available, reserved int,
 	reason string,
 ) *CatalogStockAdjustedEvent {
 	ev := &CatalogStockAdjustedEvent{
-		BaseEvent:         primitives.NewBaseEvent(),
+		BaseEvent:         primitives.NewBaseEventWith(msg, ""),
+		SchemaVersion:     CatalogStockAdjustedSchemaVersion,
 		Sku:               sku,
 		AvailableQuantity: available,
 		ReservedQuantity:  reserved,
 		Reason:            reason,
-		OccurredAtUtc:     time.Now().UTC(),
+		OccurredAtUtc:     msg.OccurredOnUtc,
 	}
 	ev.SetRoutingKey("CatalogStockAdjusted")
 	return ev
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboundEvent describe un evento saliente para generar su JSON Schema.
+type OutboundEvent struct {
+	Type    string
+	Version int
+	Sample  any
+}
+
+// OutboundEvents lista todos los eventos que publica Inventory.
+func OutboundEvents() []OutboundEvent {
+	return []OutboundEvent{
+		{Type: "StockReserved", Version: StockReservedSchemaVersion, Sample: StockReservedEvent{}},
+		{Type: "StockReservationFailed", Version: StockReservationFailedSchemaVersion, Sample: StockReservationFailedEvent{}},
+		{Type: "CatalogStockAdjusted", Version: CatalogStockAdjustedSchemaVersion, Sample: CatalogStockAdjustedEvent{}},
+	}
+}
+
+// JSONSchema genera el JSON Schema (draft 2020-12) del payload del evento a
+// partir de sus tags json.
+func (e OutboundEvent) JSONSchema() map[string]any {
+	schema := schemaOf(reflect.TypeOf(e.Sample))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = fmt.Sprintf("urn:eventshop:inventory:%s:v%d", e.Type, e.Version)
+	schema["title"] = e.Type
+
+	if props, ok := schema["properties"].(map[string]any); ok {
+		props["schemaVersion"] = map[string]any{"type": "integer", "const": e.Version}
+		props["routingKey"] = map[string]any{"type": "string", "const": e.Type}
+	}
+	return schema
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+func schemaOf(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := schemaOf(t.Elem())
+		s["type"] = []any{s["type"], "null"}
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		props := map[string]any{}
+		required := []string{}
+		collectFields(t, props, &required)
+		sort.Strings(required)
+		return map[string]any{
+			"type":       "object",
+			"properties": props,
+			"required":   required,
+		}
+	default:
+		return map[string]any{}
+	}
+}
+
+// collectFields aplana los structs embebidos igual que encoding/json.
+func collectFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			collectFields(f.Type, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = schemaOf(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
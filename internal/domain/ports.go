@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,6 +13,37 @@ type StockItemRepository interface {
 	UpsertMany(ctx context.Context, items []*StockItem) error
 }
 
+// StockHistoryRepository lee el historial de niveles que guarda
+// StockItemRepository.UpsertMany: una entrada por SKU y UpdatedAtUtc.
+type StockHistoryRepository interface {
+	// AsOf devuelve el último nivel de cada SKU en o antes de at; los SKUs
+	// sin historial hasta at no aparecen.
+	AsOf(ctx context.Context, skus []string, at time.Time) (map[string]StockLevel, error)
+	// ReportAsOf devuelve el nivel en at de todos los SKUs mayores que
+	// after, ordenados por SKU, hasta limit.
+	ReportAsOf(ctx context.Context, at time.Time, after string, limit int) ([]StockLevel, error)
+}
+
+// StockExportRepository lee el stock y las reservaciones activas por
+// páginas (keyset), para exportarlos sin cargar las tablas enteras.
+type StockExportRepository interface {
+	// StockItemsAfter devuelve hasta limit items con SKU mayor que after,
+	// ordenados por SKU.
+	StockItemsAfter(ctx context.Context, after string, limit int) ([]*StockItem, error)
+	// ActiveReservationsAfter devuelve hasta limit reservaciones activas, con
+	// sus líneas, con OrderID mayor que after (uuid.Nil para empezar),
+	// ordenadas por OrderID.
+	ActiveReservationsAfter(ctx context.Context, after uuid.UUID, limit int) ([]*StockReservation, error)
+}
+
+// StockLevel es el stock de un SKU desde ChangedAtUtc.
+type StockLevel struct {
+	Sku          string
+	Available    int
+	Reserved     int
+	ChangedAtUtc time.Time
+}
+
 type StockReservationRepository interface {
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*StockReservation, error)
 	Insert(ctx context.Context, r *StockReservation) error
@@ -21,6 +54,13 @@ type OutboxRepository interface {
 	Insert(ctx context.Context, msg OutboxMessage) error
 	GetPendingBatch(ctx context.Context, maxRetry, batchSize int) ([]OutboxMessage, error)
 	Save(ctx context.Context, msg OutboxMessage) error
+	GetPendingStats(ctx context.Context, maxRetry int) (OutboxStats, error)
+}
+
+// OutboxStats resume el backlog pendiente de publicar.
+type OutboxStats struct {
+	Pending             int
+	OldestOccurredAtUtc int64 // unix seconds, 0 si no hay pendientes
 }
 
 type OutboxMessage struct {
@@ -30,4 +70,62 @@ type OutboxMessage struct {
 	OccurredAtUtc  int64 // unix nano or seconds
 	RetryCount     int
 	ProcessedAtUtc *int64
+	TraceContext   string // traceparent/tracestate serializado, "" si no hay traza
+}
+
+type QuarantineRepository interface {
+	Insert(ctx context.Context, msg QuarantinedMessage) error
+	// List devuelve los más recientes primero; pendingOnly excluye los ya re-procesados.
+	List(ctx context.Context, pendingOnly bool, limit int) ([]QuarantinedMessage, error)
+	// GetByID devuelve nil si no existe.
+	GetByID(ctx context.Context, id uuid.UUID) (*QuarantinedMessage, error)
+	// ClaimReplay marca el mensaje como re-procesado en at solo si todavía no
+	// lo estaba, en una operación atómica; devuelve false si otro replay ya
+	// lo tomó (o no existe).
+	ClaimReplay(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// UnclaimReplay deshace un ClaimReplay en at cuyo replay falló.
+	UnclaimReplay(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// ErrConcurrencyConflict: otro escritor agregó eventos al stream desde que
+// se leyó.
+var ErrConcurrencyConflict = errors.New("event stream was modified concurrently")
+
+// EventStore guarda streams de eventos append-only con snapshots, para el
+// modo event-sourced.
+type EventStore interface {
+	// Append agrega a cada stream sus eventos, con versiones consecutivas
+	// desde ExpectedVersion+1. Es todo o nada: si algún stream ya no está en
+	// ExpectedVersion devuelve ErrConcurrencyConflict y no guarda nada.
+	Append(ctx context.Context, appends []StreamAppend) error
+	// Load devuelve los eventos del stream con versión > after, en orden.
+	Load(ctx context.Context, streamID string, after int64) ([]StoredEvent, error)
+	// StreamIDs devuelve los streams que empiezan con prefix, ordenados.
+	StreamIDs(ctx context.Context, prefix string) ([]string, error)
+	// LoadSnapshot devuelve nil si el stream no tiene snapshot.
+	LoadSnapshot(ctx context.Context, streamID string) (*Snapshot, error)
+	// SaveSnapshot reemplaza el snapshot del stream.
+	SaveSnapshot(ctx context.Context, snap Snapshot) error
+}
+
+type StreamAppend struct {
+	StreamID        string
+	ExpectedVersion int64 // 0 para un stream nuevo
+	Events          []StoredEvent
+}
+
+type StoredEvent struct {
+	StreamID      string
+	Version       int64
+	Type          string
+	Data          string // JSON
+	OccurredAtUtc time.Time
+}
+
+// Snapshot es el estado de un stream en Version.
+type Snapshot struct {
+	StreamID   string
+	Version    int64
+	Data       string // JSON
+	TakenAtUtc time.Time
 }
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuarantinedMessage es un mensaje entrante que se rechazó (error permanente
+// o reintentos agotados) y se guarda para inspección / replay.
+type QuarantinedMessage struct {
+	ID               uuid.UUID
+	EnvelopeID       uuid.UUID
+	EventType        string
+	EnvelopeJSON     string
+	Reason           string
+	Attempts         int
+	Permanent        bool
+	QuarantinedAtUtc time.Time
+	ReplayedAtUtc    *time.Time
+}
@@ -0,0 +1,1091 @@
+// Package repotest es la suite de conformidad de los repositorios de
+// domain. Cada implementación la corre desde sus tests con un constructor
+// que devuelve repositorios vacíos y aislados:
+//
+//	func TestContract(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repotest.Repos { ... })
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Repos son los repositorios bajo prueba; todos comparten el mismo
+// almacenamiento vacío.
+type Repos struct {
+	Stock        domain.StockItemRepository
+	Reservations domain.StockReservationRepository
+	Outbox       domain.OutboxRepository
+	Quarantine   domain.QuarantineRepository
+	// Events es opcional: si es nil se salta el contrato de EventStore.
+	Events domain.EventStore
+	// History es opcional y lee lo que escribe Stock.
+	History domain.StockHistoryRepository
+	// Export es opcional y lee lo que escriben Stock y Reservations.
+	Export domain.StockExportRepository
+	// UnitOfWork es opcional y agrupa las escrituras de los demás.
+	UnitOfWork domain.UnitOfWork
+}
+
+// Run corre todos los contratos; newRepos se llama una vez por caso.
+func Run(t *testing.T, newRepos func(t *testing.T) Repos) {
+	t.Run("StockItemRepository", func(t *testing.T) { StockItemRepository(t, newRepos) })
+	t.Run("StockReservationRepository", func(t *testing.T) { StockReservationRepository(t, newRepos) })
+	t.Run("OutboxRepository", func(t *testing.T) { OutboxRepository(t, newRepos) })
+	t.Run("QuarantineRepository", func(t *testing.T) { QuarantineRepository(t, newRepos) })
+	t.Run("EventStore", func(t *testing.T) { EventStore(t, newRepos) })
+	t.Run("StockHistoryRepository", func(t *testing.T) { StockHistoryRepository(t, newRepos) })
+	t.Run("StockExportRepository", func(t *testing.T) { StockExportRepository(t, newRepos) })
+	t.Run("UnitOfWork", func(t *testing.T) { UnitOfWork(t, newRepos) })
+}
+
+// ts es un instante con la precisión de timestamptz (microsegundos).
+func ts(sec int) time.Time {
+	return time.Date(2024, 5, 1, 12, 0, sec, 123456000, time.UTC)
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func StockItemRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	t.Run("GetBySkus with no skus returns an empty map", func(t *testing.T) {
+		repo := newRepos(t).Stock
+		got, err := repo.GetBySkus(ctx, nil)
+		must(t, err)
+		if got == nil || len(got) != 0 {
+			t.Errorf("GetBySkus(nil) = %v, want empty map", got)
+		}
+	})
+
+	t.Run("missing skus are absent from GetBySkus", func(t *testing.T) {
+		repo := newRepos(t).Stock
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{{Sku: "A", Available: 1, UpdatedAtUtc: ts(0)}}))
+
+		got, err := repo.GetBySkus(ctx, []string{"A", "MISSING"})
+		must(t, err)
+		if len(got) != 1 || got["A"] == nil {
+			t.Fatalf("GetBySkus = %v, want only A", got)
+		}
+		if _, ok := got["MISSING"]; ok {
+			t.Error("missing sku is present in the result")
+		}
+	})
+
+	t.Run("UpsertMany inserts and round-trips every field", func(t *testing.T) {
+		repo := newRepos(t).Stock
+		in := &domain.StockItem{ID: uuid.New(), Sku: "A", Available: 7, Reserved: 2, UpdatedAtUtc: ts(1)}
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{in}))
+
+		got, err := repo.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		item := got["A"]
+		if item == nil || item.ID != in.ID || item.Available != 7 || item.Reserved != 2 || !item.UpdatedAtUtc.Equal(in.UpdatedAtUtc) {
+			t.Errorf("GetBySkus = %+v, want %+v", item, in)
+		}
+	})
+
+	t.Run("UpsertMany updates by sku and keeps the id", func(t *testing.T) {
+		repo := newRepos(t).Stock
+		first := &domain.StockItem{ID: uuid.New(), Sku: "A", Available: 7, UpdatedAtUtc: ts(1)}
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{first}))
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{{ID: uuid.New(), Sku: "A", Available: 3, Reserved: 4, UpdatedAtUtc: ts(2)}}))
+
+		got, err := repo.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		item := got["A"]
+		if item.ID != first.ID || item.Available != 3 || item.Reserved != 4 || !item.UpdatedAtUtc.Equal(ts(2)) {
+			t.Errorf("after update = %+v", item)
+		}
+	})
+
+	t.Run("UpsertMany fills a missing id and timestamp", func(t *testing.T) {
+		repo := newRepos(t).Stock
+		in := &domain.StockItem{Sku: "A", Available: 1}
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{in}))
+		if in.ID == uuid.Nil || in.UpdatedAtUtc.IsZero() {
+			t.Errorf("item after UpsertMany = %+v, want id and timestamp set", in)
+		}
+	})
+
+	t.Run("UpsertMany saves a large batch", func(t *testing.T) {
+		// Más de copyMinRows de Postgres, para pasar por COPY.
+		repo := newRepos(t).Stock
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{{Sku: "SKU-00000", Available: 99, UpdatedAtUtc: ts(0)}}))
+		items := make([]*domain.StockItem, 0, 600)
+		skus := make([]string, 0, 600)
+		for i := 0; i < 600; i++ {
+			sku := fmt.Sprintf("SKU-%05d", i)
+			items = append(items, &domain.StockItem{Sku: sku, Available: i, Reserved: 1, UpdatedAtUtc: ts(1)})
+			skus = append(skus, sku)
+		}
+		must(t, repo.UpsertMany(ctx, items))
+
+		got, err := repo.GetBySkus(ctx, skus)
+		must(t, err)
+		if len(got) != 600 {
+			t.Fatalf("GetBySkus returned %d items, want 600", len(got))
+		}
+		for i, sku := range skus {
+			if item := got[sku]; item.Available != i || item.Reserved != 1 || item.ID == uuid.Nil {
+				t.Fatalf("%s = %+v, want %d/1", sku, item, i)
+			}
+		}
+	})
+
+	t.Run("UpsertMany with no items is a no-op", func(t *testing.T) {
+		must(t, newRepos(t).Stock.UpsertMany(ctx, nil))
+	})
+
+	t.Run("returned items are copies", func(t *testing.T) {
+		repo := newRepos(t).Stock
+		must(t, repo.UpsertMany(ctx, []*domain.StockItem{{Sku: "A", Available: 5, UpdatedAtUtc: ts(0)}}))
+
+		got, err := repo.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		got["A"].Available = 0
+
+		again, err := repo.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		if again["A"].Available != 5 {
+			t.Errorf("unsaved change leaked into the repository: %+v", again["A"])
+		}
+	})
+}
+
+func StockHistoryRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	// newRepos con A: 10/0 en ts(0), 6/4 en ts(10) y 8/2 en ts(20); B: 3/0
+	// en ts(5); C: 1/0 en ts(15).
+	seeded := func(t *testing.T) domain.StockHistoryRepository {
+		repos := newRepos(t)
+		if repos.History == nil {
+			t.Skip("no StockHistoryRepository under test")
+		}
+		upsert := func(sku string, available, reserved, sec int) {
+			must(t, repos.Stock.UpsertMany(ctx, []*domain.StockItem{
+				{Sku: sku, Available: available, Reserved: reserved, UpdatedAtUtc: ts(sec)},
+			}))
+		}
+		upsert("A", 10, 0, 0)
+		upsert("B", 3, 0, 5)
+		upsert("A", 6, 4, 10)
+		upsert("C", 1, 0, 15)
+		upsert("A", 8, 2, 20)
+		return repos.History
+	}
+
+	t.Run("AsOf returns the last level at or before the instant", func(t *testing.T) {
+		history := seeded(t)
+		cases := []struct {
+			at                  time.Time
+			available, reserved int
+			changed             time.Time
+		}{
+			{ts(0), 10, 0, ts(0)},
+			{ts(9), 10, 0, ts(0)},
+			{ts(10), 6, 4, ts(10)},
+			{ts(30), 8, 2, ts(20)},
+		}
+		for _, tc := range cases {
+			got, err := history.AsOf(ctx, []string{"A"}, tc.at)
+			must(t, err)
+			level, ok := got["A"]
+			if !ok || level.Available != tc.available || level.Reserved != tc.reserved || !level.ChangedAtUtc.Equal(tc.changed) {
+				t.Errorf("AsOf(%s) = %+v, want %d/%d since %s", tc.at, got, tc.available, tc.reserved, tc.changed)
+			}
+		}
+	})
+
+	t.Run("AsOf omits skus with no history yet", func(t *testing.T) {
+		history := seeded(t)
+		got, err := history.AsOf(ctx, []string{"A", "B", "C", "MISSING"}, ts(7))
+		must(t, err)
+		if len(got) != 2 || got["A"].Available != 10 || got["B"].Available != 3 {
+			t.Errorf("AsOf(ts(7)) = %+v, want only A and B", got)
+		}
+	})
+
+	t.Run("a second write at the same instant replaces the level", func(t *testing.T) {
+		repos := newRepos(t)
+		if repos.History == nil {
+			t.Skip("no StockHistoryRepository under test")
+		}
+		must(t, repos.Stock.UpsertMany(ctx, []*domain.StockItem{{Sku: "A", Available: 5, UpdatedAtUtc: ts(0)}}))
+		must(t, repos.Stock.UpsertMany(ctx, []*domain.StockItem{{Sku: "A", Available: 4, Reserved: 1, UpdatedAtUtc: ts(0)}}))
+
+		got, err := repos.History.AsOf(ctx, []string{"A"}, ts(0))
+		must(t, err)
+		if got["A"].Available != 4 || got["A"].Reserved != 1 {
+			t.Errorf("AsOf = %+v, want 4/1", got["A"])
+		}
+	})
+
+	t.Run("ReportAsOf pages by sku", func(t *testing.T) {
+		history := seeded(t)
+		page, err := history.ReportAsOf(ctx, ts(15), "", 2)
+		must(t, err)
+		if len(page) != 2 || page[0].Sku != "A" || page[0].Available != 6 || page[1].Sku != "B" {
+			t.Fatalf("first page = %+v, want A 6/4 and B", page)
+		}
+		page, err = history.ReportAsOf(ctx, ts(15), "B", 2)
+		must(t, err)
+		if len(page) != 1 || page[0].Sku != "C" {
+			t.Errorf("second page = %+v, want only C", page)
+		}
+		page, err = history.ReportAsOf(ctx, ts(1), "", 10)
+		must(t, err)
+		if len(page) != 1 || page[0].Sku != "A" {
+			t.Errorf("report before B and C exist = %+v, want only A", page)
+		}
+	})
+}
+
+func StockExportRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	newExport := func(t *testing.T) Repos {
+		repos := newRepos(t)
+		if repos.Export == nil {
+			t.Skip("no StockExportRepository under test")
+		}
+		return repos
+	}
+
+	t.Run("StockItemsAfter pages by sku", func(t *testing.T) {
+		repos := newExport(t)
+		must(t, repos.Stock.UpsertMany(ctx, []*domain.StockItem{
+			{Sku: "C", Available: 3, UpdatedAtUtc: ts(0)},
+			{Sku: "A", Available: 1, Reserved: 4, UpdatedAtUtc: ts(0)},
+			{Sku: "B", Available: 2, UpdatedAtUtc: ts(0)},
+		}))
+
+		page, err := repos.Export.StockItemsAfter(ctx, "", 2)
+		must(t, err)
+		if len(page) != 2 || page[0].Sku != "A" || page[0].Reserved != 4 || page[1].Sku != "B" {
+			t.Fatalf("first page = %+v, want A and B", page)
+		}
+		page, err = repos.Export.StockItemsAfter(ctx, "B", 2)
+		must(t, err)
+		if len(page) != 1 || page[0].Sku != "C" || page[0].Available != 3 {
+			t.Errorf("second page = %+v, want only C", page)
+		}
+		page, err = repos.Export.StockItemsAfter(ctx, "C", 2)
+		must(t, err)
+		if len(page) != 0 {
+			t.Errorf("after the last sku = %+v, want empty", page)
+		}
+	})
+
+	t.Run("ActiveReservationsAfter skips released ones and pages by order", func(t *testing.T) {
+		repos := newExport(t)
+		var active []uuid.UUID
+		for i := 0; i < 4; i++ {
+			res := &domain.StockReservation{
+				ID:            uuid.New(),
+				OrderID:       uuid.New(),
+				UserID:        uuid.New(),
+				Status:        domain.ReservationActive,
+				ReservedAtUtc: ts(i),
+				Lines: []domain.ReservationLine{
+					{ID: uuid.New(), Sku: "A", Quantity: 1},
+					{ID: uuid.New(), Sku: "B", Quantity: i + 1},
+				},
+			}
+			must(t, repos.Reservations.Insert(ctx, res))
+			if i == 0 {
+				res.MarkReleased(ts(10))
+				must(t, repos.Reservations.Update(ctx, res))
+				continue
+			}
+			active = append(active, res.OrderID)
+		}
+		sort.Slice(active, func(i, j int) bool { return active[i].String() < active[j].String() })
+
+		var got []uuid.UUID
+		after := uuid.Nil
+		for {
+			page, err := repos.Export.ActiveReservationsAfter(ctx, after, 2)
+			must(t, err)
+			if len(page) == 0 {
+				break
+			}
+			for _, res := range page {
+				if res.Status != domain.ReservationActive || len(res.Lines) != 2 {
+					t.Errorf("reservation %s = %+v, want active with 2 lines", res.OrderID, res)
+				}
+				got = append(got, res.OrderID)
+			}
+			after = page[len(page)-1].OrderID
+		}
+		if len(got) != len(active) {
+			t.Fatalf("exported orders = %v, want %v", got, active)
+		}
+		for i := range active {
+			if got[i] != active[i] {
+				t.Errorf("exported orders = %v, want %v in order", got, active)
+				break
+			}
+		}
+	})
+}
+
+func StockReservationRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	newReservation := func() *domain.StockReservation {
+		return &domain.StockReservation{
+			ID:            uuid.New(),
+			OrderID:       uuid.New(),
+			UserID:        uuid.New(),
+			Status:        domain.ReservationActive,
+			ReservedAtUtc: ts(0),
+			Lines: []domain.ReservationLine{
+				{ID: uuid.New(), Sku: "A", Quantity: 2},
+				{ID: uuid.New(), Sku: "B", Quantity: 1},
+			},
+		}
+	}
+
+	t.Run("GetByOrderID returns nil on miss", func(t *testing.T) {
+		got, err := newRepos(t).Reservations.GetByOrderID(ctx, uuid.New())
+		if got != nil || err != nil {
+			t.Errorf("GetByOrderID = %+v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("Insert round-trips the reservation and its lines", func(t *testing.T) {
+		repo := newRepos(t).Reservations
+		in := newReservation()
+		must(t, repo.Insert(ctx, in))
+
+		got, err := repo.GetByOrderID(ctx, in.OrderID)
+		must(t, err)
+		if got == nil {
+			t.Fatal("inserted reservation not found")
+		}
+		if got.ID != in.ID || got.UserID != in.UserID || got.Status != domain.ReservationActive ||
+			!got.ReservedAtUtc.Equal(in.ReservedAtUtc) || got.ReleasedAtUtc != nil {
+			t.Errorf("GetByOrderID = %+v, want %+v", got, in)
+		}
+
+		lines := map[string]domain.ReservationLine{}
+		for _, l := range got.Lines {
+			lines[l.Sku] = l
+		}
+		if len(lines) != 2 || lines["A"].Quantity != 2 || lines["B"].Quantity != 1 {
+			t.Errorf("lines = %+v", got.Lines)
+		}
+		for _, l := range got.Lines {
+			if l.ReservationID != in.ID {
+				t.Errorf("line %s reservationId = %s, want %s", l.Sku, l.ReservationID, in.ID)
+			}
+		}
+	})
+
+	t.Run("Insert rejects a second reservation for the same order", func(t *testing.T) {
+		repo := newRepos(t).Reservations
+		first := newReservation()
+		must(t, repo.Insert(ctx, first))
+
+		second := newReservation()
+		second.OrderID = first.OrderID
+		if err := repo.Insert(ctx, second); err == nil {
+			t.Error("duplicate order inserted without error")
+		}
+	})
+
+	t.Run("Update persists status and release time", func(t *testing.T) {
+		repo := newRepos(t).Reservations
+		in := newReservation()
+		must(t, repo.Insert(ctx, in))
+
+		released := ts(30)
+		in.Status = domain.ReservationReleased
+		in.ReleasedAtUtc = &released
+		must(t, repo.Update(ctx, in))
+
+		got, err := repo.GetByOrderID(ctx, in.OrderID)
+		must(t, err)
+		if got.Status != domain.ReservationReleased || got.ReleasedAtUtc == nil || !got.ReleasedAtUtc.Equal(released) {
+			t.Errorf("after Update = %+v", got)
+		}
+		if len(got.Lines) != 2 {
+			t.Errorf("Update changed the lines: %+v", got.Lines)
+		}
+	})
+}
+
+func OutboxRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+	const base = int64(1_700_000_000)
+
+	insert := func(t *testing.T, repo domain.OutboxRepository, msgType string, occurred int64, retries int) domain.OutboxMessage {
+		t.Helper()
+		msg := domain.OutboxMessage{
+			ID:            uuid.New(),
+			Type:          msgType,
+			PayloadJSON:   `{"k":"v"}`,
+			OccurredAtUtc: occurred,
+			RetryCount:    retries,
+		}
+		must(t, repo.Insert(ctx, msg))
+		return msg
+	}
+	ids := func(msgs []domain.OutboxMessage) []uuid.UUID {
+		out := make([]uuid.UUID, 0, len(msgs))
+		for _, m := range msgs {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	t.Run("GetPendingBatch returns the oldest first up to the batch size", func(t *testing.T) {
+		repo := newRepos(t).Outbox
+		newest := insert(t, repo, "C", base+2, 0)
+		oldest := insert(t, repo, "A", base, 0)
+		middle := insert(t, repo, "B", base+1, 0)
+
+		got, err := repo.GetPendingBatch(ctx, 5, 10)
+		must(t, err)
+		if want := []uuid.UUID{oldest.ID, middle.ID, newest.ID}; !equalIDs(ids(got), want) {
+			t.Errorf("GetPendingBatch = %v, want %v", ids(got), want)
+		}
+
+		got, err = repo.GetPendingBatch(ctx, 5, 2)
+		must(t, err)
+		if want := []uuid.UUID{oldest.ID, middle.ID}; !equalIDs(ids(got), want) {
+			t.Errorf("GetPendingBatch(batch 2) = %v, want %v", ids(got), want)
+		}
+	})
+
+	t.Run("GetPendingBatch round-trips every field", func(t *testing.T) {
+		repo := newRepos(t).Outbox
+		in := domain.OutboxMessage{
+			ID:            uuid.New(),
+			Type:          "StockReserved",
+			PayloadJSON:   `{"orderId":"1"}`,
+			OccurredAtUtc: base,
+			RetryCount:    1,
+			TraceContext:  `{"traceparent":"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}`,
+		}
+		must(t, repo.Insert(ctx, in))
+
+		got, err := repo.GetPendingBatch(ctx, 5, 10)
+		must(t, err)
+		if len(got) != 1 {
+			t.Fatalf("GetPendingBatch = %d messages, want 1", len(got))
+		}
+		m := got[0]
+		if m.ID != in.ID || m.Type != in.Type || m.PayloadJSON != in.PayloadJSON ||
+			m.OccurredAtUtc != in.OccurredAtUtc || m.RetryCount != 1 || m.TraceContext != in.TraceContext || m.ProcessedAtUtc != nil {
+			t.Errorf("GetPendingBatch = %+v, want %+v", m, in)
+		}
+	})
+
+	t.Run("processed and exhausted messages are not pending", func(t *testing.T) {
+		repo := newRepos(t).Outbox
+		pending := insert(t, repo, "A", base, 0)
+		insert(t, repo, "B", base+1, 3)
+		processed := insert(t, repo, "C", base+2, 0)
+		done := base + 10
+		processed.ProcessedAtUtc = &done
+		must(t, repo.Save(ctx, processed))
+
+		got, err := repo.GetPendingBatch(ctx, 3, 10)
+		must(t, err)
+		if want := []uuid.UUID{pending.ID}; !equalIDs(ids(got), want) {
+			t.Errorf("GetPendingBatch = %v, want %v", ids(got), want)
+		}
+
+		stats, err := repo.GetPendingStats(ctx, 3)
+		must(t, err)
+		if stats.Pending != 1 || stats.OldestOccurredAtUtc != base {
+			t.Errorf("GetPendingStats = %+v, want 1 pending since %d", stats, base)
+		}
+	})
+
+	t.Run("GetPendingStats is zero when nothing is pending", func(t *testing.T) {
+		stats, err := newRepos(t).Outbox.GetPendingStats(ctx, 3)
+		must(t, err)
+		if stats != (domain.OutboxStats{}) {
+			t.Errorf("GetPendingStats = %+v, want zero", stats)
+		}
+	})
+
+	t.Run("Save updates the retry count", func(t *testing.T) {
+		repo := newRepos(t).Outbox
+		msg := insert(t, repo, "A", base, 0)
+		msg.RetryCount = 2
+		must(t, repo.Save(ctx, msg))
+
+		got, err := repo.GetPendingBatch(ctx, 5, 10)
+		must(t, err)
+		if len(got) != 1 || got[0].RetryCount != 2 {
+			t.Errorf("after Save = %+v, want retryCount 2", got)
+		}
+	})
+
+	t.Run("Save without processed_at_utc preserves it", func(t *testing.T) {
+		repo := newRepos(t).Outbox
+		msg := insert(t, repo, "A", base, 0)
+		done := base + 10
+		msg.ProcessedAtUtc = &done
+		must(t, repo.Save(ctx, msg))
+
+		msg.ProcessedAtUtc = nil
+		msg.RetryCount = 1
+		must(t, repo.Save(ctx, msg))
+
+		got, err := repo.GetPendingBatch(ctx, 5, 10)
+		must(t, err)
+		if len(got) != 0 {
+			t.Errorf("processed message is pending again after Save: %+v", got)
+		}
+	})
+
+	t.Run("Save rejects an empty id", func(t *testing.T) {
+		if err := newRepos(t).Outbox.Save(ctx, domain.OutboxMessage{}); err == nil {
+			t.Error("Save with empty id returned no error")
+		}
+	})
+}
+
+func QuarantineRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	insert := func(t *testing.T, repo domain.QuarantineRepository, at time.Time) domain.QuarantinedMessage {
+		t.Helper()
+		msg := domain.QuarantinedMessage{
+			ID:               uuid.New(),
+			EnvelopeID:       uuid.New(),
+			EventType:        "OrderPlacedEvent",
+			EnvelopeJSON:     `{"id":"1"}`,
+			Reason:           "invalid payload",
+			Attempts:         3,
+			Permanent:        true,
+			QuarantinedAtUtc: at,
+		}
+		must(t, repo.Insert(ctx, msg))
+		return msg
+	}
+
+	t.Run("GetByID returns nil on miss", func(t *testing.T) {
+		got, err := newRepos(t).Quarantine.GetByID(ctx, uuid.New())
+		if got != nil || err != nil {
+			t.Errorf("GetByID = %+v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("Insert round-trips every field", func(t *testing.T) {
+		repo := newRepos(t).Quarantine
+		in := insert(t, repo, ts(0))
+
+		got, err := repo.GetByID(ctx, in.ID)
+		must(t, err)
+		if got == nil || got.EnvelopeID != in.EnvelopeID || got.EventType != in.EventType ||
+			got.EnvelopeJSON != in.EnvelopeJSON || got.Reason != in.Reason || got.Attempts != in.Attempts ||
+			got.Permanent != in.Permanent || !got.QuarantinedAtUtc.Equal(in.QuarantinedAtUtc) || got.ReplayedAtUtc != nil {
+			t.Errorf("GetByID = %+v, want %+v", got, in)
+		}
+	})
+
+	t.Run("List returns the newest first and honors pendingOnly and limit", func(t *testing.T) {
+		repo := newRepos(t).Quarantine
+		old := insert(t, repo, ts(0))
+		replayed := insert(t, repo, ts(1))
+		newest := insert(t, repo, ts(2))
+		claimed, err := repo.ClaimReplay(ctx, replayed.ID, ts(10))
+		must(t, err)
+		if !claimed {
+			t.Fatal("ClaimReplay = false on a pending message")
+		}
+
+		all, err := repo.List(ctx, false, 10)
+		must(t, err)
+		if want := []uuid.UUID{newest.ID, replayed.ID, old.ID}; !equalIDs(quarantineIDs(all), want) {
+			t.Errorf("List(all) = %v, want %v", quarantineIDs(all), want)
+		}
+
+		pending, err := repo.List(ctx, true, 10)
+		must(t, err)
+		if want := []uuid.UUID{newest.ID, old.ID}; !equalIDs(quarantineIDs(pending), want) {
+			t.Errorf("List(pending) = %v, want %v", quarantineIDs(pending), want)
+		}
+
+		limited, err := repo.List(ctx, false, 1)
+		must(t, err)
+		if want := []uuid.UUID{newest.ID}; !equalIDs(quarantineIDs(limited), want) {
+			t.Errorf("List(limit 1) = %v, want %v", quarantineIDs(limited), want)
+		}
+	})
+
+	t.Run("ClaimReplay sets the replay time once", func(t *testing.T) {
+		repo := newRepos(t).Quarantine
+		msg := insert(t, repo, ts(0))
+		claimed, err := repo.ClaimReplay(ctx, msg.ID, ts(5))
+		must(t, err)
+		if !claimed {
+			t.Fatal("first ClaimReplay = false")
+		}
+		again, err := repo.ClaimReplay(ctx, msg.ID, ts(6))
+		must(t, err)
+		if again {
+			t.Error("second ClaimReplay = true")
+		}
+		if missing, err := repo.ClaimReplay(ctx, uuid.New(), ts(6)); missing || err != nil {
+			t.Errorf("ClaimReplay(unknown) = %v, %v; want false, nil", missing, err)
+		}
+
+		got, err := repo.GetByID(ctx, msg.ID)
+		must(t, err)
+		if got.ReplayedAtUtc == nil || !got.ReplayedAtUtc.Equal(ts(5)) {
+			t.Errorf("replayedAt = %v, want %v", got.ReplayedAtUtc, ts(5))
+		}
+	})
+
+	t.Run("ClaimReplay lets exactly one concurrent caller win", func(t *testing.T) {
+		repo := newRepos(t).Quarantine
+		msg := insert(t, repo, ts(0))
+
+		const callers = 8
+		results := make(chan bool, callers)
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				claimed, err := repo.ClaimReplay(ctx, msg.ID, ts(10+i))
+				if err != nil {
+					t.Error(err)
+				}
+				results <- claimed
+			}(i)
+		}
+		wg.Wait()
+		close(results)
+		wins := 0
+		for claimed := range results {
+			if claimed {
+				wins++
+			}
+		}
+		if wins != 1 {
+			t.Errorf("%d callers claimed the replay, want 1", wins)
+		}
+	})
+
+	t.Run("UnclaimReplay makes the message pending again", func(t *testing.T) {
+		repo := newRepos(t).Quarantine
+		msg := insert(t, repo, ts(0))
+		_, err := repo.ClaimReplay(ctx, msg.ID, ts(5))
+		must(t, err)
+		// Un unclaim con otra hora no toca el claim de otro replay.
+		must(t, repo.UnclaimReplay(ctx, msg.ID, ts(6)))
+		if got, _ := repo.GetByID(ctx, msg.ID); got.ReplayedAtUtc == nil {
+			t.Fatal("UnclaimReplay with another time released the claim")
+		}
+		must(t, repo.UnclaimReplay(ctx, msg.ID, ts(5)))
+		if got, _ := repo.GetByID(ctx, msg.ID); got.ReplayedAtUtc != nil {
+			t.Errorf("replayedAt = %v after UnclaimReplay, want nil", got.ReplayedAtUtc)
+		}
+	})
+}
+
+func quarantineIDs(msgs []domain.QuarantinedMessage) []uuid.UUID {
+	out := make([]uuid.UUID, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+func equalIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func EventStore(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	newStore := func(t *testing.T) domain.EventStore {
+		store := newRepos(t).Events
+		if store == nil {
+			t.Skip("no EventStore under test")
+		}
+		return store
+	}
+	event := func(eventType string, sec int) domain.StoredEvent {
+		return domain.StoredEvent{Type: eventType, Data: `{"n": 1}`, OccurredAtUtc: ts(sec)}
+	}
+
+	t.Run("Append numbers events and Load returns them in order", func(t *testing.T) {
+		store := newStore(t)
+		must(t, store.Append(ctx, []domain.StreamAppend{
+			{StreamID: "s-1", Events: []domain.StoredEvent{event("A", 0), event("B", 1)}},
+		}))
+		must(t, store.Append(ctx, []domain.StreamAppend{
+			{StreamID: "s-1", ExpectedVersion: 2, Events: []domain.StoredEvent{event("C", 2)}},
+		}))
+
+		got, err := store.Load(ctx, "s-1", 0)
+		must(t, err)
+		if len(got) != 3 {
+			t.Fatalf("Load = %+v, want 3 events", got)
+		}
+		for i, want := range []string{"A", "B", "C"} {
+			ev := got[i]
+			if ev.StreamID != "s-1" || ev.Version != int64(i+1) || ev.Type != want || !ev.OccurredAtUtc.Equal(ts(i)) {
+				t.Errorf("event %d = %+v, want %s v%d", i, ev, want, i+1)
+			}
+		}
+
+		after, err := store.Load(ctx, "s-1", 2)
+		must(t, err)
+		if len(after) != 1 || after[0].Type != "C" {
+			t.Errorf("Load(after 2) = %+v, want only C", after)
+		}
+	})
+
+	t.Run("Load of an unknown stream is empty", func(t *testing.T) {
+		got, err := newStore(t).Load(ctx, "missing", 0)
+		if err != nil || len(got) != 0 {
+			t.Errorf("Load = %v, %v; want empty", got, err)
+		}
+	})
+
+	t.Run("Append with a stale version conflicts and saves nothing", func(t *testing.T) {
+		store := newStore(t)
+		must(t, store.Append(ctx, []domain.StreamAppend{
+			{StreamID: "s-1", Events: []domain.StoredEvent{event("A", 0)}},
+		}))
+
+		err := store.Append(ctx, []domain.StreamAppend{
+			{StreamID: "s-2", Events: []domain.StoredEvent{event("X", 1)}},
+			{StreamID: "s-1", ExpectedVersion: 0, Events: []domain.StoredEvent{event("B", 1)}},
+		})
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("err = %v, want ErrConcurrencyConflict", err)
+		}
+		s1, _ := store.Load(ctx, "s-1", 0)
+		s2, _ := store.Load(ctx, "s-2", 0)
+		if len(s1) != 1 || len(s2) != 0 {
+			t.Errorf("after conflict s-1 = %+v, s-2 = %+v; want only the first event", s1, s2)
+		}
+	})
+
+	t.Run("StreamIDs filters by prefix and sorts", func(t *testing.T) {
+		store := newStore(t)
+		for _, id := range []string{"stock-B", "reservation-1", "stock-A", "stock_C"} {
+			must(t, store.Append(ctx, []domain.StreamAppend{
+				{StreamID: id, Events: []domain.StoredEvent{event("A", 0)}},
+			}))
+		}
+		got, err := store.StreamIDs(ctx, "stock-")
+		must(t, err)
+		if len(got) != 2 || got[0] != "stock-A" || got[1] != "stock-B" {
+			t.Errorf("StreamIDs = %v, want [stock-A stock-B]", got)
+		}
+	})
+
+	t.Run("SaveSnapshot replaces the previous one", func(t *testing.T) {
+		store := newStore(t)
+		none, err := store.LoadSnapshot(ctx, "s-1")
+		if none != nil || err != nil {
+			t.Fatalf("LoadSnapshot = %+v, %v; want nil, nil", none, err)
+		}
+
+		must(t, store.SaveSnapshot(ctx, domain.Snapshot{StreamID: "s-1", Version: 10, Data: `{"v": 10}`, TakenAtUtc: ts(0)}))
+		must(t, store.SaveSnapshot(ctx, domain.Snapshot{StreamID: "s-1", Version: 20, Data: `{"v": 20}`, TakenAtUtc: ts(1)}))
+
+		got, err := store.LoadSnapshot(ctx, "s-1")
+		must(t, err)
+		if got == nil || got.Version != 20 || !got.TakenAtUtc.Equal(ts(1)) {
+			t.Errorf("LoadSnapshot = %+v, want version 20", got)
+		}
+	})
+}
+
+func UnitOfWork(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	newUnit := func(t *testing.T) Repos {
+		repos := newRepos(t)
+		if repos.UnitOfWork == nil {
+			t.Skip("no UnitOfWork under test")
+		}
+		must(t, repos.Stock.UpsertMany(ctx, []*domain.StockItem{{Sku: "A", Available: 10, UpdatedAtUtc: ts(0)}}))
+		return repos
+	}
+	// write reserva 4 de A y guarda la reservación, un mensaje de outbox y,
+	// si hay event store, un evento: los pasos de un Reserve.
+	write := func(ctx context.Context, repos Repos, orderID uuid.UUID) error {
+		items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+		if err != nil {
+			return err
+		}
+		if err := items["A"].Reserve(4, ts(1)); err != nil {
+			return err
+		}
+		if err := repos.Stock.UpsertMany(ctx, []*domain.StockItem{items["A"]}); err != nil {
+			return err
+		}
+		if err := repos.Reservations.Insert(ctx, &domain.StockReservation{
+			ID:            uuid.New(),
+			OrderID:       orderID,
+			UserID:        uuid.New(),
+			Status:        domain.ReservationActive,
+			ReservedAtUtc: ts(1),
+			Lines:         []domain.ReservationLine{{ID: uuid.New(), Sku: "A", Quantity: 4}},
+		}); err != nil {
+			return err
+		}
+		if err := repos.Outbox.Insert(ctx, domain.OutboxMessage{
+			ID: uuid.New(), Type: "StockReserved", PayloadJSON: `{}`, OccurredAtUtc: 1_700_000_000,
+		}); err != nil {
+			return err
+		}
+		if repos.Events != nil {
+			return repos.Events.Append(ctx, []domain.StreamAppend{{
+				StreamID: "uow-" + orderID.String(),
+				Events:   []domain.StoredEvent{{Type: "X", Data: `{}`, OccurredAtUtc: ts(1)}},
+			}})
+		}
+		return nil
+	}
+	// state devuelve A, si la orden tiene reservación, los mensajes
+	// pendientes y los eventos del stream de la orden.
+	state := func(t *testing.T, repos Repos, orderID uuid.UUID) (domain.StockItem, bool, int, int) {
+		t.Helper()
+		items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		res, err := repos.Reservations.GetByOrderID(ctx, orderID)
+		must(t, err)
+		pending, err := repos.Outbox.GetPendingBatch(ctx, 5, 100)
+		must(t, err)
+		events := 0
+		if repos.Events != nil {
+			stored, err := repos.Events.Load(ctx, "uow-"+orderID.String(), 0)
+			must(t, err)
+			events = len(stored)
+		}
+		return *items["A"], res != nil, len(pending), events
+	}
+
+	t.Run("Do commits every write", func(t *testing.T) {
+		repos := newUnit(t)
+		orderID := uuid.New()
+		must(t, repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			return write(ctx, repos, orderID)
+		}))
+
+		a, reserved, pending, _ := state(t, repos, orderID)
+		if a.Available != 6 || a.Reserved != 4 || !reserved || pending != 1 {
+			t.Errorf("after commit A = %d/%d, reservation %v, outbox %d; want 6/4, true, 1",
+				a.Available, a.Reserved, reserved, pending)
+		}
+	})
+
+	t.Run("an error from fn discards every write", func(t *testing.T) {
+		repos := newUnit(t)
+		orderID := uuid.New()
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := write(ctx, repos, orderID); err != nil {
+				return err
+			}
+			// Dentro de la unidad se ven sus propias escrituras.
+			items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+			if err != nil {
+				return err
+			}
+			if items["A"].Reserved != 4 {
+				t.Errorf("inside the unit A reserved = %d, want 4", items["A"].Reserved)
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Do = %v, want the error from fn", err)
+		}
+
+		a, reserved, pending, events := state(t, repos, orderID)
+		if a.Available != 10 || a.Reserved != 0 || reserved || pending != 0 || events != 0 {
+			t.Errorf("after rollback A = %d/%d, reservation %v, outbox %d, events %d; want 10/0 and nothing else",
+				a.Available, a.Reserved, reserved, pending, events)
+		}
+	})
+
+	t.Run("a large stock batch is discarded too", func(t *testing.T) {
+		repos := newUnit(t)
+		items := make([]*domain.StockItem, 0, 600)
+		skus := make([]string, 0, 600)
+		for i := range 600 {
+			sku := fmt.Sprintf("BULK-%04d", i)
+			items = append(items, &domain.StockItem{Sku: sku, Available: i, UpdatedAtUtc: ts(1)})
+			skus = append(skus, sku)
+		}
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := repos.Stock.UpsertMany(ctx, items); err != nil {
+				return err
+			}
+			// Un segundo lote en la misma transacción.
+			if err := repos.Stock.UpsertMany(ctx, items); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Do = %v, want the error from fn", err)
+		}
+		got, err := repos.Stock.GetBySkus(ctx, skus)
+		must(t, err)
+		if len(got) != 0 {
+			t.Errorf("%d items survived the rollback", len(got))
+		}
+	})
+
+	t.Run("a nested Do joins the outer unit", func(t *testing.T) {
+		repos := newUnit(t)
+		orderID := uuid.New()
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+				return write(ctx, repos, orderID)
+			}); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Do = %v, want the error from fn", err)
+		}
+		if a, reserved, _, _ := state(t, repos, orderID); a.Reserved != 0 || reserved {
+			t.Errorf("the inner unit was committed on its own")
+		}
+	})
+
+	t.Run("AfterCommit runs only after a commit", func(t *testing.T) {
+		repos := newUnit(t)
+		var ran []string
+		must(t, repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			domain.AfterCommit(ctx, func() { ran = append(ran, "first") })
+			domain.AfterCommit(ctx, func() { ran = append(ran, "second") })
+			if len(ran) != 0 {
+				t.Error("hook ran before the commit")
+			}
+			return nil
+		}))
+		_ = repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			domain.AfterCommit(ctx, func() { ran = append(ran, "discarded") })
+			return errBoom
+		})
+		domain.AfterCommit(ctx, func() { ran = append(ran, "outside") })
+
+		if fmt.Sprint(ran) != "[first second outside]" {
+			t.Errorf("hooks ran = %v, want [first second outside]", ran)
+		}
+	})
+
+	t.Run("concurrent units on the same sku do not lose updates", func(t *testing.T) {
+		repos := newUnit(t)
+		const workers = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+					items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+					if err != nil {
+						return err
+					}
+					if err := items["A"].Reserve(1, ts(10+i)); err != nil {
+						return err
+					}
+					return repos.Stock.UpsertMany(ctx, []*domain.StockItem{items["A"]})
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			must(t, err)
+		}
+
+		items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		if a := items["A"]; a.Available != 10-workers || a.Reserved != workers {
+			t.Errorf("A = %d/%d, want %d/%d", a.Available, a.Reserved, 10-workers, workers)
+		}
+	})
+
+	// Los pasos de un chunk del import: leer, fijar available y guardar la
+	// fila entera. Un Reserve que llega entre la lectura y el upsert tiene
+	// que esperar al commit; si no, el upsert pisa su reserved.
+	t.Run("a reserve between an import's read and upsert waits for it", func(t *testing.T) {
+		repos := newUnit(t)
+		read := make(chan struct{})
+		reserved := make(chan error, 1)
+		go func() {
+			<-read
+			reserved <- repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+				items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+				if err != nil {
+					return err
+				}
+				if err := items["A"].Reserve(1, ts(2)); err != nil {
+					return err
+				}
+				return repos.Stock.UpsertMany(ctx, []*domain.StockItem{items["A"]})
+			})
+		}()
+
+		must(t, repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+			if err != nil {
+				return err
+			}
+			close(read)
+			select {
+			case err := <-reserved:
+				t.Errorf("the reserve finished while the import held A: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+			if err := items["A"].SetAvailable(20, ts(1)); err != nil {
+				return err
+			}
+			return repos.Stock.UpsertMany(ctx, []*domain.StockItem{items["A"]})
+		}))
+		must(t, <-reserved)
+
+		items, err := repos.Stock.GetBySkus(ctx, []string{"A"})
+		must(t, err)
+		if a := items["A"]; a.Available != 19 || a.Reserved != 1 {
+			t.Errorf("A = %d/%d, want 19/1", a.Available, a.Reserved)
+		}
+	})
+}
@@ -30,10 +30,12 @@ type StockReservation struct {
 	Lines         []ReservationLine
 }
 
-func NewStockReservation(orderID, userID uuid.UUID, lines []ReservationLine) *StockReservation {
-	now := time.Now().UTC()
+func NewStockReservation(id, orderID, userID uuid.UUID, lines []ReservationLine, now time.Time) *StockReservation {
+	for i := range lines {
+		lines[i].ReservationID = id
+	}
 	return &StockReservation{
-		ID:            uuid.New(),
+		ID:            id,
 		OrderID:       orderID,
 		UserID:        userID,
 		Status:        ReservationActive,
@@ -43,11 +45,10 @@ func NewStockReservation(orderID, userID uuid.UUID, lines []ReservationLine) *St
 	}
 }
 
-func (r *StockReservation) MarkReleased() {
+func (r *StockReservation) MarkReleased(now time.Time) {
 	if r.Status == ReservationReleased {
 		return
 	}
-	now := time.Now().UTC()
 	r.Status = ReservationReleased
 	r.ReleasedAtUtc = &now
 }
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Versiones actuales de los payloads (campo "schemaVersion"). Un payload sin
+// schemaVersion se considera versión 1.
+const (
+	OrderPlacedSchemaVersion            = 1
+	OrderCancelledSchemaVersion         = 1
+	ProductCreatedSchemaVersion         = 1
+	StockReservedSchemaVersion          = 1
+	StockReservationFailedSchemaVersion = 1
+	CatalogStockAdjustedSchemaVersion   = 1
+)
+
+// ErrInvalidPayload marca payloads que nunca se van a poder procesar
+// (JSON roto, campos obligatorios, versión desconocida).
+var ErrInvalidPayload = errors.New("invalid event payload")
+
+// PayloadError describe por qué un payload entrante es inválido.
+type PayloadError struct {
+	EventType string
+	Reason    string
+}
+
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("invalid %s payload: %s", e.EventType, e.Reason)
+}
+
+func (e *PayloadError) Unwrap() error { return ErrInvalidPayload }
+
+func invalidPayload(eventType, format string, args ...any) error {
+	return &PayloadError{EventType: eventType, Reason: fmt.Sprintf(format, args...)}
+}
+
+// Upcaster transforma un payload de la versión N a la N+1.
+type Upcaster func(payload map[string]any) (map[string]any, error)
+
+// SchemaRegistry conoce la versión actual de cada tipo de evento entrante y
+// los upcasters para llevar versiones viejas a la actual.
+type SchemaRegistry struct {
+	current   map[string]int
+	upcasters map[string]map[int]Upcaster // tipo -> versión origen -> upcaster
+}
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		current: map[string]int{
+			"OrderPlacedEvent":    OrderPlacedSchemaVersion,
+			"OrderCancelledEvent": OrderCancelledSchemaVersion,
+			"OrderRejectedEvent":  OrderCancelledSchemaVersion,
+			"ProductCreated":      ProductCreatedSchemaVersion,
+		},
+		upcasters: make(map[string]map[int]Upcaster),
+	}
+}
+
+// RegisterUpcaster registra la transformación fromVersion -> fromVersion+1.
+func (r *SchemaRegistry) RegisterUpcaster(eventType string, fromVersion int, up Upcaster) *SchemaRegistry {
+	if r.upcasters[eventType] == nil {
+		r.upcasters[eventType] = make(map[int]Upcaster)
+	}
+	r.upcasters[eventType][fromVersion] = up
+	return r
+}
+
+// Upcast lleva el payload a la versión actual del tipo y lo devuelve como JSON.
+func (r *SchemaRegistry) Upcast(eventType, payloadJSON string) ([]byte, error) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+		return nil, invalidPayload(eventType, "malformed JSON: %v", err)
+	}
+	if payload == nil {
+		return nil, invalidPayload(eventType, "payload is not a JSON object")
+	}
+
+	current, ok := r.current[eventType]
+	if !ok {
+		return nil, invalidPayload(eventType, "unknown event type")
+	}
+
+	version := 1
+	if raw, ok := payload["schemaVersion"]; ok {
+		n, ok := raw.(float64)
+		if !ok || n < 1 || n != float64(int(n)) {
+			return nil, invalidPayload(eventType, "schemaVersion must be a positive integer")
+		}
+		version = int(n)
+	}
+	if version > current {
+		return nil, invalidPayload(eventType, "schemaVersion %d is newer than supported %d", version, current)
+	}
+
+	for version < current {
+		up, ok := r.upcasters[eventType][version]
+		if !ok {
+			return nil, invalidPayload(eventType, "no upcaster from schemaVersion %d", version)
+		}
+		next, err := up(payload)
+		if err != nil {
+			return nil, invalidPayload(eventType, "upcasting from schemaVersion %d: %v", version, err)
+		}
+		version++
+		next["schemaVersion"] = version
+		payload = next
+	}
+
+	return json.Marshal(payload)
+}
+
+// decodeStrict hace upcast y unmarshal; los errores de tipos se reportan
+// como PayloadError.
+func decodeStrict(r *SchemaRegistry, eventType, payloadJSON string, v any) error {
+	data, err := r.Upcast(eventType, payloadJSON)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidPayload(eventType, "%v", err)
+	}
+	return nil
+}
+
+// DecodeOrderPlaced valida y decodifica un OrderPlacedEvent.
+func (r *SchemaRegistry) DecodeOrderPlaced(eventType, payloadJSON string) (OrderPlacedPayload, error) {
+	var p OrderPlacedPayload
+	if err := decodeStrict(r, eventType, payloadJSON, &p); err != nil {
+		return p, err
+	}
+	if p.OrderID == uuid.Nil {
+		return p, invalidPayload(eventType, "orderId is required")
+	}
+	return p, nil
+}
+
+// DecodeOrderCancelled valida y decodifica OrderCancelledEvent / OrderRejectedEvent.
+func (r *SchemaRegistry) DecodeOrderCancelled(eventType, payloadJSON string) (OrderCancelledPayload, error) {
+	var p OrderCancelledPayload
+	if err := decodeStrict(r, eventType, payloadJSON, &p); err != nil {
+		return p, err
+	}
+	if p.OrderID == uuid.Nil {
+		return p, invalidPayload(eventType, "orderId is required")
+	}
+	return p, nil
+}
+
+// DecodeProductCreated valida y decodifica un ProductCreated.
+func (r *SchemaRegistry) DecodeProductCreated(eventType, payloadJSON string) (ProductCreatedPayload, error) {
+	var p ProductCreatedPayload
+	if err := decodeStrict(r, eventType, payloadJSON, &p); err != nil {
+		return p, err
+	}
+	if p.Sku == "" {
+		return p, invalidPayload(eventType, "sku is required")
+	}
+	if p.StockQuantity < 0 {
+		return p, invalidPayload(eventType, "stockQuantity must be >= 0")
+	}
+	return p, nil
+}
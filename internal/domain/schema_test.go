@@ -0,0 +1,298 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// chainedRegistry simula ProductCreated en la versión 3: la v1 tenía "qty"
+// en vez de stockQuantity y la v2 no tenía slug.
+func chainedRegistry() *SchemaRegistry {
+	r := NewSchemaRegistry()
+	r.current["ProductCreated"] = 3
+	r.RegisterUpcaster("ProductCreated", 1, func(p map[string]any) (map[string]any, error) {
+		qty, ok := p["qty"]
+		if !ok {
+			return nil, errors.New("qty is required in v1")
+		}
+		p["stockQuantity"] = qty
+		delete(p, "qty")
+		return p, nil
+	})
+	r.RegisterUpcaster("ProductCreated", 2, func(p map[string]any) (map[string]any, error) {
+		if _, ok := p["slug"]; !ok {
+			p["slug"] = strings.ToLower(fmt.Sprint(p["sku"]))
+		}
+		return p, nil
+	})
+	return r
+}
+
+func TestUpcastChainsVersions(t *testing.T) {
+	r := chainedRegistry()
+	cases := []struct {
+		name    string
+		payload string
+		want    map[string]any
+	}{
+		{
+			name:    "no schemaVersion is v1",
+			payload: `{"sku":"ABC","qty":4}`,
+			want:    map[string]any{"sku": "ABC", "stockQuantity": 4.0, "slug": "abc", "schemaVersion": 3.0},
+		},
+		{
+			name:    "v2 runs only the last upcaster",
+			payload: `{"schemaVersion":2,"sku":"ABC","stockQuantity":4,"slug":"keep"}`,
+			want:    map[string]any{"sku": "ABC", "stockQuantity": 4.0, "slug": "keep", "schemaVersion": 3.0},
+		},
+		{
+			name:    "current version is unchanged",
+			payload: `{"schemaVersion":3,"sku":"ABC","stockQuantity":1,"slug":"x"}`,
+			want:    map[string]any{"sku": "ABC", "stockQuantity": 1.0, "slug": "x", "schemaVersion": 3.0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := r.Upcast("ProductCreated", tc.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Upcast = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	p, err := r.DecodeProductCreated("ProductCreated", `{"sku":"ABC","qty":4}`)
+	if err != nil || p.StockQuantity != 4 || p.Slug != "abc" {
+		t.Errorf("DecodeProductCreated(v1) = %+v, %v", p, err)
+	}
+}
+
+func TestUpcastRejectsInvalidPayloads(t *testing.T) {
+	r := chainedRegistry()
+	r.current["OrderPlacedEvent"] = 2 // sin upcaster 1 -> 2
+
+	cases := []struct {
+		name, eventType, payload, reason string
+	}{
+		{"malformed JSON", "ProductCreated", `{"sku":`, "malformed JSON"},
+		{"not an object", "ProductCreated", `[1,2]`, "malformed JSON"},
+		{"null", "ProductCreated", `null`, "not a JSON object"},
+		{"unknown type", "ProductDeleted", `{}`, "unknown event type"},
+		{"future version", "ProductCreated", `{"schemaVersion":4}`, "newer than supported 3"},
+		{"zero version", "ProductCreated", `{"schemaVersion":0}`, "positive integer"},
+		{"fractional version", "ProductCreated", `{"schemaVersion":1.5}`, "positive integer"},
+		{"string version", "ProductCreated", `{"schemaVersion":"2"}`, "positive integer"},
+		{"missing upcaster", "OrderPlacedEvent", `{"orderId":"x"}`, "no upcaster from schemaVersion 1"},
+		{"upcaster fails", "ProductCreated", `{"sku":"ABC"}`, "upcasting from schemaVersion 1: qty is required"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := r.Upcast(tc.eventType, tc.payload)
+			var perr *PayloadError
+			if !errors.Is(err, ErrInvalidPayload) || !errors.As(err, &perr) {
+				t.Fatalf("Upcast = %v, want a PayloadError", err)
+			}
+			if perr.EventType != tc.eventType || !strings.Contains(perr.Reason, tc.reason) {
+				t.Errorf("error = %q, want %s and %q", err, tc.eventType, tc.reason)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsInvalidPayloads(t *testing.T) {
+	r := NewSchemaRegistry()
+	orderID := uuid.NewString()
+	cases := []struct {
+		name   string
+		decode func() error
+	}{
+		{"order without orderId", func() error {
+			_, err := r.DecodeOrderPlaced("OrderPlacedEvent", `{"lines":[]}`)
+			return err
+		}},
+		{"order with a bad uuid", func() error {
+			_, err := r.DecodeOrderPlaced("OrderPlacedEvent", `{"orderId":"not-a-uuid"}`)
+			return err
+		}},
+		{"line quantity is a string", func() error {
+			_, err := r.DecodeOrderPlaced("OrderPlacedEvent", `{"orderId":"`+orderID+`","lines":[{"sku":"A","quantity":"two"}]}`)
+			return err
+		}},
+		{"cancel without orderId", func() error {
+			_, err := r.DecodeOrderCancelled("OrderRejectedEvent", `{"userId":"`+orderID+`"}`)
+			return err
+		}},
+		{"product without sku", func() error {
+			_, err := r.DecodeProductCreated("ProductCreated", `{"stockQuantity":1}`)
+			return err
+		}},
+		{"product with negative stock", func() error {
+			_, err := r.DecodeProductCreated("ProductCreated", `{"sku":"A","stockQuantity":-1}`)
+			return err
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.decode(); !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("decode = %v, want ErrInvalidPayload", err)
+			}
+		})
+	}
+
+	p, err := r.DecodeOrderPlaced("OrderPlacedEvent", `{"orderId":"`+orderID+`","lines":[{"sku":"A","quantity":2}]}`)
+	if err != nil || p.OrderID.String() != orderID || len(p.Lines) != 1 {
+		t.Errorf("DecodeOrderPlaced = %+v, %v", p, err)
+	}
+}
+
+// Los archivos de /schemas tienen que coincidir con lo que genera
+// schemagen (go generate ./internal/domain).
+func TestOutboundSchemasMatchFiles(t *testing.T) {
+	for _, ev := range OutboundEvents() {
+		path := filepath.Join("..", "..", "schemas", fmt.Sprintf("%s.v%d.schema.json", ev.Type, ev.Version))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%v (run go generate ./internal/domain)", err)
+		}
+		var onDisk, generated any
+		if err := json.Unmarshal(data, &onDisk); err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := json.Marshal(ev.JSONSchema())
+		_ = json.Unmarshal(raw, &generated)
+		if !reflect.DeepEqual(onDisk, generated) {
+			t.Errorf("%s is stale, run go generate ./internal/domain", path)
+		}
+	}
+}
+
+func TestOutboundEventsValidateAgainstSchema(t *testing.T) {
+	msg := NewEventMessage(uuid.New(), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	samples := map[string]any{
+		"StockReserved": NewStockReservedEvent(msg, uuid.New(), uuid.New(),
+			[]StockReservedLine{{Sku: "A", Quantity: 2}}),
+		"StockReservationFailed": NewStockReservationFailedEvent(msg, uuid.New(), uuid.New(), "INSUFFICIENT_STOCK"),
+		"CatalogStockAdjusted":   NewCatalogStockAdjustedEvent(msg, "A", 5, 1, "IMPORT"),
+	}
+
+	for _, ev := range OutboundEvents() {
+		schema := ev.JSONSchema()
+		doc := toJSONMap(t, samples[ev.Type])
+		if errs := validateSchema(schema, doc, ""); len(errs) > 0 {
+			t.Errorf("%s sample does not validate: %v", ev.Type, errs)
+		}
+
+		// Cada campo requerido que falta es un error.
+		for _, name := range schema["required"].([]string) {
+			broken := toJSONMap(t, samples[ev.Type])
+			delete(broken, name)
+			if errs := validateSchema(schema, broken, ""); len(errs) == 0 {
+				t.Errorf("%s without %s validates", ev.Type, name)
+			}
+		}
+	}
+
+	schema := OutboundEvents()[0].JSONSchema()
+	for _, tc := range []struct {
+		name  string
+		patch func(map[string]any)
+	}{
+		{"wrong schemaVersion", func(d map[string]any) { d["schemaVersion"] = 2.0 }},
+		{"wrong routingKey", func(d map[string]any) { d["routingKey"] = "StockReleased" }},
+		{"orderId not a uuid", func(d map[string]any) { d["orderId"] = "42" }},
+		{"quantity is a string", func(d map[string]any) { d["lines"].([]any)[0].(map[string]any)["quantity"] = "2" }},
+		{"line without sku", func(d map[string]any) { delete(d["lines"].([]any)[0].(map[string]any), "sku") }},
+		{"reservedAtUtc not a date", func(d map[string]any) { d["reservedAtUtc"] = "yesterday" }},
+	} {
+		doc := toJSONMap(t, samples["StockReserved"])
+		tc.patch(doc)
+		if errs := validateSchema(schema, doc, ""); len(errs) == 0 {
+			t.Errorf("StockReserved with %s validates", tc.name)
+		}
+	}
+}
+
+func toJSONMap(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// validateSchema chequea el subconjunto de JSON Schema que genera
+// JSONSchema: type, format (uuid, date-time), const, properties, required
+// e items.
+func validateSchema(schema map[string]any, v any, path string) []string {
+	var errs []string
+	if c, ok := schema["const"]; ok && fmt.Sprint(c) != fmt.Sprint(v) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not %v", path, v, c))
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return append(errs, path+": not an object")
+		}
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, path+"."+name+": required")
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, sub := range props {
+			if fv, ok := obj[name]; ok {
+				errs = append(errs, validateSchema(sub.(map[string]any), fv, path+"."+name)...)
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return append(errs, path+": not an array")
+		}
+		for i, item := range arr {
+			errs = append(errs, validateSchema(schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			errs = append(errs, path+": not an integer")
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return append(errs, path+": not a string")
+		}
+		switch schema["format"] {
+		case "uuid":
+			if _, err := uuid.Parse(s); err != nil {
+				errs = append(errs, path+": not a uuid")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				errs = append(errs, path+": not a date-time")
+			}
+		}
+	}
+	return errs
+}
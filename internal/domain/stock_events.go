@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de los eventos de los streams de stock y reservaciones en el event
+// store. Son internos del servicio; los eventos de integración (StockReserved,
+// CatalogStockAdjusted, ...) siguen saliendo por el outbox.
+const (
+	EventStockItemCreated      = "StockItemCreated"
+	EventStockItemReserved     = "StockItemReserved"
+	EventStockItemReleased     = "StockItemReleased"
+	EventStockItemAvailableSet = "StockItemAvailableSet"
+	// EventStockItemLevelsSet fija available y reserved de una vez: lo usan
+	// las escrituras que no vienen de una operación del item (cargas,
+	// correcciones).
+	EventStockItemLevelsSet = "StockItemLevelsSet"
+
+	EventReservationPlaced   = "StockReservationPlaced"
+	EventReservationReleased = "StockReservationReleased"
+)
+
+// Prefijos de los streams; Rebuild los usa para listarlos.
+const (
+	StockStreamPrefix       = "stock-"
+	ReservationStreamPrefix = "reservation-"
+)
+
+// StockStreamID es el stream de un SKU.
+func StockStreamID(sku string) string {
+	return StockStreamPrefix + sku
+}
+
+// ReservationStreamID es el stream de la reservación de una orden.
+func ReservationStreamID(orderID uuid.UUID) string {
+	return ReservationStreamPrefix + orderID.String()
+}
+
+// StockItemEvent es un cambio de un StockItem. Los campos que lleva
+// dependen de Type: Created trae ID, Sku y Available (y Reserved en una
+// migración); Reserved y Released, Quantity; AvailableSet, Available;
+// LevelsSet, Available y Reserved.
+type StockItemEvent struct {
+	Type          string
+	OccurredAtUtc time.Time
+	ID            uuid.UUID
+	Sku           string
+	Quantity      int
+	Available     int
+	Reserved      int
+}
+
+// RehydrateStockItem reconstruye un item aplicando events sobre from (nil
+// para empezar de cero). No valida invariantes: los eventos ya pasaron.
+func RehydrateStockItem(from *StockItem, events []StockItemEvent) *StockItem {
+	item := &StockItem{}
+	if from != nil {
+		*item = *from
+		item.changes = nil
+	}
+	for _, ev := range events {
+		item.apply(ev)
+	}
+	return item
+}
+
+func (s *StockItem) apply(ev StockItemEvent) {
+	switch ev.Type {
+	case EventStockItemCreated:
+		s.ID, s.Sku = ev.ID, ev.Sku
+		s.Available, s.Reserved = ev.Available, ev.Reserved
+	case EventStockItemReserved:
+		s.Available -= ev.Quantity
+		s.Reserved += ev.Quantity
+	case EventStockItemReleased:
+		s.Available += ev.Quantity
+		s.Reserved -= ev.Quantity
+	case EventStockItemAvailableSet:
+		s.Available = ev.Available
+	case EventStockItemLevelsSet:
+		s.Available, s.Reserved = ev.Available, ev.Reserved
+	}
+	s.UpdatedAtUtc = ev.OccurredAtUtc
+}
+
+// StockReservationEvent es un cambio de una StockReservation: Placed trae
+// la reservación completa (sin estado) y Released solo la hora.
+type StockReservationEvent struct {
+	Type          string
+	OccurredAtUtc time.Time
+	ID            uuid.UUID
+	OrderID       uuid.UUID
+	UserID        uuid.UUID
+	Lines         []ReservationLine
+}
+
+// RehydrateStockReservation reconstruye una reservación desde sus eventos;
+// devuelve nil si no hay ninguno.
+func RehydrateStockReservation(events []StockReservationEvent) *StockReservation {
+	var res *StockReservation
+	for _, ev := range events {
+		switch ev.Type {
+		case EventReservationPlaced:
+			lines := append([]ReservationLine(nil), ev.Lines...)
+			res = NewStockReservation(ev.ID, ev.OrderID, ev.UserID, lines, ev.OccurredAtUtc)
+		case EventReservationReleased:
+			if res != nil {
+				res.MarkReleased(ev.OccurredAtUtc)
+			}
+		}
+	}
+	return res
+}
@@ -1,43 +1,115 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Errores de las operaciones de StockItem. Una operación que falla no
+// cambia el item.
+var (
+	ErrInvalidQuantity   = errors.New("quantity must be positive")
+	ErrInsufficientStock = errors.New("insufficient available stock")
+	// ErrReleaseExceedsReserved indica un error de contabilidad: se intenta
+	// devolver más de lo que está reservado.
+	ErrReleaseExceedsReserved = errors.New("release exceeds reserved stock")
+	ErrNegativeStock          = errors.New("stock quantity must be >= 0")
+)
+
+// StockItem lleva el stock de un SKU. Invariantes: Available >= 0,
+// Reserved >= 0, y Reserve/Release conservan Available+Reserved (lo que hay
+// en bodega); solo SetAvailable lo cambia.
+//
+// Cada operación además registra su StockItemEvent (ver Changes), que es lo
+// que guarda el modo event-sourced.
 type StockItem struct {
 	ID           uuid.UUID
 	Sku          string
 	Available    int
 	Reserved     int
 	UpdatedAtUtc time.Time
+	// Version es la del stream del item en el event store; 0 si el item no
+	// se leyó de ahí.
+	Version int64
+
+	changes []StockItemEvent
 }
 
-func NewStockItem(sku string, available int) *StockItem {
-	return &StockItem{
-		ID:           uuid.New(),
-		Sku:          sku,
-		Available:    available,
-		Reserved:     0,
-		UpdatedAtUtc: time.Now().UTC(),
+// NewStockItem crea el item con available unidades disponibles; si
+// available es negativo devuelve ErrNegativeStock, como SetAvailable.
+func NewStockItem(id uuid.UUID, sku string, available int, now time.Time) (*StockItem, error) {
+	if available < 0 {
+		return nil, fmt.Errorf("new stock item %s with %d available: %w", sku, available, ErrNegativeStock)
 	}
+	item := &StockItem{}
+	item.record(StockItemEvent{
+		Type:          EventStockItemCreated,
+		OccurredAtUtc: now,
+		ID:            id,
+		Sku:           sku,
+		Available:     available,
+	})
+	return item, nil
+}
+
+// OnHand es el stock físico: disponible más reservado.
+func (s *StockItem) OnHand() int {
+	return s.Available + s.Reserved
 }
 
 func (s *StockItem) CanReserve(qty int) bool {
 	return qty > 0 && s.Available >= qty
 }
 
-func (s *StockItem) Reserve(qty int) {
-	s.Available -= qty
-	s.Reserved += qty
-	s.UpdatedAtUtc = time.Now().UTC()
+// Reserve pasa qty de disponible a reservado.
+func (s *StockItem) Reserve(qty int, now time.Time) error {
+	if qty <= 0 {
+		return fmt.Errorf("reserve %d of %s: %w", qty, s.Sku, ErrInvalidQuantity)
+	}
+	if s.Available < qty {
+		return fmt.Errorf("reserve %d of %s with %d available: %w", qty, s.Sku, s.Available, ErrInsufficientStock)
+	}
+	s.record(StockItemEvent{Type: EventStockItemReserved, OccurredAtUtc: now, Quantity: qty})
+	return nil
 }
 
-func (s *StockItem) Release(qty int) {
-	s.Available += qty
-	if s.Reserved >= qty {
-		s.Reserved -= qty
+// Release devuelve qty de reservado a disponible.
+func (s *StockItem) Release(qty int, now time.Time) error {
+	if qty <= 0 {
+		return fmt.Errorf("release %d of %s: %w", qty, s.Sku, ErrInvalidQuantity)
 	}
-	s.UpdatedAtUtc = time.Now().UTC()
+	if s.Reserved < qty {
+		return fmt.Errorf("release %d of %s with %d reserved: %w", qty, s.Sku, s.Reserved, ErrReleaseExceedsReserved)
+	}
+	s.record(StockItemEvent{Type: EventStockItemReleased, OccurredAtUtc: now, Quantity: qty})
+	return nil
+}
+
+// SetAvailable fija el disponible (carga inicial o ajuste de catálogo); lo
+// reservado no cambia.
+func (s *StockItem) SetAvailable(available int, now time.Time) error {
+	if available < 0 {
+		return fmt.Errorf("set available of %s to %d: %w", s.Sku, available, ErrNegativeStock)
+	}
+	s.record(StockItemEvent{Type: EventStockItemAvailableSet, OccurredAtUtc: now, Available: available})
+	return nil
+}
+
+// record aplica ev y lo deja pendiente de guardar.
+func (s *StockItem) record(ev StockItemEvent) {
+	s.apply(ev)
+	s.changes = append(s.changes, ev)
+}
+
+// Changes devuelve los eventos registrados desde que se leyó o creó el item.
+func (s *StockItem) Changes() []StockItemEvent {
+	return append([]StockItemEvent(nil), s.changes...)
+}
+
+// ClearChanges descarta los eventos registrados (ya guardados).
+func (s *StockItem) ClearChanges() {
+	s.changes = nil
 }
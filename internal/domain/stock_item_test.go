@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FuzzStockItem aplica secuencias aleatorias de Reserve/Release/SetAvailable
+// y verifica las invariantes de StockItem después de cada paso. Cada par de
+// bytes de ops es una operación: el primero elige cuál y el segundo (con
+// signo) la cantidad. Un initial negativo tiene que fallar con
+// ErrNegativeStock.
+func FuzzStockItem(f *testing.F) {
+	f.Add(int16(10), []byte{0, 3, 0, 3, 1, 3, 0, 5, 1, 6})
+	f.Add(int16(0), []byte{0, 1, 1, 1, 2, 4, 0, 4, 1, 4})
+	f.Add(int16(5), []byte{1, 1, 0, 0, 0, 0xff, 2, 0xfe, 2, 0})
+	f.Add(int16(255), []byte{0, 127, 0, 127, 0, 1, 1, 127, 1, 127, 1, 1})
+	f.Add(int16(-1), []byte{0, 1})
+
+	f.Fuzz(func(t *testing.T, initial int16, ops []byte) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		item, err := NewStockItem(uuid.Nil, "SKU", int(initial), now)
+		if initial < 0 {
+			if !errors.Is(err, ErrNegativeStock) || item != nil {
+				t.Fatalf("NewStockItem(%d) = %+v, %v; want ErrNegativeStock", initial, item, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("NewStockItem(%d): %v", initial, err)
+		}
+		onHand := item.OnHand()
+
+		for i := 0; i+1 < len(ops); i += 2 {
+			qty := int(int8(ops[i+1]))
+			before := *item
+
+			var err error
+			var wantErr error
+			switch ops[i] % 3 {
+			case 0:
+				switch {
+				case qty <= 0:
+					wantErr = ErrInvalidQuantity
+				case before.Available < qty:
+					wantErr = ErrInsufficientStock
+				}
+				err = item.Reserve(qty, now)
+			case 1:
+				switch {
+				case qty <= 0:
+					wantErr = ErrInvalidQuantity
+				case before.Reserved < qty:
+					wantErr = ErrReleaseExceedsReserved
+				}
+				err = item.Release(qty, now)
+			case 2:
+				if qty < 0 {
+					wantErr = ErrNegativeStock
+				}
+				err = item.SetAvailable(qty, now)
+				if err == nil {
+					onHand = qty + item.Reserved
+				}
+			}
+
+			if !errors.Is(err, wantErr) || (err == nil) != (wantErr == nil) {
+				t.Fatalf("op %d (%d, qty %d) on %+v: err = %v, want %v", i/2, ops[i]%3, qty, before, err, wantErr)
+			}
+			if err != nil && (item.Available != before.Available || item.Reserved != before.Reserved) {
+				t.Fatalf("op %d failed but changed the item: %+v -> %+v", i/2, before, *item)
+			}
+			if item.Available < 0 || item.Reserved < 0 {
+				t.Fatalf("op %d left negative stock: %+v", i/2, *item)
+			}
+			if item.OnHand() != onHand {
+				t.Fatalf("op %d changed on-hand: %d -> %d (%+v)", i/2, onHand, item.OnHand(), *item)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"sync"
+)
+
+// UnitOfWork agrupa escrituras de varios repositorios en una transacción:
+// los repositorios que reciben el ctx de fn escriben en ella y, si fn
+// devuelve error, no queda ninguna. Un Do dentro de otro se une a la unidad
+// de afuera.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type commitHooksKey struct{}
+
+// CommitHooks son las funciones que esperan a que la unidad se confirme.
+type CommitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// WithCommitHooks lo usan las implementaciones de UnitOfWork al abrir una
+// unidad; después del commit llaman a Run.
+func WithCommitHooks(ctx context.Context) (context.Context, *CommitHooks) {
+	h := &CommitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, h), h
+}
+
+// Run corre los hooks en el orden en que se registraron.
+func (h *CommitHooks) Run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// AfterCommit corre fn cuando se confirma la unidad de trabajo de ctx; si
+// se descarta, fn no corre. Fuera de una unidad corre enseguida.
+func AfterCommit(ctx context.Context, fn func()) {
+	h, ok := ctx.Value(commitHooksKey{}).(*CommitHooks)
+	if !ok {
+		fn()
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fn)
+}
@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
+	inventoryv1 "github.com/RodolfoDevApp/eventshop-inventory-go/internal/grpcapi/inventoryv1"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+)
+
+const requestIDKey = "x-request-id"
+
+// methodRoles es el rol mínimo por método. Los que no están (ej. reflection)
+// son públicos, igual que /swagger.json en HTTP.
+var methodRoles = map[string]auth.Role{
+	inventoryv1.InventoryService_GetStock_FullMethodName:      auth.RoleReader,
+	inventoryv1.InventoryService_BatchGetStock_FullMethodName: auth.RoleReader,
+	inventoryv1.InventoryService_WatchStock_FullMethodName:    auth.RoleReader,
+	inventoryv1.InventoryService_Reserve_FullMethodName:       auth.RoleWarehouseOperator,
+	inventoryv1.InventoryService_Release_FullMethodName:       auth.RoleWarehouseOperator,
+}
+
+// ServerOptions devuelve los interceptores de logging y autenticación. Sin
+// Authenticator (auth.enabled=false) los métodos quedan abiertos.
+func ServerOptions(authn *auth.Authenticator) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryLogging, unaryAuth(authn)),
+		grpc.ChainStreamInterceptor(streamLogging, streamAuth(authn)),
+	}
+}
+
+func unaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = withRequestID(ctx)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func streamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := withRequestID(ss.Context())
+	start := time.Now()
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func unaryAuth(authn *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorize(ctx, authn, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(authn *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), authn, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize toma las credenciales de la metadata "authorization" y
+// "x-api-key", igual que los headers HTTP.
+func authorize(ctx context.Context, authn *auth.Authenticator, method string) (context.Context, error) {
+	role, protected := methodRoles[method]
+	if authn == nil || !protected {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	p, err := authn.AuthenticateCredentials(first(md, "authorization"), first(md, "x-api-key"))
+	if err != nil {
+		slog.InfoContext(ctx, "grpc call not authenticated", "method", method, "error", err)
+		return ctx, errorStatus(codes.Unauthenticated, "UNAUTHORIZED", "missing or invalid bearer token or API key")
+	}
+
+	ctx = logging.With(ctx, "subject", p.Subject, "authMethod", p.Method)
+	if !p.Has(role) {
+		slog.WarnContext(ctx, "grpc call forbidden", "method", method, "requiredRole", string(role))
+		return ctx, errorStatus(codes.PermissionDenied, "FORBIDDEN", "requires role "+string(role))
+	}
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := first(md, requestIDKey)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	return logging.With(ctx, logging.KeyRequestID, requestID)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	slog.InfoContext(ctx, "grpc call",
+		"method", method,
+		"code", status.Code(err).String(),
+		"durationMs", time.Since(start).Milliseconds(),
+	)
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// contextStream reemplaza el contexto de un ServerStream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,287 @@
+// Package grpcapi expone la API gRPC de inventario (proto/eventshop/inventory/v1).
+// Usa los mismos repos y servicios de aplicación que la API HTTP y los
+// consumidores de eventos.
+package grpcapi
+
+//go:generate sh -c "cd ../.. && buf generate"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	inventoryv1 "github.com/RodolfoDevApp/eventshop-inventory-go/internal/grpcapi/inventoryv1"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+// errorDomain es el domain de los google.rpc.ErrorInfo que devolvemos.
+const errorDomain = "inventory.eventshop"
+
+type Server struct {
+	inventoryv1.UnimplementedInventoryServiceServer
+
+	cfg             config.Config
+	stockRepo       domain.StockItemRepository
+	reservationRepo domain.StockReservationRepository
+	reserveSvc      *application.ReserveStockService
+	releaseSvc      *application.ReleaseReservationService
+	feed            *stockfeed.Feed
+}
+
+func NewServer(
+	cfg config.Config,
+	stockRepo domain.StockItemRepository,
+	reservationRepo domain.StockReservationRepository,
+	reserveSvc *application.ReserveStockService,
+	releaseSvc *application.ReleaseReservationService,
+	feed *stockfeed.Feed,
+) *Server {
+	return &Server{
+		cfg:             cfg,
+		stockRepo:       stockRepo,
+		reservationRepo: reservationRepo,
+		reserveSvc:      reserveSvc,
+		releaseSvc:      releaseSvc,
+		feed:            feed,
+	}
+}
+
+// Register agrega el servicio al grpc.Server.
+func (s *Server) Register(gs *grpc.Server) {
+	inventoryv1.RegisterInventoryServiceServer(gs, s)
+}
+
+func (s *Server) GetStock(ctx context.Context, req *inventoryv1.GetStockRequest) (*inventoryv1.GetStockResponse, error) {
+	if req.GetSku() == "" {
+		return nil, invalidArgument("INVALID_PARAMETER", "sku is required")
+	}
+
+	items, err := s.stockRepo.GetBySkus(ctx, []string{req.GetSku()})
+	if err != nil {
+		return nil, internalError(ctx, "get stock failed", err)
+	}
+	item, ok := items[req.GetSku()]
+	if !ok {
+		return nil, errorStatus(codes.NotFound, "SKU_NOT_FOUND", fmt.Sprintf("SKU %s not found", req.GetSku()))
+	}
+	return &inventoryv1.GetStockResponse{Stock: toStockLevel(item)}, nil
+}
+
+func (s *Server) BatchGetStock(ctx context.Context, req *inventoryv1.BatchGetStockRequest) (*inventoryv1.BatchGetStockResponse, error) {
+	skus, err := s.checkSkus(req.GetSkus(), s.cfg.GRPC.MaxSkusPerRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.stockRepo.GetBySkus(ctx, skus)
+	if err != nil {
+		return nil, internalError(ctx, "batch get stock failed", err)
+	}
+
+	resp := &inventoryv1.BatchGetStockResponse{}
+	for _, sku := range skus {
+		if item, ok := items[sku]; ok {
+			resp.Items = append(resp.Items, toStockLevel(item))
+		} else {
+			resp.MissingSkus = append(resp.MissingSkus, sku)
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) Reserve(ctx context.Context, req *inventoryv1.ReserveRequest) (*inventoryv1.ReserveResponse, error) {
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil || orderID == uuid.Nil {
+		return nil, invalidArgument("INVALID_ORDER_ID", "order_id must be a UUID")
+	}
+	var userID uuid.UUID
+	if req.GetUserId() != "" {
+		if userID, err = uuid.Parse(req.GetUserId()); err != nil {
+			return nil, invalidArgument("INVALID_PARAMETER", "user_id must be a UUID")
+		}
+	}
+
+	payload := domain.OrderPlacedPayload{OrderID: orderID, UserID: userID}
+	for _, l := range req.GetLines() {
+		if l.GetSku() == "" || l.GetQuantity() <= 0 {
+			return nil, invalidArgument("INVALID_PARAMETER", "every line needs a sku and a positive quantity")
+		}
+		payload.Lines = append(payload.Lines, domain.OrderPlacedLine{Sku: l.GetSku(), Quantity: int(l.GetQuantity())})
+	}
+
+	res, err := s.reserveSvc.Reserve(ctx, payload)
+	if err != nil {
+		return nil, internalError(ctx, "reserve failed", err)
+	}
+
+	switch res.FailureReason {
+	case "":
+		return &inventoryv1.ReserveResponse{
+			Reservation:     toReservation(res.Reservation),
+			AlreadyReserved: res.AlreadyReserved,
+		}, nil
+	case metrics.ReasonNoLines, metrics.ReasonInvalidQuantity:
+		return nil, invalidArgument("INVALID_PARAMETER", res.FailureDetail)
+	case metrics.ReasonSkuNotFound:
+		return nil, errorStatus(codes.NotFound, "SKU_NOT_FOUND", res.FailureDetail)
+	default:
+		return nil, errorStatus(codes.FailedPrecondition, "INSUFFICIENT_STOCK", res.FailureDetail)
+	}
+}
+
+func (s *Server) Release(ctx context.Context, req *inventoryv1.ReleaseRequest) (*inventoryv1.ReleaseResponse, error) {
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil || orderID == uuid.Nil {
+		return nil, invalidArgument("INVALID_ORDER_ID", "order_id must be a UUID")
+	}
+
+	res, err := s.releaseSvc.Release(ctx, orderID)
+	if err != nil {
+		return nil, internalError(ctx, "release failed", err)
+	}
+	if res.Reservation == nil {
+		return nil, errorStatus(codes.NotFound, "RESERVATION_NOT_FOUND", "no reservation for order "+orderID.String())
+	}
+	return &inventoryv1.ReleaseResponse{
+		Reservation:     toReservation(res.Reservation),
+		AlreadyReleased: res.AlreadyReleased,
+	}, nil
+}
+
+// WatchStock manda el nivel actual de cada SKU existente y luego cada
+// cambio del feed. Igual que el SSE, un cliente lento recibe solo el último
+// nivel de cada SKU.
+func (s *Server) WatchStock(req *inventoryv1.WatchStockRequest, stream inventoryv1.InventoryService_WatchStockServer) error {
+	skus, err := s.checkSkus(req.GetSkus(), s.cfg.Stream.MaxSkus)
+	if err != nil {
+		return err
+	}
+	ctx := stream.Context()
+
+	sub, err := s.feed.Subscribe(skus)
+	if errors.Is(err, stockfeed.ErrTooManySubscribers) {
+		return errorStatus(codes.ResourceExhausted, "TOO_MANY_STREAMS", err.Error())
+	} else if err != nil {
+		return status.Error(codes.Unavailable, "server shutting down")
+	}
+	defer sub.Close()
+
+	items, err := s.stockRepo.GetBySkus(ctx, skus)
+	if err != nil {
+		return internalError(ctx, "watch stock failed", err)
+	}
+	changes := make([]stockfeed.Change, 0, len(items))
+	for _, sku := range skus {
+		if item, ok := items[sku]; ok {
+			changes = append(changes, stockfeed.ChangeOf(item))
+		}
+	}
+
+	for {
+		for _, c := range changes {
+			if err := stream.Send(&inventoryv1.WatchStockResponse{Stock: changeToStockLevel(c)}); err != nil {
+				return err
+			}
+		}
+		if changes, err = sub.Next(ctx); errors.Is(err, stockfeed.ErrClosed) {
+			return status.Error(codes.Unavailable, "server shutting down")
+		} else if err != nil {
+			return status.FromContextError(err).Err()
+		}
+	}
+}
+
+// checkSkus valida la lista de SKUs y le quita los repetidos.
+func (s *Server) checkSkus(skus []string, max int) ([]string, error) {
+	if len(skus) == 0 {
+		return nil, invalidArgument("INVALID_PARAMETER", "skus is required")
+	}
+	if len(skus) > max {
+		return nil, invalidArgument("INVALID_PARAMETER", fmt.Sprintf("at most %d skus per request", max))
+	}
+
+	seen := make(map[string]bool, len(skus))
+	out := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		if sku == "" {
+			return nil, invalidArgument("INVALID_PARAMETER", "skus must not be empty")
+		}
+		if !seen[sku] {
+			seen[sku] = true
+			out = append(out, sku)
+		}
+	}
+	return out, nil
+}
+
+func toStockLevel(item *domain.StockItem) *inventoryv1.StockLevel {
+	return &inventoryv1.StockLevel{
+		Sku:       item.Sku,
+		Available: int32(item.Available),
+		Reserved:  int32(item.Reserved),
+		UpdatedAt: timestamppb.New(item.UpdatedAtUtc),
+	}
+}
+
+func changeToStockLevel(c stockfeed.Change) *inventoryv1.StockLevel {
+	return &inventoryv1.StockLevel{
+		Sku:       c.Sku,
+		Available: int32(c.Available),
+		Reserved:  int32(c.Reserved),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+}
+
+func toReservation(r *domain.StockReservation) *inventoryv1.Reservation {
+	out := &inventoryv1.Reservation{
+		OrderId:    r.OrderID.String(),
+		UserId:     r.UserID.String(),
+		Status:     inventoryv1.ReservationStatus_RESERVATION_STATUS_ACTIVE,
+		ReservedAt: timestamppb.New(r.ReservedAtUtc),
+	}
+	if r.Status == domain.ReservationReleased {
+		out.Status = inventoryv1.ReservationStatus_RESERVATION_STATUS_RELEASED
+	}
+	if r.ReleasedAtUtc != nil {
+		out.ReleasedAt = timestamppb.New(*r.ReleasedAtUtc)
+	}
+	for _, l := range r.Lines {
+		out.Lines = append(out.Lines, &inventoryv1.ReservationLine{Sku: l.Sku, Quantity: int32(l.Quantity)})
+	}
+	return out
+}
+
+// errorStatus arma un status con ErrorInfo; reason es el mismo código que
+// usa la API HTTP en los problem+json.
+func errorStatus(code codes.Code, reason, msg string) error {
+	st := status.New(code, msg)
+	if withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}); err == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
+
+func invalidArgument(reason, msg string) error {
+	return errorStatus(codes.InvalidArgument, reason, msg)
+}
+
+// internalError loguea el error real y devuelve uno genérico al cliente.
+func internalError(ctx context.Context, msg string, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	slog.ErrorContext(ctx, "grpc "+msg, "error", err)
+	return errorStatus(codes.Internal, "INTERNAL_ERROR", msg)
+}
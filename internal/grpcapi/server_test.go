@@ -0,0 +1,368 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/auth"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	inventoryv1 "github.com/RodolfoDevApp/eventshop-inventory-go/internal/grpcapi/inventoryv1"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/stockfeed"
+)
+
+const testSecret = "grpc-test-secret-at-least-32-bytes!!"
+
+var testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testServer es el servicio gRPC sobre bufconn y repos en memoria.
+type testServer struct {
+	store  *memory.Store
+	client inventoryv1.InventoryServiceClient
+}
+
+// newTestServer levanta el servidor con los interceptores reales; authn nil
+// deja los métodos abiertos.
+func newTestServer(t *testing.T, authn *auth.Authenticator, stock map[string]int) *testServer {
+	t.Helper()
+	store := memory.NewStore()
+	stockRepo := memory.NewStockItemRepository(store)
+	reservations := memory.NewStockReservationRepository(store)
+	clock := domain.NewManualClock(testStart, time.Second)
+	ids := domain.NewSequentialIDGenerator(t.Name())
+	writer := application.NewOutboxWriter(memory.NewOutboxRepository(store), clock, ids)
+	uow := memory.NewUnitOfWork(store)
+
+	var items []*domain.StockItem
+	for sku, qty := range stock {
+		item, err := domain.NewStockItem(ids.NewID(), sku, qty, clock.Now())
+		if err != nil {
+			t.Fatalf("seed stock: %v", err)
+		}
+		items = append(items, item)
+	}
+	if err := stockRepo.UpsertMany(context.Background(), items); err != nil {
+		t.Fatalf("seed stock: %v", err)
+	}
+
+	srv := NewServer(
+		config.Default(),
+		stockRepo,
+		reservations,
+		application.NewReserveStockService(uow, stockRepo, reservations, writer, clock, ids),
+		application.NewReleaseReservationService(uow, stockRepo, reservations, writer, clock, ids),
+		stockfeed.NewFeed(0),
+	)
+	gs := grpc.NewServer(ServerOptions(authn)...)
+	srv.Register(gs)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &testServer{store: store, client: inventoryv1.NewInventoryServiceClient(conn)}
+}
+
+// assertStatus compara el código y el reason del ErrorInfo.
+func assertStatus(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		t.Fatalf("error = %v, want %s", err, code)
+	}
+	var got string
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			got = info.GetReason()
+			if info.GetDomain() != errorDomain {
+				t.Errorf("ErrorInfo domain = %q, want %q", info.GetDomain(), errorDomain)
+			}
+		}
+	}
+	if got != reason {
+		t.Errorf("ErrorInfo reason = %q, want %q", got, reason)
+	}
+}
+
+func TestGetStock(t *testing.T) {
+	ts := newTestServer(t, nil, map[string]int{"SKU-1": 5})
+	ctx := context.Background()
+
+	resp, err := ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{Sku: "SKU-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := resp.GetStock()
+	if s.GetSku() != "SKU-1" || s.GetAvailable() != 5 || s.GetReserved() != 0 || !s.GetUpdatedAt().AsTime().Equal(testStart) {
+		t.Errorf("stock = %v", s)
+	}
+
+	_, err = ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{Sku: "NOPE"})
+	assertStatus(t, err, codes.NotFound, "SKU_NOT_FOUND")
+
+	_, err = ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{})
+	assertStatus(t, err, codes.InvalidArgument, "INVALID_PARAMETER")
+
+	ts.store.FailOn("StockItemRepository.GetBySkus", errors.New("db down"))
+	_, err = ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{Sku: "SKU-1"})
+	assertStatus(t, err, codes.Internal, "INTERNAL_ERROR")
+	if strings.Contains(err.Error(), "db down") {
+		t.Errorf("internal error leaks the cause: %v", err)
+	}
+}
+
+func TestReserveAndRelease(t *testing.T) {
+	ts := newTestServer(t, nil, map[string]int{"SKU-1": 5, "SKU-2": 1})
+	ctx := context.Background()
+	orderID := uuid.NewString()
+	req := &inventoryv1.ReserveRequest{
+		OrderId: orderID,
+		Lines:   []*inventoryv1.ReservationLine{{Sku: "SKU-1", Quantity: 2}},
+	}
+
+	resp, err := ts.client.Reserve(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := resp.GetReservation()
+	if resp.GetAlreadyReserved() || r.GetOrderId() != orderID ||
+		r.GetStatus() != inventoryv1.ReservationStatus_RESERVATION_STATUS_ACTIVE || len(r.GetLines()) != 1 {
+		t.Fatalf("reserve = %v", resp)
+	}
+
+	again, err := ts.client.Reserve(ctx, req)
+	if err != nil || !again.GetAlreadyReserved() {
+		t.Errorf("second reserve = %v, %v; want already reserved", again, err)
+	}
+
+	stock, err := ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{Sku: "SKU-1"})
+	if err != nil || stock.GetStock().GetAvailable() != 3 || stock.GetStock().GetReserved() != 2 {
+		t.Errorf("stock after reserve = %v, %v; want 3/2", stock, err)
+	}
+
+	rel, err := ts.client.Release(ctx, &inventoryv1.ReleaseRequest{OrderId: orderID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.GetAlreadyReleased() || rel.GetReservation().GetStatus() != inventoryv1.ReservationStatus_RESERVATION_STATUS_RELEASED ||
+		rel.GetReservation().GetReleasedAt() == nil {
+		t.Errorf("release = %v", rel)
+	}
+	rel, err = ts.client.Release(ctx, &inventoryv1.ReleaseRequest{OrderId: orderID})
+	if err != nil || !rel.GetAlreadyReleased() {
+		t.Errorf("second release = %v, %v; want already released", rel, err)
+	}
+
+	stock, err = ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{Sku: "SKU-1"})
+	if err != nil || stock.GetStock().GetAvailable() != 5 || stock.GetStock().GetReserved() != 0 {
+		t.Errorf("stock after release = %v, %v; want 5/0", stock, err)
+	}
+}
+
+func TestStatusMapping(t *testing.T) {
+	ts := newTestServer(t, nil, map[string]int{"SKU-1": 1})
+	ctx := context.Background()
+	line := func(sku string, qty int32) []*inventoryv1.ReservationLine {
+		return []*inventoryv1.ReservationLine{{Sku: sku, Quantity: qty}}
+	}
+
+	cases := []struct {
+		name   string
+		call   func() error
+		code   codes.Code
+		reason string
+	}{
+		{
+			name: "reserve with a bad order id",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: "x", Lines: line("SKU-1", 1)})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_ORDER_ID",
+		},
+		{
+			name: "reserve with the nil order id",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: uuid.Nil.String(), Lines: line("SKU-1", 1)})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_ORDER_ID",
+		},
+		{
+			name: "reserve with a bad user id",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: uuid.NewString(), UserId: "x", Lines: line("SKU-1", 1)})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_PARAMETER",
+		},
+		{
+			name: "reserve a non-positive quantity",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: uuid.NewString(), Lines: line("SKU-1", 0)})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_PARAMETER",
+		},
+		{
+			name: "reserve without lines",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: uuid.NewString()})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_PARAMETER",
+		},
+		{
+			name: "reserve an unknown sku",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: uuid.NewString(), Lines: line("NOPE", 1)})
+				return err
+			},
+			code: codes.NotFound, reason: "SKU_NOT_FOUND",
+		},
+		{
+			name: "reserve more than available",
+			call: func() error {
+				_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{OrderId: uuid.NewString(), Lines: line("SKU-1", 2)})
+				return err
+			},
+			code: codes.FailedPrecondition, reason: "INSUFFICIENT_STOCK",
+		},
+		{
+			name: "release with a bad order id",
+			call: func() error {
+				_, err := ts.client.Release(ctx, &inventoryv1.ReleaseRequest{OrderId: "x"})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_ORDER_ID",
+		},
+		{
+			name: "release an unknown order",
+			call: func() error {
+				_, err := ts.client.Release(ctx, &inventoryv1.ReleaseRequest{OrderId: uuid.NewString()})
+				return err
+			},
+			code: codes.NotFound, reason: "RESERVATION_NOT_FOUND",
+		},
+		{
+			name: "batch get without skus",
+			call: func() error {
+				_, err := ts.client.BatchGetStock(ctx, &inventoryv1.BatchGetStockRequest{})
+				return err
+			},
+			code: codes.InvalidArgument, reason: "INVALID_PARAMETER",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertStatus(t, tc.call(), tc.code, tc.reason)
+		})
+	}
+
+	t.Run("storage failure", func(t *testing.T) {
+		ts.store.FailOn("StockReservationRepository.GetByOrderID", errors.New("db down"))
+		defer ts.store.FailOn("StockReservationRepository.GetByOrderID", nil)
+		_, err := ts.client.Release(ctx, &inventoryv1.ReleaseRequest{OrderId: uuid.NewString()})
+		assertStatus(t, err, codes.Internal, "INTERNAL_ERROR")
+	})
+}
+
+// token firma un JWT HS256 con los roles dados.
+func token(t *testing.T, roles ...auth.Role) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "tester",
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	authn, err := auth.NewAuthenticator(config.AuthConfig{
+		Enabled:    true,
+		HMACSecret: testSecret,
+		RolesClaim: "roles",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newTestServer(t, authn, map[string]int{"SKU-1": 5})
+
+	get := func(ctx context.Context) error {
+		_, err := ts.client.GetStock(ctx, &inventoryv1.GetStockRequest{Sku: "SKU-1"})
+		return err
+	}
+	reserve := func(ctx context.Context) error {
+		_, err := ts.client.Reserve(ctx, &inventoryv1.ReserveRequest{
+			OrderId: uuid.NewString(),
+			Lines:   []*inventoryv1.ReservationLine{{Sku: "SKU-1", Quantity: 1}},
+		})
+		return err
+	}
+	release := func(ctx context.Context) error {
+		_, err := ts.client.Release(ctx, &inventoryv1.ReleaseRequest{OrderId: uuid.NewString()})
+		return err
+	}
+
+	cases := []struct {
+		name          string
+		authorization string
+		call          func(context.Context) error
+		code          codes.Code
+		reason        string
+	}{
+		{name: "get without credentials", call: get, code: codes.Unauthenticated, reason: "UNAUTHORIZED"},
+		{name: "get with a bad token", authorization: "Bearer nope", call: get, code: codes.Unauthenticated, reason: "UNAUTHORIZED"},
+		{name: "get as reader", authorization: token(t, auth.RoleReader), call: get, code: codes.OK},
+		{name: "reserve as reader", authorization: token(t, auth.RoleReader), call: reserve, code: codes.PermissionDenied, reason: "FORBIDDEN"},
+		{name: "reserve as operator", authorization: token(t, auth.RoleWarehouseOperator), call: reserve, code: codes.OK},
+		{name: "release without credentials", call: release, code: codes.Unauthenticated, reason: "UNAUTHORIZED"},
+		{name: "release as reader", authorization: token(t, auth.RoleReader), call: release, code: codes.PermissionDenied, reason: "FORBIDDEN"},
+		// Pasa la autorización y llega al handler.
+		{name: "release as admin", authorization: token(t, auth.RoleAdmin), call: release, code: codes.NotFound, reason: "RESERVATION_NOT_FOUND"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.authorization)
+			}
+			err := tc.call(ctx)
+			if tc.code == codes.OK {
+				if err != nil {
+					t.Fatalf("call = %v, want OK", err)
+				}
+				return
+			}
+			assertStatus(t, err, tc.code, tc.reason)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// pgUniqueViolation es el SQLSTATE de unique_violation.
+const pgUniqueViolation = "23505"
+
+// PgEventStore guarda los streams en inventory_events (unique por stream y
+// versión) y el último snapshot de cada uno en inventory_snapshots.
+type PgEventStore struct {
+	db *sql.DB
+}
+
+func NewPgEventStore(db *sql.DB) *PgEventStore {
+	return &PgEventStore{db: db}
+}
+
+// Append verifica la versión de cada stream dentro de la transacción; si
+// otro escritor gana la carrera entre la verificación y el insert, el unique
+// (stream_id, version) lo rechaza y también se devuelve
+// ErrConcurrencyConflict.
+func (s *PgEventStore) Append(ctx context.Context, appends []domain.StreamAppend) error {
+	ctx, span := startSpan(ctx, "PgEventStore.Append")
+	defer span.End()
+
+	if len(appends) == 0 {
+		return nil
+	}
+
+	vq := `select coalesce(max(version), 0) from inventory_events where stream_id = $1`
+	iq := `
+        insert into inventory_events (stream_id, version, event_type, data, occurred_at_utc)
+        values ($1,$2,$3,$4::jsonb,$5)
+    `
+	return conflictOr(inTx(ctx, s.db, func(tx *sql.Tx) error {
+		for _, a := range appends {
+			var current int64
+			if err := tx.QueryRowContext(ctx, vq, a.StreamID).Scan(&current); err != nil {
+				return err
+			}
+			if current != a.ExpectedVersion {
+				return fmt.Errorf("stream %s is at version %d, expected %d: %w",
+					a.StreamID, current, a.ExpectedVersion, domain.ErrConcurrencyConflict)
+			}
+			for i, ev := range a.Events {
+				if _, err := tx.ExecContext(
+					ctx, iq,
+					a.StreamID,
+					a.ExpectedVersion+int64(i)+1,
+					ev.Type,
+					ev.Data,
+					ev.OccurredAtUtc,
+				); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}))
+}
+
+// conflictOr traduce la violación del unique a ErrConcurrencyConflict.
+func conflictOr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return fmt.Errorf("%w: %s", domain.ErrConcurrencyConflict, pgErr.Detail)
+	}
+	return err
+}
+
+func (s *PgEventStore) Load(ctx context.Context, streamID string, after int64) ([]domain.StoredEvent, error) {
+	ctx, span := startSpan(ctx, "PgEventStore.Load")
+	defer span.End()
+
+	q := `
+        select stream_id, version, event_type, data::text, occurred_at_utc
+        from inventory_events
+        where stream_id = $1 and version > $2
+        order by version
+    `
+	rows, err := querier(ctx, s.db).QueryContext(ctx, q, streamID, after)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.StoredEvent
+	for rows.Next() {
+		var ev domain.StoredEvent
+		if err := rows.Scan(&ev.StreamID, &ev.Version, &ev.Type, &ev.Data, &ev.OccurredAtUtc); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+func (s *PgEventStore) StreamIDs(ctx context.Context, prefix string) ([]string, error) {
+	ctx, span := startSpan(ctx, "PgEventStore.StreamIDs")
+	defer span.End()
+
+	// left() en vez de like: el prefijo puede traer % o _.
+	q := `
+        select distinct stream_id
+        from inventory_events
+        where left(stream_id, length($1)) = $1
+        order by stream_id
+    `
+	rows, err := querier(ctx, s.db).QueryContext(ctx, q, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// LoadSnapshot devuelve nil si el stream no tiene snapshot.
+func (s *PgEventStore) LoadSnapshot(ctx context.Context, streamID string) (*domain.Snapshot, error) {
+	ctx, span := startSpan(ctx, "PgEventStore.LoadSnapshot")
+	defer span.End()
+
+	q := `
+        select stream_id, version, data::text, taken_at_utc
+        from inventory_snapshots
+        where stream_id = $1
+    `
+	var snap domain.Snapshot
+	err := querier(ctx, s.db).QueryRowContext(ctx, q, streamID).Scan(&snap.StreamID, &snap.Version, &snap.Data, &snap.TakenAtUtc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *PgEventStore) SaveSnapshot(ctx context.Context, snap domain.Snapshot) error {
+	ctx, span := startSpan(ctx, "PgEventStore.SaveSnapshot")
+	defer span.End()
+
+	q := `
+        insert into inventory_snapshots (stream_id, version, data, taken_at_utc)
+        values ($1,$2,$3::jsonb,$4)
+        on conflict (stream_id) do update
+        set version = excluded.version,
+            data = excluded.data,
+            taken_at_utc = excluded.taken_at_utc
+    `
+	_, err := querier(ctx, s.db).ExecContext(ctx, q, snap.StreamID, snap.Version, snap.Data, snap.TakenAtUtc)
+	return err
+}
+
+var _ domain.EventStore = (*PgEventStore)(nil)
@@ -23,6 +23,9 @@ func (r *PgOutboxRepository) Insert(
 	ctx context.Context,
 	msg domain.OutboxMessage,
 ) error {
+	ctx, span := startSpan(ctx, "PgOutboxRepository.Insert")
+	defer span.End()
+
 	if msg.ID == uuid.Nil {
 		msg.ID = uuid.New()
 	}
@@ -32,16 +35,17 @@ func (r *PgOutboxRepository) Insert(
 
 	q := `
         insert into outbox_messages
-        (id, type, payload_json, occurred_at_utc, retry_count, processed_at_utc)
-        values ($1,$2,$3,to_timestamp($4),$5,null)
+        (id, type, payload_json, occurred_at_utc, retry_count, processed_at_utc, trace_context)
+        values ($1,$2,$3,to_timestamp($4),$5,null,nullif($6,''))
     `
-	_, err := r.db.ExecContext(
+	_, err := querier(ctx, r.db).ExecContext(
 		ctx, q,
 		msg.ID,
 		msg.Type,
 		msg.PayloadJSON,
 		msg.OccurredAtUtc,
 		msg.RetryCount,
+		msg.TraceContext,
 	)
 	return err
 }
@@ -50,18 +54,22 @@ func (r *PgOutboxRepository) GetPendingBatch(
 	ctx context.Context,
 	maxRetry, batchSize int,
 ) ([]domain.OutboxMessage, error) {
+	ctx, span := startSpan(ctx, "PgOutboxRepository.GetPendingBatch")
+	defer span.End()
+
 	q := `
         select id, type, payload_json,
                extract(epoch from occurred_at_utc) as occurred_at_sec,
                retry_count,
-               processed_at_utc
+               processed_at_utc,
+               coalesce(trace_context, '')
         from outbox_messages
         where processed_at_utc is null
           and retry_count < $1
         order by occurred_at_utc asc
         limit $2
     `
-	rows, err := r.db.QueryContext(ctx, q, maxRetry, batchSize)
+	rows, err := querier(ctx, r.db).QueryContext(ctx, q, maxRetry, batchSize)
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +87,7 @@ func (r *PgOutboxRepository) GetPendingBatch(
 			&occurredSec,
 			&msg.RetryCount,
 			&processedAt,
+			&msg.TraceContext,
 		); err != nil {
 			return nil, err
 		}
@@ -96,6 +105,9 @@ func (r *PgOutboxRepository) Save(
 	ctx context.Context,
 	msg domain.OutboxMessage,
 ) error {
+	ctx, span := startSpan(ctx, "PgOutboxRepository.Save")
+	defer span.End()
+
 	if msg.ID == uuid.Nil {
 		return errors.New("outbox message id is empty")
 	}
@@ -115,7 +127,7 @@ func (r *PgOutboxRepository) Save(
             processed_at_utc = coalesce(to_timestamp($3), processed_at_utc)
         where id = $1
     `
-	_, err := r.db.ExecContext(
+	_, err := querier(ctx, r.db).ExecContext(
 		ctx, q,
 		msg.ID,
 		msg.RetryCount,
@@ -123,3 +135,26 @@ func (r *PgOutboxRepository) Save(
 	)
 	return err
 }
+
+func (r *PgOutboxRepository) GetPendingStats(
+	ctx context.Context,
+	maxRetry int,
+) (domain.OutboxStats, error) {
+	ctx, span := startSpan(ctx, "PgOutboxRepository.GetPendingStats")
+	defer span.End()
+
+	q := `
+        select count(*),
+               coalesce(extract(epoch from min(occurred_at_utc)), 0)
+        from outbox_messages
+        where processed_at_utc is null
+          and retry_count < $1
+    `
+	var stats domain.OutboxStats
+	var oldestSec float64
+	if err := querier(ctx, r.db).QueryRowContext(ctx, q, maxRetry).Scan(&stats.Pending, &oldestSec); err != nil {
+		return stats, err
+	}
+	stats.OldestOccurredAtUtc = int64(oldestSec)
+	return stats, nil
+}
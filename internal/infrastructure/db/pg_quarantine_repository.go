@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+type PgQuarantineRepository struct {
+	db *sql.DB
+}
+
+func NewPgQuarantineRepository(db *sql.DB) *PgQuarantineRepository {
+	return &PgQuarantineRepository{db: db}
+}
+
+func (r *PgQuarantineRepository) Insert(
+	ctx context.Context,
+	msg domain.QuarantinedMessage,
+) error {
+	ctx, span := startSpan(ctx, "PgQuarantineRepository.Insert")
+	defer span.End()
+
+	if msg.ID == uuid.Nil {
+		msg.ID = uuid.New()
+	}
+
+	var envelopeID *uuid.UUID
+	if msg.EnvelopeID != uuid.Nil {
+		envelopeID = &msg.EnvelopeID
+	}
+
+	q := `
+        insert into inventory_quarantine
+        (id, envelope_id, event_type, envelope_json, reason, attempts, permanent, quarantined_at_utc, replayed_at_utc)
+        values ($1,$2,$3,$4,$5,$6,$7,$8,null)
+    `
+	_, err := querier(ctx, r.db).ExecContext(
+		ctx, q,
+		msg.ID,
+		envelopeID,
+		msg.EventType,
+		msg.EnvelopeJSON,
+		msg.Reason,
+		msg.Attempts,
+		msg.Permanent,
+		msg.QuarantinedAtUtc,
+	)
+	return err
+}
+
+const quarantineColumns = `
+        id, envelope_id, event_type, envelope_json, reason, attempts, permanent,
+        quarantined_at_utc, replayed_at_utc
+    `
+
+func (r *PgQuarantineRepository) List(
+	ctx context.Context,
+	pendingOnly bool,
+	limit int,
+) ([]domain.QuarantinedMessage, error) {
+	ctx, span := startSpan(ctx, "PgQuarantineRepository.List")
+	defer span.End()
+
+	q := `
+        select ` + quarantineColumns + `
+        from inventory_quarantine
+        where ($1 = false or replayed_at_utc is null)
+        order by quarantined_at_utc desc
+        limit $2
+    `
+	rows, err := querier(ctx, r.db).QueryContext(ctx, q, pendingOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []domain.QuarantinedMessage{}
+	for rows.Next() {
+		msg, err := scanQuarantined(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *msg)
+	}
+	return result, rows.Err()
+}
+
+func (r *PgQuarantineRepository) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*domain.QuarantinedMessage, error) {
+	ctx, span := startSpan(ctx, "PgQuarantineRepository.GetByID")
+	defer span.End()
+
+	q := `
+        select ` + quarantineColumns + `
+        from inventory_quarantine
+        where id = $1
+    `
+	msg, err := scanQuarantined(querier(ctx, r.db).QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return msg, err
+}
+
+func (r *PgQuarantineRepository) ClaimReplay(
+	ctx context.Context,
+	id uuid.UUID,
+	at time.Time,
+) (bool, error) {
+	ctx, span := startSpan(ctx, "PgQuarantineRepository.ClaimReplay")
+	defer span.End()
+
+	q := `
+        update inventory_quarantine
+        set replayed_at_utc = $2
+        where id = $1 and replayed_at_utc is null
+    `
+	res, err := querier(ctx, r.db).ExecContext(ctx, q, id, at)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *PgQuarantineRepository) UnclaimReplay(
+	ctx context.Context,
+	id uuid.UUID,
+	at time.Time,
+) error {
+	ctx, span := startSpan(ctx, "PgQuarantineRepository.UnclaimReplay")
+	defer span.End()
+
+	q := `
+        update inventory_quarantine
+        set replayed_at_utc = null
+        where id = $1 and replayed_at_utc = $2
+    `
+	_, err := querier(ctx, r.db).ExecContext(ctx, q, id, at)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanQuarantined(row rowScanner) (*domain.QuarantinedMessage, error) {
+	var msg domain.QuarantinedMessage
+	var envelopeID uuid.NullUUID
+	var replayedAt sql.NullTime
+	if err := row.Scan(
+		&msg.ID,
+		&envelopeID,
+		&msg.EventType,
+		&msg.EnvelopeJSON,
+		&msg.Reason,
+		&msg.Attempts,
+		&msg.Permanent,
+		&msg.QuarantinedAtUtc,
+		&replayedAt,
+	); err != nil {
+		return nil, err
+	}
+	if envelopeID.Valid {
+		msg.EnvelopeID = envelopeID.UUID
+	}
+	if replayedAt.Valid {
+		t := replayedAt.Time
+		msg.ReplayedAtUtc = &t
+	}
+	return &msg, nil
+}
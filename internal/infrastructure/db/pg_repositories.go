@@ -19,10 +19,16 @@ func NewPgStockItemRepository(db *sql.DB) *PgStockItemRepository {
 	return &PgStockItemRepository{db: db}
 }
 
+// GetBySkus dentro de una unidad de trabajo bloquea las filas hasta el
+// commit (en orden de SKU, para no trabarse con otra unidad), así nadie las
+// cambia entre la lectura y el UpsertMany.
 func (r *PgStockItemRepository) GetBySkus(
 	ctx context.Context,
 	skus []string,
 ) (map[string]*domain.StockItem, error) {
+	ctx, span := startSpan(ctx, "PgStockItemRepository.GetBySkus")
+	defer span.End()
+
 	if len(skus) == 0 {
 		return map[string]*domain.StockItem{}, nil
 	}
@@ -32,7 +38,10 @@ func (r *PgStockItemRepository) GetBySkus(
         from inventory_stock_items
         where sku = any($1)
     `
-	rows, err := r.db.QueryContext(ctx, query, skus)
+	if txFrom(ctx, r.db) != nil {
+		query += " order by sku for update"
+	}
+	rows, err := querier(ctx, r.db).QueryContext(ctx, query, skus)
 	if err != nil {
 		return nil, err
 	}
@@ -61,9 +70,23 @@ func (r *PgStockItemRepository) UpsertMany(
 	ctx context.Context,
 	items []*domain.StockItem,
 ) error {
+	ctx, span := startSpan(ctx, "PgStockItemRepository.UpsertMany")
+	defer span.End()
+
 	if len(items) == 0 {
 		return nil
 	}
+	for _, item := range items {
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		if item.UpdatedAtUtc.IsZero() {
+			item.UpdatedAtUtc = time.Now().UTC()
+		}
+	}
+	if len(items) >= copyMinRows {
+		return r.upsertWithCopy(ctx, items)
+	}
 
 	query := `
         insert into inventory_stock_items (id, sku, available_quantity, reserved_quantity, updated_at_utc)
@@ -73,38 +96,42 @@ func (r *PgStockItemRepository) UpsertMany(
             reserved_quantity = excluded.reserved_quantity,
             updated_at_utc = excluded.updated_at_utc
     `
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.PrepareContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	for _, item := range items {
-		if item.ID == uuid.Nil {
-			item.ID = uuid.New()
-		}
-		if item.UpdatedAtUtc.IsZero() {
-			item.UpdatedAtUtc = time.Now().UTC()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
 		}
-		if _, err := stmt.ExecContext(
-			ctx,
-			item.ID,
-			item.Sku,
-			item.Available,
-			item.Reserved,
-			item.UpdatedAtUtc,
-		); err != nil {
+		defer stmt.Close()
+
+		history, err := tx.PrepareContext(ctx, stockHistoryInsert)
+		if err != nil {
 			return err
 		}
-	}
+		defer history.Close()
 
-	return tx.Commit()
+		for _, item := range items {
+			if _, err := stmt.ExecContext(
+				ctx,
+				item.ID,
+				item.Sku,
+				item.Available,
+				item.Reserved,
+				item.UpdatedAtUtc,
+			); err != nil {
+				return err
+			}
+			if _, err := history.ExecContext(
+				ctx,
+				item.Sku,
+				item.Available,
+				item.Reserved,
+				item.UpdatedAtUtc,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Reservations
@@ -121,12 +148,15 @@ func (r *PgStockReservationRepository) GetByOrderID(
 	ctx context.Context,
 	orderID uuid.UUID,
 ) (*domain.StockReservation, error) {
+	ctx, span := startSpan(ctx, "PgStockReservationRepository.GetByOrderID")
+	defer span.End()
+
 	query := `
         select id, order_id, user_id, status, reserved_at_utc, released_at_utc
         from inventory_reservations
         where order_id = $1
     `
-	row := r.db.QueryRowContext(ctx, query, orderID)
+	row := querier(ctx, r.db).QueryRowContext(ctx, query, orderID)
 	var res domain.StockReservation
 	var status string
 	var releasedAt sql.NullTime
@@ -155,7 +185,7 @@ func (r *PgStockReservationRepository) GetByOrderID(
         from inventory_reservation_lines
         where reservation_id = $1
     `
-	rows, err := r.db.QueryContext(ctx, lq, res.ID)
+	rows, err := querier(ctx, r.db).QueryContext(ctx, lq, res.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -178,69 +208,65 @@ func (r *PgStockReservationRepository) Insert(
 	ctx context.Context,
 	res *domain.StockReservation,
 ) error {
+	ctx, span := startSpan(ctx, "PgStockReservationRepository.Insert")
+	defer span.End()
+
 	if res.ID == uuid.Nil {
 		res.ID = uuid.New()
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
 	q := `
         insert into inventory_reservations
         (id, order_id, user_id, status, reserved_at_utc, released_at_utc)
         values ($1,$2,$3,$4,$5,$6)
     `
-	var releasedAt *time.Time
-	if res.ReleasedAtUtc != nil {
-		releasedAt = res.ReleasedAtUtc
-	}
-	if _, err := tx.ExecContext(
-		ctx, q,
-		res.ID,
-		res.OrderID,
-		res.UserID,
-		string(res.Status),
-		res.ReservedAtUtc,
-		releasedAt,
-	); err != nil {
-		return err
-	}
-
 	lq := `
         insert into inventory_reservation_lines
         (id, reservation_id, sku, quantity)
         values ($1,$2,$3,$4)
     `
-	for _, l := range res.Lines {
-		id := l.ID
-		if id == uuid.Nil {
-			id = uuid.New()
-		}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
 		if _, err := tx.ExecContext(
-			ctx, lq,
-			id, res.ID, l.Sku, l.Quantity,
+			ctx, q,
+			res.ID,
+			res.OrderID,
+			res.UserID,
+			string(res.Status),
+			res.ReservedAtUtc,
+			res.ReleasedAtUtc,
 		); err != nil {
 			return err
 		}
-	}
-
-	return tx.Commit()
+		for _, l := range res.Lines {
+			id := l.ID
+			if id == uuid.Nil {
+				id = uuid.New()
+			}
+			if _, err := tx.ExecContext(
+				ctx, lq,
+				id, res.ID, l.Sku, l.Quantity,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *PgStockReservationRepository) Update(
 	ctx context.Context,
 	res *domain.StockReservation,
 ) error {
+	ctx, span := startSpan(ctx, "PgStockReservationRepository.Update")
+	defer span.End()
+
 	q := `
         update inventory_reservations
         set status = $2,
             released_at_utc = $3
         where id = $1
     `
-	_, err := r.db.ExecContext(
+	_, err := querier(ctx, r.db).ExecContext(
 		ctx, q,
 		res.ID,
 		string(res.Status),
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain/repotest"
+)
+
+// testDSNEnv apunta a un Postgres local; sin ella los tests se saltan.
+const testDSNEnv = "INVENTORY_TEST_DB_CONN"
+
+// baseTables replica las tablas que crea el init de la BD (ver
+// schemaStatements), para poder correr en un schema vacío.
+var baseTables = []string{
+	`create table inventory_stock_items (
+        id uuid primary key,
+        sku text not null unique,
+        available_quantity int not null,
+        reserved_quantity int not null,
+        updated_at_utc timestamptz not null
+    )`,
+	`create table inventory_reservations (
+        id uuid primary key,
+        order_id uuid not null unique,
+        user_id uuid not null,
+        status text not null,
+        reserved_at_utc timestamptz not null,
+        released_at_utc timestamptz null
+    )`,
+	`create table inventory_reservation_lines (
+        id uuid primary key,
+        reservation_id uuid not null references inventory_reservations (id),
+        sku text not null,
+        quantity int not null
+    )`,
+	`create table outbox_messages (
+        id uuid primary key,
+        type text not null,
+        payload_json text not null,
+        occurred_at_utc timestamptz not null,
+        retry_count int not null,
+        processed_at_utc timestamptz null
+    )`,
+}
+
+// Cada caso corre en un schema propio que se borra al terminar, así la
+// suite no toca datos de la base apuntada.
+func TestRepositoryContract(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping Postgres contract tests", testDSNEnv)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		conn := openTestSchema(t, dsn)
+		return repotest.Repos{
+			Stock:        NewPgStockItemRepository(conn),
+			Reservations: NewPgStockReservationRepository(conn),
+			Outbox:       NewPgOutboxRepository(conn),
+			Quarantine:   NewPgQuarantineRepository(conn),
+			Events:       NewPgEventStore(conn),
+			History:      NewPgStockHistoryRepository(conn),
+			Export:       NewPgStockExportRepository(conn),
+			UnitOfWork:   NewPgUnitOfWork(conn),
+		}
+	})
+}
+
+// El backfill del historial corre una vez por base: un reinicio no vuelve
+// a copiar la fila actual de cada SKU.
+func TestSchemaMigrationsRunOnce(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping Postgres contract tests", testDSNEnv)
+	}
+	ctx := context.Background()
+	conn := openTestSchema(t, dsn)
+
+	historyRows := func() int {
+		t.Helper()
+		var n int
+		if err := conn.QueryRowContext(ctx, `select count(*) from inventory_stock_history`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	insertItem := func(sku string, at time.Time) {
+		t.Helper()
+		if _, err := conn.ExecContext(ctx, `
+            insert into inventory_stock_items (id, sku, available_quantity, reserved_quantity, updated_at_utc)
+            values ($1, $2, 5, 0, $3)`, uuid.New(), sku, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	insertItem("A", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err := EnsureSchema(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	if n := historyRows(); n != 0 {
+		t.Fatalf("restart backfilled %d history rows, want 0", n)
+	}
+
+	// Una base que todavía no corrió la migración sí recibe el backfill.
+	if _, err := conn.ExecContext(ctx, `delete from inventory_schema_migrations`); err != nil {
+		t.Fatal(err)
+	}
+	insertItem("B", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err := EnsureSchema(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	if n := historyRows(); n != 2 {
+		t.Fatalf("first run backfilled %d history rows, want 2", n)
+	}
+}
+
+func openTestSchema(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	schema := "repotest_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	if _, err := admin.ExecContext(ctx, "create schema "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.ExecContext(context.Background(), "drop schema "+schema+" cascade"); err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.RuntimeParams["search_path"] = schema
+	conn := stdlib.OpenDB(*cfg)
+	t.Cleanup(func() { conn.Close() })
+
+	for _, stmt := range baseTables {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := EnsureSchema(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
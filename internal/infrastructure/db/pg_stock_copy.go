@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// copyMinRows es el tamaño de lote desde el que UpsertMany carga los items
+// con COPY en vez de un insert por fila (ej. chunks de import).
+const copyMinRows = 500
+
+// upsertWithCopy copia los items a una tabla temporal y desde ahí hace el
+// upsert de inventory_stock_items y del historial, en una transacción (la de
+// la unidad de trabajo de ctx, si hay una). Si un SKU viene repetido gana el
+// último, como en el insert por fila.
+func (r *PgStockItemRepository) upsertWithCopy(ctx context.Context, items []*domain.StockItem) error {
+	ctx, span := startSpan(ctx, "PgStockItemRepository.upsertWithCopy")
+	defer span.End()
+
+	if t := txFrom(ctx, r.db); t != nil {
+		return t.conn.Raw(func(driverConn any) error {
+			conn, err := pgxConn(driverConn)
+			if err != nil {
+				return err
+			}
+			return copyUpsert(ctx, conn, items)
+		})
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pc, err := pgxConn(driverConn)
+		if err != nil {
+			return err
+		}
+		tx, err := pc.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if err := copyUpsert(ctx, tx, items); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	})
+}
+
+func pgxConn(driverConn any) (*pgx.Conn, error) {
+	sc, ok := driverConn.(*stdlib.Conn)
+	if !ok {
+		return nil, fmt.Errorf("COPY needs the pgx driver, got %T", driverConn)
+	}
+	return sc.Conn(), nil
+}
+
+// copier es lo común a *pgx.Conn y pgx.Tx.
+type copier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error)
+}
+
+// copyUpsert corre dentro de una transacción abierta. Borra la tabla
+// temporal al terminar porque la misma transacción puede volver a usarla.
+func copyUpsert(ctx context.Context, c copier, items []*domain.StockItem) error {
+	if _, err := c.Exec(ctx, `
+        create temp table inventory_stock_copy (
+            ord int not null,
+            id uuid not null,
+            sku text not null,
+            available_quantity int not null,
+            reserved_quantity int not null,
+            updated_at_utc timestamptz not null
+        ) on commit drop
+    `); err != nil {
+		return err
+	}
+
+	if _, err := c.CopyFrom(
+		ctx,
+		pgx.Identifier{"inventory_stock_copy"},
+		[]string{"ord", "id", "sku", "available_quantity", "reserved_quantity", "updated_at_utc"},
+		pgx.CopyFromSlice(len(items), func(i int) ([]any, error) {
+			item := items[i]
+			return []any{i, item.ID, item.Sku, item.Available, item.Reserved, item.UpdatedAtUtc}, nil
+		}),
+	); err != nil {
+		return err
+	}
+
+	if _, err := c.Exec(ctx, `
+        insert into inventory_stock_items (id, sku, available_quantity, reserved_quantity, updated_at_utc)
+        select distinct on (sku) id, sku, available_quantity, reserved_quantity, updated_at_utc
+        from inventory_stock_copy
+        order by sku, ord desc
+        on conflict (sku) do update
+        set available_quantity = excluded.available_quantity,
+            reserved_quantity = excluded.reserved_quantity,
+            updated_at_utc = excluded.updated_at_utc
+    `); err != nil {
+		return err
+	}
+	if _, err := c.Exec(ctx, `
+        insert into inventory_stock_history (sku, available_quantity, reserved_quantity, changed_at_utc)
+        select distinct on (sku, updated_at_utc) sku, available_quantity, reserved_quantity, updated_at_utc
+        from inventory_stock_copy
+        order by sku, updated_at_utc, ord desc
+        on conflict (sku, changed_at_utc) do update
+        set available_quantity = excluded.available_quantity,
+            reserved_quantity = excluded.reserved_quantity
+    `); err != nil {
+		return err
+	}
+	_, err := c.Exec(ctx, `drop table inventory_stock_copy`)
+	return err
+}
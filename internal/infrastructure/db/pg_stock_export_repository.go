@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// PgStockExportRepository pagina inventory_stock_items y las reservaciones
+// activas por su clave única (sku y order_id).
+type PgStockExportRepository struct {
+	db *sql.DB
+}
+
+func NewPgStockExportRepository(db *sql.DB) *PgStockExportRepository {
+	return &PgStockExportRepository{db: db}
+}
+
+func (r *PgStockExportRepository) StockItemsAfter(
+	ctx context.Context,
+	after string,
+	limit int,
+) ([]*domain.StockItem, error) {
+	ctx, span := startSpan(ctx, "PgStockExportRepository.StockItemsAfter")
+	defer span.End()
+
+	query := `
+        select id, sku, available_quantity, reserved_quantity, updated_at_utc
+        from inventory_stock_items
+        where sku > $1
+        order by sku
+        limit $2
+    `
+	rows, err := r.db.QueryContext(ctx, query, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*domain.StockItem{}
+	for rows.Next() {
+		var item domain.StockItem
+		if err := rows.Scan(
+			&item.ID,
+			&item.Sku,
+			&item.Available,
+			&item.Reserved,
+			&item.UpdatedAtUtc,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+// ActiveReservationsAfter trae la página de reservaciones y sus líneas en
+// una sola consulta; el limit aplica a reservaciones, no a líneas.
+func (r *PgStockExportRepository) ActiveReservationsAfter(
+	ctx context.Context,
+	after uuid.UUID,
+	limit int,
+) ([]*domain.StockReservation, error) {
+	ctx, span := startSpan(ctx, "PgStockExportRepository.ActiveReservationsAfter")
+	defer span.End()
+
+	query := `
+        select r.id, r.order_id, r.user_id, r.reserved_at_utc, l.id, l.sku, l.quantity
+        from (
+            select id, order_id, user_id, reserved_at_utc
+            from inventory_reservations
+            where status = $1 and order_id > $2
+            order by order_id
+            limit $3
+        ) r
+        left join inventory_reservation_lines l on l.reservation_id = r.id
+        order by r.order_id, l.sku
+    `
+	rows, err := r.db.QueryContext(ctx, query, string(domain.ReservationActive), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*domain.StockReservation{}
+	var current *domain.StockReservation
+	for rows.Next() {
+		var res domain.StockReservation
+		var lineID uuid.NullUUID
+		var sku sql.NullString
+		var qty sql.NullInt64
+		if err := rows.Scan(
+			&res.ID,
+			&res.OrderID,
+			&res.UserID,
+			&res.ReservedAtUtc,
+			&lineID,
+			&sku,
+			&qty,
+		); err != nil {
+			return nil, err
+		}
+		if current == nil || current.ID != res.ID {
+			res.Status = domain.ReservationActive
+			res.Lines = []domain.ReservationLine{}
+			current = &res
+			out = append(out, current)
+		}
+		if lineID.Valid {
+			current.Lines = append(current.Lines, domain.ReservationLine{
+				ID:            lineID.UUID,
+				ReservationID: current.ID,
+				Sku:           sku.String,
+				Quantity:      int(qty.Int64),
+			})
+		}
+	}
+	return out, rows.Err()
+}
+
+var _ domain.StockExportRepository = (*PgStockExportRepository)(nil)
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// stockHistoryInsert agrega un nivel al historial en la misma transacción
+// que PgStockItemRepository.UpsertMany. Dos escrituras con la misma hora
+// dejan la última, así reescribir un item (ej. rebuild-projections) no
+// falla.
+const stockHistoryInsert = `
+    insert into inventory_stock_history (sku, available_quantity, reserved_quantity, changed_at_utc)
+    values ($1,$2,$3,$4)
+    on conflict (sku, changed_at_utc) do update
+    set available_quantity = excluded.available_quantity,
+        reserved_quantity = excluded.reserved_quantity
+`
+
+// PgStockHistoryRepository lee inventory_stock_history.
+type PgStockHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewPgStockHistoryRepository(db *sql.DB) *PgStockHistoryRepository {
+	return &PgStockHistoryRepository{db: db}
+}
+
+func (r *PgStockHistoryRepository) AsOf(
+	ctx context.Context,
+	skus []string,
+	at time.Time,
+) (map[string]domain.StockLevel, error) {
+	ctx, span := startSpan(ctx, "PgStockHistoryRepository.AsOf")
+	defer span.End()
+
+	if len(skus) == 0 {
+		return map[string]domain.StockLevel{}, nil
+	}
+
+	query := `
+        select distinct on (sku) sku, available_quantity, reserved_quantity, changed_at_utc
+        from inventory_stock_history
+        where sku = any($1) and changed_at_utc <= $2
+        order by sku, changed_at_utc desc
+    `
+	levels, err := r.query(ctx, query, skus, at)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]domain.StockLevel, len(levels))
+	for _, level := range levels {
+		result[level.Sku] = level
+	}
+	return result, nil
+}
+
+func (r *PgStockHistoryRepository) ReportAsOf(
+	ctx context.Context,
+	at time.Time,
+	after string,
+	limit int,
+) ([]domain.StockLevel, error) {
+	ctx, span := startSpan(ctx, "PgStockHistoryRepository.ReportAsOf")
+	defer span.End()
+
+	query := `
+        select distinct on (sku) sku, available_quantity, reserved_quantity, changed_at_utc
+        from inventory_stock_history
+        where sku > $1 and changed_at_utc <= $2
+        order by sku, changed_at_utc desc
+        limit $3
+    `
+	return r.query(ctx, query, after, at, limit)
+}
+
+func (r *PgStockHistoryRepository) query(ctx context.Context, query string, args ...any) ([]domain.StockLevel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []domain.StockLevel{}
+	for rows.Next() {
+		var level domain.StockLevel
+		if err := rows.Scan(
+			&level.Sku,
+			&level.Available,
+			&level.Reserved,
+			&level.ChangedAtUtc,
+		); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, rows.Err()
+}
+
+var _ domain.StockHistoryRepository = (*PgStockHistoryRepository)(nil)
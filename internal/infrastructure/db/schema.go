@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// schemaStatements crea las tablas propias de features nuevas. Las tablas
+// base (stock, reservaciones, outbox) las sigue creando el init de la BD.
+var schemaStatements = []string{
+	`alter table outbox_messages add column if not exists trace_context text null`,
+	`create table if not exists inventory_quarantine (
+        id uuid primary key,
+        envelope_id uuid null,
+        event_type text not null,
+        envelope_json text not null,
+        reason text not null,
+        attempts int not null,
+        permanent boolean not null,
+        quarantined_at_utc timestamptz not null,
+        replayed_at_utc timestamptz null
+    )`,
+	`create index if not exists ix_inventory_quarantine_quarantined_at
+        on inventory_quarantine (quarantined_at_utc desc)`,
+	`create table if not exists inventory_events (
+        position bigserial primary key,
+        stream_id text not null,
+        version bigint not null,
+        event_type text not null,
+        data jsonb not null,
+        occurred_at_utc timestamptz not null,
+        unique (stream_id, version)
+    )`,
+	`create table if not exists inventory_snapshots (
+        stream_id text primary key,
+        version bigint not null,
+        data jsonb not null,
+        taken_at_utc timestamptz not null
+    )`,
+	`create table if not exists inventory_stock_history (
+        sku text not null,
+        available_quantity int not null,
+        reserved_quantity int not null,
+        changed_at_utc timestamptz not null,
+        primary key (sku, changed_at_utc)
+    )`,
+	`create table if not exists inventory_schema_migrations (
+        name text primary key,
+        applied_at_utc timestamptz not null
+    )`,
+}
+
+// migration es un cambio de datos que corre una sola vez por base; su
+// nombre queda en inventory_schema_migrations.
+type migration struct {
+	name string
+	stmt string
+}
+
+// schemaMigrations corren después de schemaStatements, en orden. No se
+// renombran ni se editan una vez publicadas.
+var schemaMigrations = []migration{
+	// Los SKUs escritos antes de existir el historial arrancan con su fila
+	// actual; no se sabe qué tenían antes de updated_at_utc.
+	{
+		name: "0001_stock_history_backfill",
+		stmt: `insert into inventory_stock_history (sku, available_quantity, reserved_quantity, changed_at_utc)
+            select sku, available_quantity, reserved_quantity, updated_at_utc
+            from inventory_stock_items
+            on conflict do nothing`,
+	},
+}
+
+// EnsureSchema aplica los DDL idempotentes y las migraciones pendientes al
+// arrancar.
+func EnsureSchema(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range schemaStatements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	for _, m := range schemaMigrations {
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration registra m y la corre en la misma transacción. Si otra
+// instancia arranca a la vez, su insert espera a ésta y no encuentra nada
+// que hacer.
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        insert into inventory_schema_migrations (name, applied_at_utc)
+        values ($1, now())
+        on conflict (name) do nothing`, m.name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, m.stmt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
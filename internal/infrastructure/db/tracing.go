@@ -0,0 +1,18 @@
+package db
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/tracing"
+)
+
+// startSpan abre un span de cliente para una llamada a Postgres.
+func startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "postgresql")),
+	)
+}
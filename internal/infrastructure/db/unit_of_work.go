@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// txKey guarda en el ctx la transacción de la unidad de trabajo abierta
+// sobre db.
+type txKey struct{ db *sql.DB }
+
+type pgTx struct {
+	conn *sql.Conn // COPY necesita la conexión pgx de la transacción
+	tx   *sql.Tx
+}
+
+// PgUnitOfWork abre una transacción por unidad; los repositorios Pg* que
+// reciben su ctx ejecutan dentro de ella.
+type PgUnitOfWork struct {
+	db *sql.DB
+}
+
+func NewPgUnitOfWork(db *sql.DB) *PgUnitOfWork {
+	return &PgUnitOfWork{db: db}
+}
+
+func (u *PgUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFrom(ctx, u.db) != nil {
+		return fn(ctx)
+	}
+
+	conn, err := u.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ctx, hooks := domain.WithCommitHooks(context.WithValue(ctx, txKey{u.db}, &pgTx{conn: conn, tx: tx}))
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	hooks.Run()
+	return nil
+}
+
+func txFrom(ctx context.Context, db *sql.DB) *pgTx {
+	t, _ := ctx.Value(txKey{db}).(*pgTx)
+	return t
+}
+
+// dbtx es lo que los repositorios usan de *sql.DB y *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// querier devuelve la transacción de la unidad de trabajo de ctx, o db si
+// no hay una.
+func querier(ctx context.Context, db *sql.DB) dbtx {
+	if t := txFrom(ctx, db); t != nil {
+		return t.tx
+	}
+	return db
+}
+
+// inTx corre fn en la transacción de la unidad de trabajo de ctx o, si no
+// hay una, en una propia que confirma al terminar.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if t := txFrom(ctx, db); t != nil {
+		return fn(t.tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,163 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// stockEventData es el JSON de un StockItemEvent; cada tipo llena solo sus
+// campos (ver domain.StockItemEvent).
+type stockEventData struct {
+	ID        *uuid.UUID `json:"id,omitempty"`
+	Sku       string     `json:"sku,omitempty"`
+	Quantity  int        `json:"quantity,omitempty"`
+	Available *int       `json:"available,omitempty"`
+	Reserved  *int       `json:"reserved,omitempty"`
+}
+
+func encodeStockEvent(ev domain.StockItemEvent) (domain.StoredEvent, error) {
+	var data stockEventData
+	switch ev.Type {
+	case domain.EventStockItemCreated:
+		data = stockEventData{ID: &ev.ID, Sku: ev.Sku, Available: &ev.Available, Reserved: &ev.Reserved}
+	case domain.EventStockItemReserved, domain.EventStockItemReleased:
+		data = stockEventData{Quantity: ev.Quantity}
+	case domain.EventStockItemAvailableSet:
+		data = stockEventData{Available: &ev.Available}
+	case domain.EventStockItemLevelsSet:
+		data = stockEventData{Available: &ev.Available, Reserved: &ev.Reserved}
+	default:
+		return domain.StoredEvent{}, fmt.Errorf("unknown stock event type %q", ev.Type)
+	}
+	return stored(ev.Type, ev.OccurredAtUtc, data)
+}
+
+func decodeStockEvent(st domain.StoredEvent) (domain.StockItemEvent, error) {
+	var data stockEventData
+	if err := json.Unmarshal([]byte(st.Data), &data); err != nil {
+		return domain.StockItemEvent{}, fmt.Errorf("decode %s v%d: %w", st.StreamID, st.Version, err)
+	}
+	ev := domain.StockItemEvent{
+		Type:          st.Type,
+		OccurredAtUtc: st.OccurredAtUtc,
+		Sku:           data.Sku,
+		Quantity:      data.Quantity,
+	}
+	if data.ID != nil {
+		ev.ID = *data.ID
+	}
+	if data.Available != nil {
+		ev.Available = *data.Available
+	}
+	if data.Reserved != nil {
+		ev.Reserved = *data.Reserved
+	}
+	return ev, nil
+}
+
+// stockSnapshot es el JSON del snapshot de un stream de stock.
+type stockSnapshot struct {
+	ID           uuid.UUID `json:"id"`
+	Sku          string    `json:"sku"`
+	Available    int       `json:"available"`
+	Reserved     int       `json:"reserved"`
+	UpdatedAtUtc time.Time `json:"updatedAtUtc"`
+}
+
+func encodeStockSnapshot(item *domain.StockItem, at time.Time) (domain.Snapshot, error) {
+	body, err := json.Marshal(stockSnapshot{
+		ID:           item.ID,
+		Sku:          item.Sku,
+		Available:    item.Available,
+		Reserved:     item.Reserved,
+		UpdatedAtUtc: item.UpdatedAtUtc,
+	})
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+	return domain.Snapshot{
+		StreamID:   domain.StockStreamID(item.Sku),
+		Version:    item.Version,
+		Data:       string(body),
+		TakenAtUtc: at,
+	}, nil
+}
+
+func decodeStockSnapshot(snap domain.Snapshot) (*domain.StockItem, error) {
+	var data stockSnapshot
+	if err := json.Unmarshal([]byte(snap.Data), &data); err != nil {
+		return nil, fmt.Errorf("decode snapshot of %s: %w", snap.StreamID, err)
+	}
+	return &domain.StockItem{
+		ID:           data.ID,
+		Sku:          data.Sku,
+		Available:    data.Available,
+		Reserved:     data.Reserved,
+		UpdatedAtUtc: data.UpdatedAtUtc,
+		Version:      snap.Version,
+	}, nil
+}
+
+type reservationLineData struct {
+	ID       uuid.UUID `json:"id"`
+	Sku      string    `json:"sku"`
+	Quantity int       `json:"quantity"`
+}
+
+// reservationEventData es el JSON de un StockReservationEvent; Released no
+// lleva datos.
+type reservationEventData struct {
+	ID      *uuid.UUID            `json:"id,omitempty"`
+	OrderID *uuid.UUID            `json:"orderId,omitempty"`
+	UserID  *uuid.UUID            `json:"userId,omitempty"`
+	Lines   []reservationLineData `json:"lines,omitempty"`
+}
+
+func encodeReservationEvent(ev domain.StockReservationEvent) (domain.StoredEvent, error) {
+	var data reservationEventData
+	switch ev.Type {
+	case domain.EventReservationPlaced:
+		data = reservationEventData{ID: &ev.ID, OrderID: &ev.OrderID, UserID: &ev.UserID}
+		for _, l := range ev.Lines {
+			data.Lines = append(data.Lines, reservationLineData{ID: l.ID, Sku: l.Sku, Quantity: l.Quantity})
+		}
+	case domain.EventReservationReleased:
+	default:
+		return domain.StoredEvent{}, fmt.Errorf("unknown reservation event type %q", ev.Type)
+	}
+	return stored(ev.Type, ev.OccurredAtUtc, data)
+}
+
+func decodeReservationEvent(st domain.StoredEvent) (domain.StockReservationEvent, error) {
+	var data reservationEventData
+	if err := json.Unmarshal([]byte(st.Data), &data); err != nil {
+		return domain.StockReservationEvent{}, fmt.Errorf("decode %s v%d: %w", st.StreamID, st.Version, err)
+	}
+	ev := domain.StockReservationEvent{Type: st.Type, OccurredAtUtc: st.OccurredAtUtc}
+	if data.ID != nil {
+		ev.ID = *data.ID
+	}
+	if data.OrderID != nil {
+		ev.OrderID = *data.OrderID
+	}
+	if data.UserID != nil {
+		ev.UserID = *data.UserID
+	}
+	for _, l := range data.Lines {
+		ev.Lines = append(ev.Lines, domain.ReservationLine{ID: l.ID, Sku: l.Sku, Quantity: l.Quantity})
+	}
+	return ev, nil
+}
+
+func stored(eventType string, at time.Time, data any) (domain.StoredEvent, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return domain.StoredEvent{}, err
+	}
+	return domain.StoredEvent{Type: eventType, Data: string(body), OccurredAtUtc: at}, nil
+}
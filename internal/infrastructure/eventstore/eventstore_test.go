@@ -0,0 +1,300 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain/repotest"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fixture son los repos event-sourced sobre un event store en memoria, con
+// las proyecciones en otro store para poder borrarlas.
+type fixture struct {
+	events      *memory.EventStore
+	eventsStore *memory.Store
+	projections *memory.Store
+	stock       *StockItemRepository
+	reservation *StockReservationRepository
+}
+
+func newFixture(snapshotEvery int) *fixture {
+	eventsStore := memory.NewStore()
+	events := memory.NewEventStore(eventsStore)
+	projections := memory.NewStore()
+	return &fixture{
+		events:      events,
+		eventsStore: eventsStore,
+		projections: projections,
+		stock:       NewStockItemRepository(events, memory.NewStockItemRepository(projections), snapshotEvery),
+		reservation: NewStockReservationRepository(events, memory.NewStockReservationRepository(projections)),
+	}
+}
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		f := newFixture(3)
+		return repotest.Repos{
+			Stock:        f.stock,
+			Reservations: f.reservation,
+			Outbox:       memory.NewOutboxRepository(f.projections),
+			Quarantine:   memory.NewQuarantineRepository(f.projections),
+			History:      memory.NewStockHistoryRepository(f.projections),
+			Export:       memory.NewStockExportRepository(f.projections),
+			UnitOfWork:   memory.NewUnitOfWork(f.eventsStore, f.projections),
+		}
+	})
+}
+
+func (f *fixture) get(t *testing.T, sku string) *domain.StockItem {
+	t.Helper()
+	items, err := f.stock.GetBySkus(context.Background(), []string{sku})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items[sku]
+}
+
+func (f *fixture) save(t *testing.T, items ...*domain.StockItem) {
+	t.Helper()
+	if err := f.stock.UpsertMany(context.Background(), items); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fixture) eventTypes(t *testing.T, stream string) []string {
+	t.Helper()
+	stored, err := f.events.Load(context.Background(), stream, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, ev := range stored {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestStockOperationsAreStoredAsEvents(t *testing.T) {
+	f := newFixture(0)
+	f.save(t, newItem(t, uuid.New(), "A", 10, t0))
+
+	item := f.get(t, "A")
+	must(t, item.Reserve(4, t0.Add(time.Minute)))
+	must(t, item.Reserve(1, t0.Add(2*time.Minute)))
+	f.save(t, item)
+
+	item = f.get(t, "A")
+	must(t, item.Release(4, t0.Add(3*time.Minute)))
+	must(t, item.SetAvailable(20, t0.Add(4*time.Minute)))
+	f.save(t, item)
+
+	want := []string{
+		domain.EventStockItemCreated,
+		domain.EventStockItemReserved, domain.EventStockItemReserved,
+		domain.EventStockItemReleased, domain.EventStockItemAvailableSet,
+	}
+	if got := f.eventTypes(t, "stock-A"); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	got := f.get(t, "A")
+	if got.Available != 20 || got.Reserved != 1 || got.Version != 5 || !got.UpdatedAtUtc.Equal(t0.Add(4*time.Minute)) {
+		t.Errorf("rehydrated = %+v", got)
+	}
+	projected := f.projections.StockItems()
+	if len(projected) != 1 || projected[0].Available != 20 || projected[0].Reserved != 1 {
+		t.Errorf("projection = %+v", projected)
+	}
+}
+
+func TestStaleItemConflicts(t *testing.T) {
+	f := newFixture(0)
+	f.save(t, newItem(t, uuid.New(), "A", 10, t0))
+
+	first, second := f.get(t, "A"), f.get(t, "A")
+	must(t, first.Reserve(6, t0))
+	f.save(t, first)
+
+	must(t, second.Reserve(6, t0))
+	err := f.stock.UpsertMany(context.Background(), []*domain.StockItem{second})
+	if !errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("err = %v, want ErrConcurrencyConflict", err)
+	}
+	if got := f.get(t, "A"); got.Available != 4 || got.Reserved != 6 {
+		t.Errorf("after conflict = %+v, want 4/6", got)
+	}
+}
+
+func TestSnapshotsShortenTheReplay(t *testing.T) {
+	f := newFixture(3)
+	f.save(t, newItem(t, uuid.New(), "A", 100, t0))
+	for i := 1; i <= 7; i++ {
+		item := f.get(t, "A")
+		must(t, item.Reserve(1, t0.Add(time.Duration(i)*time.Minute)))
+		f.save(t, item)
+	}
+
+	snap, err := f.events.LoadSnapshot(context.Background(), "stock-A")
+	must(t, err)
+	if snap == nil || snap.Version != 6 {
+		t.Fatalf("snapshot = %+v, want version 6", snap)
+	}
+
+	// Con snapshot o sin él (replay completo) el estado es el mismo.
+	withSnapshot := f.get(t, "A")
+	stored, err := f.events.Load(context.Background(), "stock-A", 0)
+	must(t, err)
+	var evs []domain.StockItemEvent
+	for _, st := range stored {
+		ev, err := decodeStockEvent(st)
+		must(t, err)
+		evs = append(evs, ev)
+	}
+	replayed := domain.RehydrateStockItem(nil, evs)
+	if withSnapshot.Available != 93 || withSnapshot.Reserved != 7 || withSnapshot.Version != 8 ||
+		replayed.Available != withSnapshot.Available || replayed.Reserved != withSnapshot.Reserved ||
+		!replayed.UpdatedAtUtc.Equal(withSnapshot.UpdatedAtUtc) || replayed.ID != withSnapshot.ID {
+		t.Errorf("with snapshot = %+v, full replay = %+v", withSnapshot, replayed)
+	}
+}
+
+func TestLegacyRowsMigrateOnFirstWrite(t *testing.T) {
+	f := newFixture(0)
+	legacyID := uuid.New()
+	legacy := memory.NewStockItemRepository(f.projections)
+	must(t, legacy.UpsertMany(context.Background(), []*domain.StockItem{
+		{ID: legacyID, Sku: "OLD", Available: 5, Reserved: 2, UpdatedAtUtc: t0},
+	}))
+
+	item := f.get(t, "OLD")
+	if item == nil || item.Available != 5 || item.Version != 0 {
+		t.Fatalf("legacy item = %+v", item)
+	}
+	must(t, item.Reserve(1, t0.Add(time.Minute)))
+	f.save(t, item)
+
+	if got := f.eventTypes(t, "stock-OLD"); !slices.Equal(got, []string{domain.EventStockItemCreated}) {
+		t.Errorf("events = %v, want a single Created with the full state", got)
+	}
+	got := f.get(t, "OLD")
+	if got.ID != legacyID || got.Available != 4 || got.Reserved != 3 || got.Version != 1 {
+		t.Errorf("migrated = %+v", got)
+	}
+}
+
+func TestReservationStream(t *testing.T) {
+	f := newFixture(0)
+	ctx := context.Background()
+	res := domain.NewStockReservation(uuid.New(), uuid.New(), uuid.New(),
+		[]domain.ReservationLine{{ID: uuid.New(), Sku: "A", Quantity: 2}}, t0)
+	must(t, f.reservation.Insert(ctx, res))
+	res.MarkReleased(t0.Add(time.Hour))
+	must(t, f.reservation.Update(ctx, res))
+	must(t, f.reservation.Update(ctx, res)) // sin cambios no agrega eventos
+
+	stream := domain.ReservationStreamID(res.OrderID)
+	want := []string{domain.EventReservationPlaced, domain.EventReservationReleased}
+	if got := f.eventTypes(t, stream); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	got, err := f.reservation.GetByOrderID(ctx, res.OrderID)
+	must(t, err)
+	if got.Status != domain.ReservationReleased || !got.ReleasedAtUtc.Equal(t0.Add(time.Hour)) || len(got.Lines) != 1 {
+		t.Errorf("rehydrated = %+v", got)
+	}
+}
+
+func TestRebuildRestoresProjections(t *testing.T) {
+	f := newFixture(2)
+	ctx := context.Background()
+	f.save(t, newItem(t, uuid.New(), "A", 10, t0), newItem(t, uuid.New(), "B", 3, t0))
+	for i := 0; i < 3; i++ {
+		item := f.get(t, "A")
+		must(t, item.Reserve(2, t0.Add(time.Duration(i)*time.Minute)))
+		f.save(t, item)
+	}
+	res := domain.NewStockReservation(uuid.New(), uuid.New(), uuid.New(),
+		[]domain.ReservationLine{{Sku: "A", Quantity: 6}}, t0)
+	must(t, f.reservation.Insert(ctx, res))
+	res.MarkReleased(t0.Add(time.Hour))
+	must(t, f.reservation.Update(ctx, res))
+
+	fresh := memory.NewStore()
+	stats, err := Rebuild(ctx, f.events,
+		memory.NewStockItemRepository(fresh), memory.NewStockReservationRepository(fresh), 1)
+	must(t, err)
+	if stats.StockItems != 2 || stats.Reservations != 1 {
+		t.Errorf("stats = %+v, want 2 items and 1 reservation", stats)
+	}
+
+	want, got := f.projections.StockItems(), fresh.StockItems()
+	if len(got) != len(want) {
+		t.Fatalf("rebuilt = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Available != want[i].Available || got[i].Reserved != want[i].Reserved {
+			t.Errorf("rebuilt[%s] = %+v, want %+v", want[i].Sku, got[i], want[i])
+		}
+	}
+	rebuilt, err := memory.NewStockReservationRepository(fresh).GetByOrderID(ctx, res.OrderID)
+	must(t, err)
+	if rebuilt == nil || rebuilt.Status != domain.ReservationReleased || rebuilt.ID != res.ID {
+		t.Errorf("rebuilt reservation = %+v", rebuilt)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newItem(t *testing.T, id uuid.UUID, sku string, available int, now time.Time) *domain.StockItem {
+	t.Helper()
+	item, err := domain.NewStockItem(id, sku, available, now)
+	must(t, err)
+	return item
+}
+
+func TestServicesOverEventStore(t *testing.T) {
+	f := newFixture(2)
+	ctx := context.Background()
+	clock, ids := domain.NewManualClock(t0, time.Second), domain.NewSequentialIDGenerator(t.Name())
+	writer := application.NewOutboxWriter(memory.NewOutboxRepository(f.projections), clock, ids)
+	uow := memory.NewUnitOfWork(f.eventsStore, f.projections)
+	reserve := application.NewReserveStockService(uow, f.stock, f.reservation, writer, clock, ids)
+	release := application.NewReleaseReservationService(uow, f.stock, f.reservation, writer, clock, ids)
+
+	f.save(t, newItem(t, ids.NewID(), "A", 10, clock.Now()))
+	orderID := ids.NewID()
+	_, err := reserve.Reserve(ctx, domain.OrderPlacedPayload{
+		OrderID: orderID,
+		Lines:   []domain.OrderPlacedLine{{Sku: "A", Quantity: 3}, {Sku: "A", Quantity: 2}},
+	})
+	must(t, err)
+	_, err = release.Release(ctx, orderID)
+	must(t, err)
+
+	want := []string{
+		domain.EventStockItemCreated,
+		domain.EventStockItemReserved, domain.EventStockItemReserved,
+		domain.EventStockItemReleased, domain.EventStockItemReleased,
+	}
+	if got := f.eventTypes(t, "stock-A"); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if got := f.projections.StockItems(); len(got) != 1 || got[0].Available != 10 || got[0].Reserved != 0 {
+		t.Errorf("projection = %+v, want A 10/0", got)
+	}
+}
@@ -0,0 +1,91 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// RebuildStats cuenta las filas que reescribió Rebuild.
+type RebuildStats struct {
+	StockItems   int
+	Reservations int
+}
+
+// Rebuild reescribe las proyecciones (inventory_stock_items y las tablas de
+// reservaciones) desde los streams del event store, de batchSize SKUs a la
+// vez. Las filas sin stream (anteriores al modo event-sourced) no se tocan.
+func Rebuild(
+	ctx context.Context,
+	events domain.EventStore,
+	stock domain.StockItemRepository,
+	reservations domain.StockReservationRepository,
+	batchSize int,
+) (RebuildStats, error) {
+	var stats RebuildStats
+
+	streams, err := events.StreamIDs(ctx, domain.StockStreamPrefix)
+	if err != nil {
+		return stats, err
+	}
+	source := NewStockItemRepository(events, nil, 0)
+	for start := 0; start < len(streams); start += batchSize {
+		end := min(start+batchSize, len(streams))
+		skus := make([]string, 0, end-start)
+		for _, stream := range streams[start:end] {
+			skus = append(skus, strings.TrimPrefix(stream, domain.StockStreamPrefix))
+		}
+		items, err := source.GetBySkus(ctx, skus)
+		if err != nil {
+			return stats, err
+		}
+		batch := make([]*domain.StockItem, 0, len(items))
+		for _, sku := range skus {
+			if item, ok := items[sku]; ok {
+				batch = append(batch, item)
+			}
+		}
+		if err := stock.UpsertMany(ctx, batch); err != nil {
+			return stats, fmt.Errorf("rebuild stock items: %w", err)
+		}
+		stats.StockItems += len(batch)
+	}
+
+	streams, err = events.StreamIDs(ctx, domain.ReservationStreamPrefix)
+	if err != nil {
+		return stats, err
+	}
+	resSource := NewStockReservationRepository(events, nil)
+	for _, stream := range streams {
+		orderID, err := uuid.Parse(strings.TrimPrefix(stream, domain.ReservationStreamPrefix))
+		if err != nil {
+			return stats, fmt.Errorf("stream %s: %w", stream, err)
+		}
+		res, err := resSource.GetByOrderID(ctx, orderID)
+		if err != nil {
+			return stats, err
+		}
+		if res == nil {
+			continue
+		}
+		existing, err := reservations.GetByOrderID(ctx, orderID)
+		if err != nil {
+			return stats, err
+		}
+		if existing == nil {
+			err = reservations.Insert(ctx, res)
+		} else {
+			res.ID = existing.ID // Update busca por id
+			err = reservations.Update(ctx, res)
+		}
+		if err != nil {
+			return stats, fmt.Errorf("rebuild reservation of order %s: %w", orderID, err)
+		}
+		stats.Reservations++
+	}
+	return stats, nil
+}
@@ -0,0 +1,191 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+)
+
+// StockReservationRepository guarda cada reservación en el stream
+// reservation-<orderId>: StockReservationPlaced al insertarla y
+// StockReservationReleased al liberarla.
+type StockReservationRepository struct {
+	events     domain.EventStore
+	projection domain.StockReservationRepository
+}
+
+// projection puede ser nil (ej. en Rebuild).
+func NewStockReservationRepository(
+	events domain.EventStore,
+	projection domain.StockReservationRepository,
+) *StockReservationRepository {
+	return &StockReservationRepository{events: events, projection: projection}
+}
+
+// GetByOrderID devuelve nil si no existe. Como en el stock, las
+// reservaciones anteriores al modo event-sourced se leen de la proyección.
+func (r *StockReservationRepository) GetByOrderID(
+	ctx context.Context,
+	orderID uuid.UUID,
+) (*domain.StockReservation, error) {
+	res, _, err := r.load(ctx, orderID)
+	if err != nil || res != nil || r.projection == nil {
+		return res, err
+	}
+	return r.projection.GetByOrderID(ctx, orderID)
+}
+
+// load devuelve la reservación del stream (nil si no tiene) y su versión.
+func (r *StockReservationRepository) load(
+	ctx context.Context,
+	orderID uuid.UUID,
+) (*domain.StockReservation, int64, error) {
+	stored, err := r.events.Load(ctx, domain.ReservationStreamID(orderID), 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	evs := make([]domain.StockReservationEvent, 0, len(stored))
+	for _, st := range stored {
+		ev, err := decodeReservationEvent(st)
+		if err != nil {
+			return nil, 0, err
+		}
+		evs = append(evs, ev)
+	}
+	return domain.RehydrateStockReservation(evs), int64(len(stored)), nil
+}
+
+// Insert falla si la orden ya tiene reservación, en el stream o en la
+// proyección.
+func (r *StockReservationRepository) Insert(
+	ctx context.Context,
+	res *domain.StockReservation,
+) error {
+	if r.projection != nil {
+		legacy, err := r.projection.GetByOrderID(ctx, res.OrderID)
+		if err != nil {
+			return err
+		}
+		if legacy != nil {
+			return fmt.Errorf("reservation for order %s already exists", res.OrderID)
+		}
+	}
+
+	if res.ID == uuid.Nil {
+		res.ID = uuid.New()
+	}
+	for i := range res.Lines {
+		res.Lines[i].ReservationID = res.ID
+		if res.Lines[i].ID == uuid.Nil {
+			res.Lines[i].ID = uuid.New()
+		}
+	}
+
+	evs := reservationEventsFor(nil, res)
+	if err := r.append(ctx, res.OrderID, 0, evs); err != nil {
+		return err
+	}
+	if r.projection != nil {
+		if err := r.projection.Insert(ctx, res); err != nil {
+			slog.ErrorContext(logging.With(ctx, logging.KeyOrderID, res.OrderID.String()),
+				"reservation projection insert failed; run rebuild-projections", "error", err)
+		}
+	}
+	return nil
+}
+
+// Update guarda la liberación; las líneas no cambian. Una reservación que
+// solo existe en la proyección se migra a su stream en el mismo Append.
+func (r *StockReservationRepository) Update(
+	ctx context.Context,
+	res *domain.StockReservation,
+) error {
+	current, version, err := r.load(ctx, res.OrderID)
+	if err != nil {
+		return err
+	}
+
+	var evs []domain.StockReservationEvent
+	if current == nil {
+		if r.projection == nil {
+			return nil
+		}
+		legacy, err := r.projection.GetByOrderID(ctx, res.OrderID)
+		if err != nil || legacy == nil {
+			return err
+		}
+		evs = reservationEventsFor(nil, legacy)
+		current = domain.RehydrateStockReservation(evs)
+	}
+	if current.Status == domain.ReservationReleased && res.Status != domain.ReservationReleased {
+		return fmt.Errorf("reservation for order %s is already released", res.OrderID)
+	}
+	evs = append(evs, reservationEventsFor(current, res)...)
+	if len(evs) == 0 {
+		return nil
+	}
+
+	if err := r.append(ctx, res.OrderID, version, evs); err != nil {
+		return err
+	}
+	if r.projection != nil {
+		if err := r.projection.Update(ctx, res); err != nil {
+			slog.ErrorContext(logging.With(ctx, logging.KeyOrderID, res.OrderID.String()),
+				"reservation projection update failed; run rebuild-projections", "error", err)
+		}
+	}
+	return nil
+}
+
+// reservationEventsFor devuelve los eventos que llevan current (nil si no
+// existe) al estado de res.
+func reservationEventsFor(current, res *domain.StockReservation) []domain.StockReservationEvent {
+	var evs []domain.StockReservationEvent
+	if current == nil {
+		evs = append(evs, domain.StockReservationEvent{
+			Type:          domain.EventReservationPlaced,
+			OccurredAtUtc: res.ReservedAtUtc,
+			ID:            res.ID,
+			OrderID:       res.OrderID,
+			UserID:        res.UserID,
+			Lines:         res.Lines,
+		})
+	}
+	if res.Status == domain.ReservationReleased && (current == nil || current.Status != domain.ReservationReleased) {
+		at := time.Now().UTC()
+		if res.ReleasedAtUtc != nil {
+			at = *res.ReleasedAtUtc
+		}
+		evs = append(evs, domain.StockReservationEvent{Type: domain.EventReservationReleased, OccurredAtUtc: at})
+	}
+	return evs
+}
+
+func (r *StockReservationRepository) append(
+	ctx context.Context,
+	orderID uuid.UUID,
+	expected int64,
+	evs []domain.StockReservationEvent,
+) error {
+	stored := make([]domain.StoredEvent, 0, len(evs))
+	for _, ev := range evs {
+		st, err := encodeReservationEvent(ev)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, st)
+	}
+	return r.events.Append(ctx, []domain.StreamAppend{{
+		StreamID:        domain.ReservationStreamID(orderID),
+		ExpectedVersion: expected,
+		Events:          stored,
+	}})
+}
+
+var _ domain.StockReservationRepository = (*StockReservationRepository)(nil)
@@ -0,0 +1,261 @@
+// Package eventstore implementa el modo de persistencia event-sourced: los
+// repositorios de stock y reservaciones guardan eventos en un
+// domain.EventStore y reconstruyen los agregados desde ahí (con snapshots
+// para los SKUs con mucho historial). Las tablas del modo state quedan como
+// proyecciones, que se actualizan en cada escritura y se pueden reconstruir
+// con Rebuild.
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+)
+
+// StockItemRepository lee y escribe el stream stock-<sku> de cada SKU.
+//
+// UpsertMany guarda los eventos que registró el item (Changes) si se leyó
+// de este repositorio y el stream no cambió desde entonces; si otro escritor
+// agregó eventos devuelve ErrConcurrencyConflict. Un item que no viene del
+// event store (Version 0) se guarda como StockItemCreated o
+// StockItemLevelsSet con su estado completo.
+type StockItemRepository struct {
+	events        domain.EventStore
+	projection    domain.StockItemRepository
+	snapshotEvery int64
+}
+
+// projection puede ser nil (ej. en Rebuild); snapshotEvery <= 0 no guarda
+// snapshots.
+func NewStockItemRepository(
+	events domain.EventStore,
+	projection domain.StockItemRepository,
+	snapshotEvery int,
+) *StockItemRepository {
+	return &StockItemRepository{
+		events:        events,
+		projection:    projection,
+		snapshotEvery: int64(snapshotEvery),
+	}
+}
+
+// GetBySkus reconstruye cada item desde su stream. Los SKUs sin stream
+// (cargados antes del modo event-sourced) se leen de la proyección; su
+// primera escritura crea el stream con el estado que tenían.
+func (r *StockItemRepository) GetBySkus(
+	ctx context.Context,
+	skus []string,
+) (map[string]*domain.StockItem, error) {
+	result := make(map[string]*domain.StockItem)
+	var missing []string
+	for _, sku := range skus {
+		item, err := r.load(ctx, sku)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			missing = append(missing, sku)
+			continue
+		}
+		result[sku] = item
+	}
+
+	if len(missing) > 0 && r.projection != nil {
+		legacy, err := r.projection.GetBySkus(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for sku, item := range legacy {
+			item.ClearChanges()
+			item.Version = 0
+			result[sku] = item
+		}
+	}
+	return result, nil
+}
+
+// load devuelve nil si el SKU no tiene stream.
+func (r *StockItemRepository) load(ctx context.Context, sku string) (*domain.StockItem, error) {
+	stream := domain.StockStreamID(sku)
+
+	var item *domain.StockItem
+	var after int64
+	snap, err := r.events.LoadSnapshot(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if snap != nil {
+		if item, err = decodeStockSnapshot(*snap); err != nil {
+			return nil, err
+		}
+		after = snap.Version
+	}
+
+	stored, err := r.events.Load(ctx, stream, after)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil && len(stored) == 0 {
+		return nil, nil
+	}
+
+	evs := make([]domain.StockItemEvent, 0, len(stored))
+	for _, st := range stored {
+		ev, err := decodeStockEvent(st)
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+	item = domain.RehydrateStockItem(item, evs)
+	item.Version = after + int64(len(stored))
+	return item, nil
+}
+
+// pendingStock es un item que UpsertMany va a guardar, con el estado que
+// queda en su stream después del Append.
+type pendingStock struct {
+	item  *domain.StockItem
+	from  int64
+	state *domain.StockItem
+}
+
+// UpsertMany agrega los eventos de todos los items en un solo Append (todo o
+// nada) y después actualiza la proyección y los snapshots. Si eso falla solo
+// se loguea: los eventos ya están guardados y la proyección se repara con
+// rebuild-projections.
+func (r *StockItemRepository) UpsertMany(
+	ctx context.Context,
+	items []*domain.StockItem,
+) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var pending []pendingStock
+	var appends []domain.StreamAppend
+	for _, item := range items {
+		if item.Sku == "" {
+			return errors.New("stock item sku is empty")
+		}
+		current, err := r.load(ctx, item.Sku)
+		if err != nil {
+			return err
+		}
+		var head int64
+		if current != nil {
+			head = current.Version
+		}
+		if item.Version != 0 && item.Version != head {
+			return fmt.Errorf("stock item %s read at version %d, stream is at %d: %w",
+				item.Sku, item.Version, head, domain.ErrConcurrencyConflict)
+		}
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		if item.UpdatedAtUtc.IsZero() {
+			item.UpdatedAtUtc = time.Now().UTC()
+		}
+
+		evs := stockEventsFor(current, head, item)
+		if len(evs) == 0 {
+			continue
+		}
+		stored := make([]domain.StoredEvent, 0, len(evs))
+		for _, ev := range evs {
+			st, err := encodeStockEvent(ev)
+			if err != nil {
+				return err
+			}
+			stored = append(stored, st)
+		}
+		state := domain.RehydrateStockItem(current, evs)
+		state.Version = head + int64(len(evs))
+		pending = append(pending, pendingStock{item: item, from: head, state: state})
+		appends = append(appends, domain.StreamAppend{
+			StreamID:        domain.StockStreamID(item.Sku),
+			ExpectedVersion: head,
+			Events:          stored,
+		})
+	}
+	if len(appends) == 0 {
+		return nil
+	}
+	if err := r.events.Append(ctx, appends); err != nil {
+		return err
+	}
+
+	projected := make([]*domain.StockItem, 0, len(pending))
+	for _, p := range pending {
+		p.item.Version = p.state.Version
+		p.item.ClearChanges()
+		r.maybeSnapshot(ctx, p.from, p.state)
+		projected = append(projected, p.state)
+	}
+	if r.projection != nil {
+		if err := r.projection.UpsertMany(ctx, projected); err != nil {
+			slog.ErrorContext(ctx, "stock projection update failed; run rebuild-projections", "error", err)
+		}
+	}
+	return nil
+}
+
+// stockEventsFor decide qué eventos llevan el stream de head al estado de
+// item: los que registró el item si se leyó en head, más un
+// StockItemLevelsSet si no alcanzan (ej. campos asignados a mano). Un stream
+// nuevo siempre empieza con StockItemCreated.
+func stockEventsFor(current *domain.StockItem, head int64, item *domain.StockItem) []domain.StockItemEvent {
+	var evs []domain.StockItemEvent
+	if item.Version == head {
+		evs = item.Changes()
+	}
+	if current == nil && (len(evs) == 0 || evs[0].Type != domain.EventStockItemCreated) {
+		evs = nil
+	}
+
+	next := domain.RehydrateStockItem(current, evs)
+	created := current == nil && (next.ID != item.ID || next.Sku != item.Sku)
+	if !created && next.Available == item.Available && next.Reserved == item.Reserved {
+		return evs
+	}
+	if current == nil {
+		return []domain.StockItemEvent{{
+			Type:          domain.EventStockItemCreated,
+			OccurredAtUtc: item.UpdatedAtUtc,
+			ID:            item.ID,
+			Sku:           item.Sku,
+			Available:     item.Available,
+			Reserved:      item.Reserved,
+		}}
+	}
+	return append(evs, domain.StockItemEvent{
+		Type:          domain.EventStockItemLevelsSet,
+		OccurredAtUtc: item.UpdatedAtUtc,
+		Available:     item.Available,
+		Reserved:      item.Reserved,
+	})
+}
+
+// maybeSnapshot guarda un snapshot cuando el stream cruza un múltiplo de
+// snapshotEvery.
+func (r *StockItemRepository) maybeSnapshot(ctx context.Context, from int64, item *domain.StockItem) {
+	if r.snapshotEvery <= 0 || from/r.snapshotEvery == item.Version/r.snapshotEvery {
+		return
+	}
+	snap, err := encodeStockSnapshot(item, time.Now().UTC())
+	if err == nil {
+		err = r.events.SaveSnapshot(ctx, snap)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "stock snapshot failed", logging.KeySku, item.Sku, "version", item.Version, "error", err)
+	}
+}
+
+var _ domain.StockItemRepository = (*StockItemRepository)(nil)
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+type EventStore struct {
+	store *Store
+}
+
+func NewEventStore(store *Store) *EventStore {
+	return &EventStore{store: store}
+}
+
+// Append valida todos los streams antes de guardar, igual que la
+// transacción de PgEventStore.
+func (s *EventStore) Append(ctx context.Context, appends []domain.StreamAppend) error {
+	defer s.store.lock(ctx)()
+
+	if err := s.store.failure("EventStore.Append"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, a := range appends {
+		if seen[a.StreamID] {
+			return fmt.Errorf("stream %s appears twice in the same append", a.StreamID)
+		}
+		seen[a.StreamID] = true
+		if current := int64(len(s.store.events[a.StreamID])); current != a.ExpectedVersion {
+			return fmt.Errorf("stream %s is at version %d, expected %d: %w",
+				a.StreamID, current, a.ExpectedVersion, domain.ErrConcurrencyConflict)
+		}
+	}
+	for _, a := range appends {
+		for i, ev := range a.Events {
+			ev.StreamID = a.StreamID
+			ev.Version = a.ExpectedVersion + int64(i) + 1
+			s.store.events[a.StreamID] = append(s.store.events[a.StreamID], ev)
+		}
+	}
+	return nil
+}
+
+func (s *EventStore) Load(ctx context.Context, streamID string, after int64) ([]domain.StoredEvent, error) {
+	defer s.store.rlock(ctx)()
+
+	if err := s.store.failure("EventStore.Load"); err != nil {
+		return nil, err
+	}
+	events := s.store.events[streamID]
+	if after >= int64(len(events)) {
+		return nil, nil
+	}
+	if after < 0 {
+		after = 0
+	}
+	return append([]domain.StoredEvent(nil), events[after:]...), nil
+}
+
+func (s *EventStore) StreamIDs(ctx context.Context, prefix string) ([]string, error) {
+	defer s.store.rlock(ctx)()
+
+	if err := s.store.failure("EventStore.StreamIDs"); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for id, events := range s.store.events {
+		if strings.HasPrefix(id, prefix) && len(events) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// LoadSnapshot devuelve nil si el stream no tiene snapshot.
+func (s *EventStore) LoadSnapshot(ctx context.Context, streamID string) (*domain.Snapshot, error) {
+	defer s.store.rlock(ctx)()
+
+	if err := s.store.failure("EventStore.LoadSnapshot"); err != nil {
+		return nil, err
+	}
+	snap, ok := s.store.snapshots[streamID]
+	if !ok {
+		return nil, nil
+	}
+	return &snap, nil
+}
+
+func (s *EventStore) SaveSnapshot(ctx context.Context, snap domain.Snapshot) error {
+	defer s.store.lock(ctx)()
+
+	if err := s.store.failure("EventStore.SaveSnapshot"); err != nil {
+		return err
+	}
+	s.store.snapshots[snap.StreamID] = snap
+	return nil
+}
+
+var _ domain.EventStore = (*EventStore)(nil)
@@ -0,0 +1,503 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+type StockItemRepository struct {
+	store *Store
+}
+
+func NewStockItemRepository(store *Store) *StockItemRepository {
+	return &StockItemRepository{store: store}
+}
+
+func (r *StockItemRepository) GetBySkus(
+	ctx context.Context,
+	skus []string,
+) (map[string]*domain.StockItem, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("StockItemRepository.GetBySkus"); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*domain.StockItem)
+	for _, sku := range skus {
+		if item, ok := r.store.stock[sku]; ok {
+			result[sku] = &item
+		}
+	}
+	return result, nil
+}
+
+// UpsertMany aplica todos los items o ninguno, igual que la transacción de
+// PgStockItemRepository.
+func (r *StockItemRepository) UpsertMany(
+	ctx context.Context,
+	items []*domain.StockItem,
+) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("StockItemRepository.UpsertMany"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Sku == "" {
+			return errors.New("stock item sku is empty")
+		}
+	}
+	for _, item := range items {
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		if item.UpdatedAtUtc.IsZero() {
+			item.UpdatedAtUtc = time.Now().UTC()
+		}
+		stored := *item
+		stored.ClearChanges()
+		if existing, ok := r.store.stock[item.Sku]; ok {
+			stored.ID = existing.ID // on conflict (sku) no cambia el id
+		}
+		r.store.stock[item.Sku] = stored
+		r.store.recordLevel(stored)
+	}
+	return nil
+}
+
+// recordLevel agrega el nivel al historial del SKU; uno con la misma hora
+// reemplaza al anterior, como el on conflict de Postgres. Se llama con
+// s.mu tomado.
+func (s *Store) recordLevel(item domain.StockItem) {
+	level := domain.StockLevel{
+		Sku:          item.Sku,
+		Available:    item.Available,
+		Reserved:     item.Reserved,
+		ChangedAtUtc: item.UpdatedAtUtc,
+	}
+	levels := s.history[item.Sku]
+	i := sort.Search(len(levels), func(i int) bool { return !levels[i].ChangedAtUtc.Before(level.ChangedAtUtc) })
+	if i < len(levels) && levels[i].ChangedAtUtc.Equal(level.ChangedAtUtc) {
+		levels[i] = level
+		return
+	}
+	levels = append(levels, domain.StockLevel{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = level
+	s.history[item.Sku] = levels
+}
+
+type StockHistoryRepository struct {
+	store *Store
+}
+
+func NewStockHistoryRepository(store *Store) *StockHistoryRepository {
+	return &StockHistoryRepository{store: store}
+}
+
+func (r *StockHistoryRepository) AsOf(
+	ctx context.Context,
+	skus []string,
+	at time.Time,
+) (map[string]domain.StockLevel, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("StockHistoryRepository.AsOf"); err != nil {
+		return nil, err
+	}
+	result := make(map[string]domain.StockLevel)
+	for _, sku := range skus {
+		if level, ok := r.store.levelAt(sku, at); ok {
+			result[sku] = level
+		}
+	}
+	return result, nil
+}
+
+func (r *StockHistoryRepository) ReportAsOf(
+	ctx context.Context,
+	at time.Time,
+	after string,
+	limit int,
+) ([]domain.StockLevel, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("StockHistoryRepository.ReportAsOf"); err != nil {
+		return nil, err
+	}
+	skus := make([]string, 0, len(r.store.history))
+	for sku := range r.store.history {
+		if sku > after {
+			skus = append(skus, sku)
+		}
+	}
+	sort.Strings(skus)
+
+	out := []domain.StockLevel{}
+	for _, sku := range skus {
+		if len(out) == limit {
+			break
+		}
+		if level, ok := r.store.levelAt(sku, at); ok {
+			out = append(out, level)
+		}
+	}
+	return out, nil
+}
+
+type StockExportRepository struct {
+	store *Store
+}
+
+func NewStockExportRepository(store *Store) *StockExportRepository {
+	return &StockExportRepository{store: store}
+}
+
+func (r *StockExportRepository) StockItemsAfter(
+	ctx context.Context,
+	after string,
+	limit int,
+) ([]*domain.StockItem, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("StockExportRepository.StockItemsAfter"); err != nil {
+		return nil, err
+	}
+	skus := make([]string, 0, len(r.store.stock))
+	for sku := range r.store.stock {
+		if sku > after {
+			skus = append(skus, sku)
+		}
+	}
+	sort.Strings(skus)
+
+	out := make([]*domain.StockItem, 0, min(limit, len(skus)))
+	for _, sku := range skus[:min(limit, len(skus))] {
+		item := r.store.stock[sku]
+		out = append(out, &item)
+	}
+	return out, nil
+}
+
+func (r *StockExportRepository) ActiveReservationsAfter(
+	ctx context.Context,
+	after uuid.UUID,
+	limit int,
+) ([]*domain.StockReservation, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("StockExportRepository.ActiveReservationsAfter"); err != nil {
+		return nil, err
+	}
+	var orderIDs []uuid.UUID
+	for orderID, res := range r.store.reservations {
+		if res.Status == domain.ReservationActive && bytes.Compare(orderID[:], after[:]) > 0 {
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return bytes.Compare(orderIDs[i][:], orderIDs[j][:]) < 0 })
+
+	out := make([]*domain.StockReservation, 0, min(limit, len(orderIDs)))
+	for _, orderID := range orderIDs[:min(limit, len(orderIDs))] {
+		res := copyReservation(r.store.reservations[orderID])
+		out = append(out, &res)
+	}
+	return out, nil
+}
+
+// levelAt es el último nivel de sku en o antes de at.
+func (s *Store) levelAt(sku string, at time.Time) (domain.StockLevel, bool) {
+	levels := s.history[sku]
+	i := sort.Search(len(levels), func(i int) bool { return levels[i].ChangedAtUtc.After(at) })
+	if i == 0 {
+		return domain.StockLevel{}, false
+	}
+	return levels[i-1], true
+}
+
+type StockReservationRepository struct {
+	store *Store
+}
+
+func NewStockReservationRepository(store *Store) *StockReservationRepository {
+	return &StockReservationRepository{store: store}
+}
+
+// GetByOrderID devuelve nil si no existe.
+func (r *StockReservationRepository) GetByOrderID(
+	ctx context.Context,
+	orderID uuid.UUID,
+) (*domain.StockReservation, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("StockReservationRepository.GetByOrderID"); err != nil {
+		return nil, err
+	}
+
+	res, ok := r.store.reservations[orderID]
+	if !ok {
+		return nil, nil
+	}
+	res = copyReservation(res)
+	return &res, nil
+}
+
+// Insert falla si la orden ya tiene reservación (order_id es único).
+func (r *StockReservationRepository) Insert(
+	ctx context.Context,
+	res *domain.StockReservation,
+) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("StockReservationRepository.Insert"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := r.store.reservations[res.OrderID]; ok {
+		return fmt.Errorf("reservation for order %s already exists", res.OrderID)
+	}
+
+	if res.ID == uuid.Nil {
+		res.ID = uuid.New()
+	}
+	stored := copyReservation(*res)
+	for i := range stored.Lines {
+		stored.Lines[i].ReservationID = res.ID
+		if stored.Lines[i].ID == uuid.Nil {
+			stored.Lines[i].ID = uuid.New()
+		}
+	}
+	r.store.reservations[res.OrderID] = stored
+	return nil
+}
+
+// Update guarda status y fecha de liberación; las líneas no cambian.
+func (r *StockReservationRepository) Update(
+	ctx context.Context,
+	res *domain.StockReservation,
+) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("StockReservationRepository.Update"); err != nil {
+		return err
+	}
+
+	for orderID, stored := range r.store.reservations {
+		if stored.ID != res.ID {
+			continue
+		}
+		stored.Status = res.Status
+		stored.ReleasedAtUtc = nil
+		if res.ReleasedAtUtc != nil {
+			t := *res.ReleasedAtUtc
+			stored.ReleasedAtUtc = &t
+		}
+		r.store.reservations[orderID] = stored
+	}
+	return nil
+}
+
+type OutboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{store: store}
+}
+
+func (r *OutboxRepository) Insert(ctx context.Context, msg domain.OutboxMessage) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("OutboxRepository.Insert"); err != nil {
+		return err
+	}
+	if msg.ID == uuid.Nil {
+		msg.ID = uuid.New()
+	}
+	if _, ok := r.store.outbox[msg.ID]; ok {
+		return fmt.Errorf("outbox message %s already exists", msg.ID)
+	}
+	if msg.OccurredAtUtc == 0 {
+		msg.OccurredAtUtc = time.Now().UTC().Unix()
+	}
+	r.store.outbox[msg.ID] = copyOutbox(msg)
+	return nil
+}
+
+// GetPendingBatch devuelve los no procesados con menos de maxRetry
+// intentos, del más viejo al más nuevo.
+func (r *OutboxRepository) GetPendingBatch(
+	ctx context.Context,
+	maxRetry, batchSize int,
+) ([]domain.OutboxMessage, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("OutboxRepository.GetPendingBatch"); err != nil {
+		return nil, err
+	}
+
+	pending := r.store.outboxSorted(func(m domain.OutboxMessage) bool {
+		return m.ProcessedAtUtc == nil && m.RetryCount < maxRetry
+	})
+	if len(pending) > batchSize {
+		pending = pending[:batchSize]
+	}
+	return pending, nil
+}
+
+// Save actualiza retry_count y processed_at; un ProcessedAtUtc nil no borra
+// uno ya guardado.
+func (r *OutboxRepository) Save(ctx context.Context, msg domain.OutboxMessage) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("OutboxRepository.Save"); err != nil {
+		return err
+	}
+	if msg.ID == uuid.Nil {
+		return errors.New("outbox message id is empty")
+	}
+
+	stored, ok := r.store.outbox[msg.ID]
+	if !ok {
+		return nil
+	}
+	stored.RetryCount = msg.RetryCount
+	if msg.ProcessedAtUtc != nil {
+		t := *msg.ProcessedAtUtc
+		stored.ProcessedAtUtc = &t
+	}
+	r.store.outbox[msg.ID] = stored
+	return nil
+}
+
+func (r *OutboxRepository) GetPendingStats(ctx context.Context, maxRetry int) (domain.OutboxStats, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("OutboxRepository.GetPendingStats"); err != nil {
+		return domain.OutboxStats{}, err
+	}
+
+	var stats domain.OutboxStats
+	for _, m := range r.store.outbox {
+		if m.ProcessedAtUtc != nil || m.RetryCount >= maxRetry {
+			continue
+		}
+		stats.Pending++
+		if stats.OldestOccurredAtUtc == 0 || m.OccurredAtUtc < stats.OldestOccurredAtUtc {
+			stats.OldestOccurredAtUtc = m.OccurredAtUtc
+		}
+	}
+	return stats, nil
+}
+
+type QuarantineRepository struct {
+	store *Store
+}
+
+func NewQuarantineRepository(store *Store) *QuarantineRepository {
+	return &QuarantineRepository{store: store}
+}
+
+func (r *QuarantineRepository) Insert(ctx context.Context, msg domain.QuarantinedMessage) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("QuarantineRepository.Insert"); err != nil {
+		return err
+	}
+	if msg.ID == uuid.Nil {
+		msg.ID = uuid.New()
+	}
+	r.store.quarantine[msg.ID] = copyQuarantined(msg)
+	return nil
+}
+
+func (r *QuarantineRepository) List(
+	ctx context.Context,
+	pendingOnly bool,
+	limit int,
+) ([]domain.QuarantinedMessage, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("QuarantineRepository.List"); err != nil {
+		return nil, err
+	}
+
+	var out []domain.QuarantinedMessage
+	for _, m := range r.store.quarantine {
+		if !pendingOnly || m.ReplayedAtUtc == nil {
+			out = append(out, copyQuarantined(m))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].QuarantinedAtUtc.After(out[j].QuarantinedAtUtc) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// GetByID devuelve nil si no existe.
+func (r *QuarantineRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.QuarantinedMessage, error) {
+	defer r.store.rlock(ctx)()
+
+	if err := r.store.failure("QuarantineRepository.GetByID"); err != nil {
+		return nil, err
+	}
+	m, ok := r.store.quarantine[id]
+	if !ok {
+		return nil, nil
+	}
+	m = copyQuarantined(m)
+	return &m, nil
+}
+
+func (r *QuarantineRepository) ClaimReplay(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("QuarantineRepository.ClaimReplay"); err != nil {
+		return false, err
+	}
+	m, ok := r.store.quarantine[id]
+	if !ok || m.ReplayedAtUtc != nil {
+		return false, nil
+	}
+	m.ReplayedAtUtc = &at
+	r.store.quarantine[id] = m
+	return true, nil
+}
+
+func (r *QuarantineRepository) UnclaimReplay(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer r.store.lock(ctx)()
+
+	if err := r.store.failure("QuarantineRepository.UnclaimReplay"); err != nil {
+		return err
+	}
+	if m, ok := r.store.quarantine[id]; ok && m.ReplayedAtUtc != nil && m.ReplayedAtUtc.Equal(at) {
+		m.ReplayedAtUtc = nil
+		r.store.quarantine[id] = m
+	}
+	return nil
+}
+
+var (
+	_ domain.StockItemRepository        = (*StockItemRepository)(nil)
+	_ domain.StockHistoryRepository     = (*StockHistoryRepository)(nil)
+	_ domain.StockExportRepository      = (*StockExportRepository)(nil)
+	_ domain.StockReservationRepository = (*StockReservationRepository)(nil)
+	_ domain.OutboxRepository           = (*OutboxRepository)(nil)
+	_ domain.QuarantineRepository       = (*QuarantineRepository)(nil)
+)
@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain/repotest"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		store := NewStore()
+		return repotest.Repos{
+			Stock:        NewStockItemRepository(store),
+			Reservations: NewStockReservationRepository(store),
+			Outbox:       NewOutboxRepository(store),
+			Quarantine:   NewQuarantineRepository(store),
+			Events:       NewEventStore(store),
+			History:      NewStockHistoryRepository(store),
+			Export:       NewStockExportRepository(store),
+			UnitOfWork:   NewUnitOfWork(store),
+		}
+	})
+}
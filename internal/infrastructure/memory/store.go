@@ -0,0 +1,125 @@
+// Package memory implementa los repositorios de domain en memoria, para
+// tests y para correr sin Postgres. Cada operación es atómica como su par
+// en db: o se aplica completa o no se aplica nada, y nunca se comparten
+// punteros con el llamador (lo que devuelve un Get no cambia el store hasta
+// que se guarda). Para agrupar varias operaciones está UnitOfWork.
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Store es el estado compartido por los repositorios de un mismo "base de
+// datos" en memoria.
+type Store struct {
+	mu           sync.RWMutex
+	stock        map[string]domain.StockItem
+	history      map[string][]domain.StockLevel        // por SKU, en orden de ChangedAtUtc
+	reservations map[uuid.UUID]domain.StockReservation // por OrderID
+	outbox       map[uuid.UUID]domain.OutboxMessage
+	quarantine   map[uuid.UUID]domain.QuarantinedMessage
+	events       map[string][]domain.StoredEvent
+	snapshots    map[string]domain.Snapshot
+	failures     map[string]error
+}
+
+func NewStore() *Store {
+	return &Store{
+		stock:        map[string]domain.StockItem{},
+		history:      map[string][]domain.StockLevel{},
+		reservations: map[uuid.UUID]domain.StockReservation{},
+		outbox:       map[uuid.UUID]domain.OutboxMessage{},
+		quarantine:   map[uuid.UUID]domain.QuarantinedMessage{},
+		events:       map[string][]domain.StoredEvent{},
+		snapshots:    map[string]domain.Snapshot{},
+		failures:     map[string]error{},
+	}
+}
+
+// FailOn hace que la operación op (ej. "StockItemRepository.UpsertMany")
+// devuelva err sin tocar el estado; err nil la vuelve a habilitar. Sirve
+// para probar qué pasa cuando falla la base a mitad de un flujo.
+func (s *Store) FailOn(op string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.failures, op)
+		return
+	}
+	s.failures[op] = err
+}
+
+// failure se llama con s.mu tomado.
+func (s *Store) failure(op string) error {
+	if err, ok := s.failures[op]; ok {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// StockItems devuelve una copia del stock ordenada por SKU.
+func (s *Store) StockItems() []domain.StockItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]domain.StockItem, 0, len(s.stock))
+	for _, item := range s.stock {
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Sku < out[j].Sku })
+	return out
+}
+
+// OutboxMessages devuelve una copia del outbox en orden de OccurredAtUtc.
+func (s *Store) OutboxMessages() []domain.OutboxMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.outboxSorted(func(domain.OutboxMessage) bool { return true })
+}
+
+func (s *Store) outboxSorted(keep func(domain.OutboxMessage) bool) []domain.OutboxMessage {
+	out := make([]domain.OutboxMessage, 0, len(s.outbox))
+	for _, msg := range s.outbox {
+		if keep(msg) {
+			out = append(out, copyOutbox(msg))
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].OccurredAtUtc != out[j].OccurredAtUtc {
+			return out[i].OccurredAtUtc < out[j].OccurredAtUtc
+		}
+		return out[i].ID.String() < out[j].ID.String()
+	})
+	return out
+}
+
+func copyReservation(r domain.StockReservation) domain.StockReservation {
+	if r.ReleasedAtUtc != nil {
+		t := *r.ReleasedAtUtc
+		r.ReleasedAtUtc = &t
+	}
+	r.Lines = append([]domain.ReservationLine(nil), r.Lines...)
+	return r
+}
+
+func copyOutbox(m domain.OutboxMessage) domain.OutboxMessage {
+	if m.ProcessedAtUtc != nil {
+		t := *m.ProcessedAtUtc
+		m.ProcessedAtUtc = &t
+	}
+	return m
+}
+
+func copyQuarantined(m domain.QuarantinedMessage) domain.QuarantinedMessage {
+	if m.ReplayedAtUtc != nil {
+		t := *m.ReplayedAtUtc
+		m.ReplayedAtUtc = &t
+	}
+	return m
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// txKey marca en el ctx que la unidad de trabajo en curso tiene tomado el
+// lock de store.
+type txKey struct{ store *Store }
+
+// UnitOfWork corre cada unidad con los stores bloqueados: las demás
+// operaciones esperan a que termine, así nadie ve escrituras a medias, y si
+// fn falla los stores vuelven al estado de antes. Los repositorios no deben
+// usarse desde otras goroutines con el ctx de la unidad.
+type UnitOfWork struct {
+	stores []*Store
+}
+
+// NewUnitOfWork recibe los stores que forman una misma "base de datos" (ej.
+// eventos y proyecciones en stores separados).
+func NewUnitOfWork(stores ...*Store) *UnitOfWork {
+	return &UnitOfWork{stores: stores}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if len(u.stores) == 0 || u.stores[0].inTx(ctx) {
+		return fn(ctx)
+	}
+
+	ctx, hooks := domain.WithCommitHooks(ctx)
+	if err := u.run(ctx, fn); err != nil {
+		return err
+	}
+	hooks.Run()
+	return nil
+}
+
+// run deshace los cambios si fn devuelve error o entra en pánico.
+func (u *UnitOfWork) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := make([]storeState, len(u.stores))
+	for i, s := range u.stores {
+		s.mu.Lock()
+		saved[i] = s.state()
+		ctx = context.WithValue(ctx, txKey{s}, true)
+	}
+	committed := false
+	defer func() {
+		for i := len(u.stores) - 1; i >= 0; i-- {
+			if !committed {
+				u.stores[i].restore(saved[i])
+			}
+			u.stores[i].mu.Unlock()
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (s *Store) inTx(ctx context.Context) bool {
+	held, _ := ctx.Value(txKey{s}).(bool)
+	return held
+}
+
+// lock toma el lock de escritura del store, salvo que ctx esté en una
+// unidad de trabajo que ya lo tiene.
+func (s *Store) lock(ctx context.Context) (unlock func()) {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// rlock es lock para lectura.
+func (s *Store) rlock(ctx context.Context) (unlock func()) {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// storeState es una copia del contenido del store (sin las fallas
+// inyectadas), para deshacer una unidad de trabajo.
+type storeState struct {
+	stock        map[string]domain.StockItem
+	history      map[string][]domain.StockLevel
+	reservations map[uuid.UUID]domain.StockReservation
+	outbox       map[uuid.UUID]domain.OutboxMessage
+	quarantine   map[uuid.UUID]domain.QuarantinedMessage
+	events       map[string][]domain.StoredEvent
+	snapshots    map[string]domain.Snapshot
+}
+
+// state se llama con s.mu tomado. Los slices se copian porque el historial
+// se modifica en su lugar.
+func (s *Store) state() storeState {
+	return storeState{
+		stock:        maps.Clone(s.stock),
+		history:      cloneSlices(s.history),
+		reservations: maps.Clone(s.reservations),
+		outbox:       maps.Clone(s.outbox),
+		quarantine:   maps.Clone(s.quarantine),
+		events:       cloneSlices(s.events),
+		snapshots:    maps.Clone(s.snapshots),
+	}
+}
+
+// restore se llama con s.mu tomado.
+func (s *Store) restore(st storeState) {
+	s.stock = st.stock
+	s.history = st.history
+	s.reservations = st.reservations
+	s.outbox = st.outbox
+	s.quarantine = st.quarantine
+	s.events = st.events
+	s.snapshots = st.snapshots
+}
+
+func cloneSlices[V any](m map[string][]V) map[string][]V {
+	out := make(map[string][]V, len(m))
+	for k, v := range m {
+		out[k] = slices.Clone(v)
+	}
+	return out
+}
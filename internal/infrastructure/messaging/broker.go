@@ -0,0 +1,148 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+)
+
+// ConsumerBus es un EventBus que además arranca consumidores y se puede detener.
+type ConsumerBus interface {
+	abstractions.EventBus
+	StartConsumers(ctx context.Context) error
+	// Drain deja de tomar mensajes nuevos y espera a que terminen los
+	// handlers en curso, o hasta que venza ctx.
+	Drain(ctx context.Context) error
+	Stop() error
+}
+
+// Broker abstrae el transporte de mensajes. Un stream es el exchange/topic
+// (ej. "orders.events"); group identifica al grupo de consumidores
+// (en RabbitMQ, el prefijo de las colas).
+type Broker interface {
+	Consumer(stream, group string) ConsumerBus
+	Producer(stream string) abstractions.EventBus
+	// Check devuelve error si los consumidores no están conectados.
+	Check(ctx context.Context) error
+	// Drain hace Drain de todos los consumidores creados por el broker.
+	Drain(ctx context.Context) error
+	Close() error
+}
+
+// Los handlers llegan envueltos en application.RetryingHandler, que manda a
+// cuarentena lo que no se arregla reintentando. Un error que llega al bus es
+// transitorio (la cuarentena no se pudo guardar, o el servicio se apaga):
+// el mensaje se vuelve a entregar, no se descarta. redeliveryDelay es la
+// espera entre entregas en Kafka y en el broker en memoria.
+const redeliveryDelay = time.Second
+
+const (
+	BrokerRabbitMQ = "rabbitmq"
+	BrokerMemory   = "memory"
+	BrokerKafka    = "kafka"
+)
+
+// NewBroker crea el broker seleccionado en messaging.broker con el formato
+// de salida de messaging.eventFormat.
+func NewBroker(cfg config.MessagingConfig) (Broker, error) {
+	codec, err := NewCodec(cfg.EventFormat, cfg.CloudEventsSource)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Broker {
+	case "", BrokerRabbitMQ:
+		return NewRabbitMqBroker(cfg.RabbitURI, cfg.Prefetch, cfg.Queues.Producer, codec), nil
+	case BrokerMemory:
+		return NewMemoryBroker(codec), nil
+	case BrokerKafka:
+		return NewKafkaBroker(cfg.KafkaBrokers, codec), nil
+	default:
+		return nil, fmt.Errorf("unknown message broker %q", cfg.Broker)
+	}
+}
+
+// drainAll drena los consumidores en paralelo y devuelve el primer error.
+func drainAll(ctx context.Context, buses []ConsumerBus) error {
+	errs := make(chan error, len(buses))
+	for _, bus := range buses {
+		go func(bus ConsumerBus) { errs <- bus.Drain(ctx) }(bus)
+	}
+
+	var firstErr error
+	for range buses {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// waitGroup espera wg o hasta que venza ctx.
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// toEnvelope envuelve el evento igual que RabbitMqEventBus: si ya es un
+// envelope se usa tal cual, si no se serializa como payload.
+func toEnvelope(event primitives.Event) (primitives.IntegrationEventEnvelope, error) {
+	if event == nil {
+		return primitives.IntegrationEventEnvelope{}, fmt.Errorf("event is nil")
+	}
+
+	var envelope primitives.IntegrationEventEnvelope
+	if ev, ok := event.(*primitives.IntegrationEventEnvelope); ok {
+		envelope = *ev
+	} else {
+		payloadBytes, err := json.Marshal(event)
+		if err != nil {
+			return envelope, err
+		}
+		envelope = primitives.NewIntegrationEventEnvelope(eventTypeName(event), string(payloadBytes))
+	}
+	if envelope.GetRoutingKey() == "" {
+		envelope.SetRoutingKey(envelope.Type)
+	}
+	return envelope, nil
+}
+
+// eventTypeName obtiene el nombre simple del tipo del evento.
+func eventTypeName(ev primitives.Event) string {
+	t := reflect.TypeOf(ev)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// envelopeContext agrega los campos de correlación del envelope a los logs.
+func envelopeContext(ctx context.Context, env *primitives.IntegrationEventEnvelope) context.Context {
+	args := []any{
+		logging.KeyEnvelopeID, env.ID.String(),
+		logging.KeyEventType, env.Type,
+	}
+	if env.CorrelationID != "" {
+		args = append(args, logging.KeyCorrelationID, env.CorrelationID)
+	}
+	return logging.With(ctx, args...)
+}
@@ -0,0 +1,171 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+)
+
+const (
+	FormatEnvelope    = "envelope"
+	FormatCloudEvents = "cloudevents"
+)
+
+// Codec convierte envelopes a bytes en el cable y viceversa. Encode usa el
+// formato configurado; Decode acepta cualquiera de los formatos soportados,
+// así los consumidores reciben siempre un IntegrationEventEnvelope.
+type Codec interface {
+	ContentType() string
+	Encode(env primitives.IntegrationEventEnvelope) ([]byte, error)
+	Decode(body []byte) (primitives.IntegrationEventEnvelope, error)
+}
+
+// NewCodec crea el codec para EVENT_FORMAT.
+func NewCodec(format, cloudEventsSource string) (Codec, error) {
+	switch format {
+	case "", FormatEnvelope:
+		return EnvelopeCodec{}, nil
+	case FormatCloudEvents:
+		return CloudEventsCodec{Source: cloudEventsSource}, nil
+	default:
+		return nil, fmt.Errorf("unknown event format %q", format)
+	}
+}
+
+// EnvelopeCodec es el formato de la librería: IntegrationEventEnvelope con
+// el payload como string JSON embebido.
+type EnvelopeCodec struct{}
+
+func (EnvelopeCodec) ContentType() string { return "application/json" }
+
+func (EnvelopeCodec) Encode(env primitives.IntegrationEventEnvelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+func (EnvelopeCodec) Decode(body []byte) (primitives.IntegrationEventEnvelope, error) {
+	return decodeAny(body)
+}
+
+// CloudEventsCodec emite CloudEvents 1.0 en modo structured JSON.
+type CloudEventsCodec struct {
+	Source string
+}
+
+// cloudEvent es la representación structured JSON de CloudEvents 1.0.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	CausationID     string          `json:"causationid,omitempty"`
+}
+
+// cloudEventIDNamespace es el namespace UUIDv5 de los ids de CloudEvents
+// que no son UUID. No se cambia: los ids derivados dejarían de coincidir.
+var cloudEventIDNamespace = uuid.MustParse("6f1c9a52-3d7e-4b8a-9c41-2e5d8f0a7b13")
+
+func (CloudEventsCodec) ContentType() string { return "application/cloudevents+json" }
+
+func (c CloudEventsCodec) Encode(env primitives.IntegrationEventEnvelope) ([]byte, error) {
+	data := json.RawMessage(env.PayloadJSON)
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("payload of %s is not valid JSON", env.Type)
+	}
+
+	occurred := env.OccurredAtUtc
+	if occurred.IsZero() {
+		occurred = time.Now().UTC()
+	}
+
+	ce := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              env.ID.String(),
+		Source:          c.Source,
+		Type:            env.Type,
+		Subject:         subjectOf(env),
+		Time:            occurred,
+		DataContentType: "application/json",
+		Data:            data,
+		CorrelationID:   env.CorrelationID,
+		CausationID:     env.CausationID,
+	}
+	return json.Marshal(ce)
+}
+
+func (CloudEventsCodec) Decode(body []byte) (primitives.IntegrationEventEnvelope, error) {
+	return decodeAny(body)
+}
+
+// decodeAny detecta CloudEvents por "specversion"; si no, asume envelope.
+func decodeAny(body []byte) (primitives.IntegrationEventEnvelope, error) {
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return primitives.IntegrationEventEnvelope{}, err
+	}
+	if probe.SpecVersion == "" {
+		var env primitives.IntegrationEventEnvelope
+		err := json.Unmarshal(body, &env)
+		return env, err
+	}
+	return decodeCloudEvent(body)
+}
+
+func decodeCloudEvent(body []byte) (primitives.IntegrationEventEnvelope, error) {
+	var ce cloudEvent
+	if err := json.Unmarshal(body, &ce); err != nil {
+		return primitives.IntegrationEventEnvelope{}, err
+	}
+	if ce.SpecVersion != "1.0" {
+		return primitives.IntegrationEventEnvelope{}, fmt.Errorf("unsupported cloudevents specversion %q", ce.SpecVersion)
+	}
+	if ce.Type == "" {
+		return primitives.IntegrationEventEnvelope{}, fmt.Errorf("cloudevent without type")
+	}
+
+	// El id de CloudEvents es texto libre y único por source; si no es UUID
+	// lo derivamos de los dos, así cada reentrega del evento tiene el mismo
+	// envelope id.
+	id, err := uuid.Parse(ce.ID)
+	if err != nil {
+		id = uuid.NewSHA1(cloudEventIDNamespace, []byte(ce.Source+"/"+ce.ID))
+	}
+
+	payload := "{}"
+	if len(bytes.TrimSpace(ce.Data)) > 0 {
+		payload = string(ce.Data)
+	}
+
+	msg := primitives.NewMessageWith(id, ce.CorrelationID, ce.CausationID, ce.Time.UTC())
+	return primitives.NewIntegrationEventEnvelopeWith(
+		primitives.NewBaseEventWith(msg, ce.Type),
+		ce.Type,
+		payload,
+		ce.Time.UTC(),
+	), nil
+}
+
+// subjectOf devuelve orderId o sku del payload (vacío si no hay).
+func subjectOf(env primitives.IntegrationEventEnvelope) string {
+	var keys struct {
+		OrderID string `json:"orderId"`
+		Sku     string `json:"sku"`
+	}
+	if err := json.Unmarshal([]byte(env.PayloadJSON), &keys); err != nil {
+		return ""
+	}
+	if keys.OrderID != "" && keys.OrderID != uuid.Nil.String() {
+		return keys.OrderID
+	}
+	return keys.Sku
+}
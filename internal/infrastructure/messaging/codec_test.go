@@ -0,0 +1,258 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func codecTestEnvelope() primitives.IntegrationEventEnvelope {
+	occurred := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	msg := primitives.NewMessageWith(uuid.New(), "corr-1", "cause-1", occurred)
+	return primitives.NewIntegrationEventEnvelopeWith(
+		primitives.NewBaseEventWith(msg, "StockReserved"),
+		"StockReserved",
+		`{"orderId":"6f1c2d4e-0000-4000-8000-000000000001","lines":[{"sku":"A","quantity":2}]}`,
+		occurred,
+	)
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{EnvelopeCodec{}, CloudEventsCodec{Source: "urn:test"}} {
+		env := codecTestEnvelope()
+		body, err := codec.Encode(env)
+		if err != nil {
+			t.Fatalf("%T.Encode: %v", codec, err)
+		}
+		got, err := codec.Decode(body)
+		if err != nil {
+			t.Fatalf("%T.Decode: %v", codec, err)
+		}
+		if got.ID != env.ID || got.Type != env.Type || got.GetRoutingKey() != env.Type ||
+			got.CorrelationID != env.CorrelationID || got.CausationID != env.CausationID ||
+			!got.OccurredAtUtc.Equal(env.OccurredAtUtc) {
+			t.Errorf("%T round trip = %+v, want %+v", codec, got, env)
+		}
+		var gotPayload, wantPayload any
+		_ = json.Unmarshal([]byte(got.PayloadJSON), &gotPayload)
+		_ = json.Unmarshal([]byte(env.PayloadJSON), &wantPayload)
+		if gotJSON, _ := json.Marshal(gotPayload); string(gotJSON) != mustJSON(t, wantPayload) {
+			t.Errorf("%T payload = %s, want %s", codec, got.PayloadJSON, env.PayloadJSON)
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCloudEventsEncodeAttributes(t *testing.T) {
+	env := codecTestEnvelope()
+	body, err := CloudEventsCodec{Source: "urn:test"}.Encode(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ce map[string]any
+	if err := json.Unmarshal(body, &ce); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"specversion":     "1.0",
+		"id":              env.ID.String(),
+		"source":          "urn:test",
+		"type":            "StockReserved",
+		"subject":         "6f1c2d4e-0000-4000-8000-000000000001",
+		"time":            "2026-10-01T12:00:00Z",
+		"datacontenttype": "application/json",
+		"correlationid":   "corr-1",
+		"causationid":     "cause-1",
+	}
+	for k, v := range want {
+		if ce[k] != v {
+			t.Errorf("%s = %v, want %v", k, ce[k], v)
+		}
+	}
+	// data va como objeto, no como string embebido.
+	if _, ok := ce["data"].(map[string]any); !ok {
+		t.Errorf("data = %T, want a JSON object", ce["data"])
+	}
+
+	env.PayloadJSON = `{"sku":"B"}`
+	body, _ = CloudEventsCodec{}.Encode(env)
+	var bySku struct{ Subject string }
+	_ = json.Unmarshal(body, &bySku)
+	if bySku.Subject != "B" {
+		t.Errorf("subject without orderId = %q, want the sku", bySku.Subject)
+	}
+
+	env.PayloadJSON = "not json"
+	if _, err := (CloudEventsCodec{}).Encode(env); err == nil {
+		t.Error("Encode accepted a payload that is not JSON")
+	}
+}
+
+// Cada codec decodifica también el otro formato: un consumidor acepta
+// envelopes y CloudEvents sin importar EVENT_FORMAT.
+func TestCodecsDecodeEitherFormat(t *testing.T) {
+	env := codecTestEnvelope()
+	envelopeBody, _ := EnvelopeCodec{}.Encode(env)
+	cloudBody, _ := CloudEventsCodec{Source: "urn:test"}.Encode(env)
+	for _, codec := range []Codec{EnvelopeCodec{}, CloudEventsCodec{}} {
+		for _, body := range [][]byte{envelopeBody, cloudBody} {
+			got, err := codec.Decode(body)
+			if err != nil || got.ID != env.ID || got.Type != env.Type {
+				t.Errorf("%T.Decode(%s) = %+v, %v", codec, body, got, err)
+			}
+		}
+	}
+}
+
+func TestCloudEventsDecodeEdgeCases(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		wantErr bool
+		check   func(primitives.IntegrationEventEnvelope) bool
+	}{
+		{name: "future specversion", body: `{"specversion":"2.0","id":"x","type":"T"}`, wantErr: true},
+		{name: "missing type", body: `{"specversion":"1.0","id":"x"}`, wantErr: true},
+		{name: "not json", body: `{`, wantErr: true},
+		{
+			name: "non uuid id is derived from source and id",
+			body: `{"specversion":"1.0","id":"evt-42","source":"/catalog","type":"ProductCreated","data":{"sku":"A"}}`,
+			check: func(env primitives.IntegrationEventEnvelope) bool {
+				return env.ID == uuid.NewSHA1(cloudEventIDNamespace, []byte("/catalog/evt-42")) &&
+					env.Type == "ProductCreated" && env.PayloadJSON == `{"sku":"A"}`
+			},
+		},
+		{
+			name: "no data is an empty object",
+			body: `{"specversion":"1.0","id":"x","type":"Ping"}`,
+			check: func(env primitives.IntegrationEventEnvelope) bool {
+				return env.PayloadJSON == "{}" && env.GetRoutingKey() == "Ping"
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			env, err := CloudEventsCodec{}.Decode([]byte(tc.body))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Decode = %+v, want an error", env)
+				}
+				return
+			}
+			if err != nil || !tc.check(env) {
+				t.Errorf("Decode = %+v, %v", env, err)
+			}
+		})
+	}
+}
+
+// Una reentrega del mismo evento tiene el mismo envelope id; el mismo id
+// desde otro source es otro evento.
+func TestCloudEventsDecodeStableID(t *testing.T) {
+	decode := func(body string) uuid.UUID {
+		t.Helper()
+		env, err := CloudEventsCodec{}.Decode([]byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return env.ID
+	}
+	first := decode(`{"specversion":"1.0","id":"evt-42","source":"/catalog","type":"ProductCreated"}`)
+	again := decode(`{"specversion":"1.0","id":"evt-42","source":"/catalog","type":"ProductCreated"}`)
+	other := decode(`{"specversion":"1.0","id":"evt-42","source":"/orders","type":"ProductCreated"}`)
+	if first != again {
+		t.Errorf("redelivery got id %s, first delivery %s", again, first)
+	}
+	if first == other {
+		t.Errorf("sources /catalog and /orders share id %s", first)
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	if c, err := NewCodec("", ""); err != nil || c.ContentType() != "application/json" {
+		t.Errorf(`NewCodec("") = %T, %v`, c, err)
+	}
+	if c, err := NewCodec(FormatCloudEvents, "urn:x"); err != nil || c.ContentType() != "application/cloudevents+json" {
+		t.Errorf("NewCodec(cloudevents) = %T, %v", c, err)
+	}
+	if _, err := NewCodec("avro", ""); err == nil {
+		t.Error("NewCodec accepted an unknown format")
+	}
+}
+
+// withTraceContext usa el propagador W3C durante el test y devuelve un
+// contexto con un span remoto.
+func withTraceContext(t *testing.T) (context.Context, trace.SpanContext) {
+	t.Helper()
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc), sc
+}
+
+func TestRabbitPublishingHeaders(t *testing.T) {
+	ctx, sc := withTraceContext(t)
+	bus := newRabbitBus(rabbitOptions{ExchangeName: "inventory.events"}, CloudEventsCodec{})
+	env := codecTestEnvelope()
+
+	pub := bus.publishing(ctx, env, []byte("{}"))
+	if pub.ContentType != "application/cloudevents+json" || pub.MessageId != env.ID.String() || pub.Type != env.Type {
+		t.Errorf("publishing = %+v", pub)
+	}
+	if _, ok := pub.Headers["traceparent"].(string); !ok {
+		t.Fatalf("headers = %v, want traceparent", pub.Headers)
+	}
+
+	got := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(context.Background(), amqpHeaders(pub.Headers)))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Errorf("extracted span = %v, want %v", got, sc)
+	}
+}
+
+func TestRabbitProducerUsesConfiguredPrefix(t *testing.T) {
+	b := NewRabbitMqBroker("amqp://localhost:0/", 0, "inventory.dispatcher.v2", nil)
+	bus := b.Producer("inventory.events").(*rabbitBus)
+	if bus.opts.QueuePrefix != "inventory.dispatcher.v2" || bus.opts.ExchangeName != "inventory.events" {
+		t.Errorf("producer options = %+v", bus.opts)
+	}
+}
+
+func TestKafkaHeadersCarrier(t *testing.T) {
+	ctx, sc := withTraceContext(t)
+	msg := kafka.Message{Headers: []kafka.Header{{Key: "type", Value: []byte("StockReserved")}}}
+	carrier := kafkaHeaders{&msg}
+
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	carrier.Set("type", "StockReleased")
+	if carrier.Get("type") != "StockReleased" || len(msg.Headers) != 2 {
+		t.Errorf("headers = %v, want type replaced and traceparent added", msg.Headers)
+	}
+
+	got := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(context.Background(), carrier))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Errorf("extracted span = %v, want %v", got, sc)
+	}
+}
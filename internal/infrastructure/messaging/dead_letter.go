@@ -0,0 +1,61 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// DeadLetterPublisher publica los mensajes rechazados como "DeadLettered" en
+// el exchange de dead-letter. La routing key es el tipo original, para poder
+// bindear colas por tipo.
+type DeadLetterPublisher struct {
+	bus   abstractions.EventBus
+	clock domain.Clock
+	ids   domain.IDGenerator
+}
+
+func NewDeadLetterPublisher(bus abstractions.EventBus, clock domain.Clock, ids domain.IDGenerator) *DeadLetterPublisher {
+	return &DeadLetterPublisher{bus: bus, clock: clock, ids: ids}
+}
+
+type deadLetteredPayload struct {
+	EnvelopeID        uuid.UUID `json:"envelopeId"`
+	OriginalType      string    `json:"originalType"`
+	PayloadJSON       string    `json:"payloadJson"`
+	Reason            string    `json:"reason"`
+	DeadLetteredAtUtc time.Time `json:"deadLetteredAtUtc"`
+}
+
+func (p *DeadLetterPublisher) DeadLetter(
+	ctx context.Context,
+	env *primitives.IntegrationEventEnvelope,
+	reason error,
+) error {
+	at := p.clock.Now()
+	payload, err := json.Marshal(deadLetteredPayload{
+		EnvelopeID:        env.ID,
+		OriginalType:      env.Type,
+		PayloadJSON:       env.PayloadJSON,
+		Reason:            reason.Error(),
+		DeadLetteredAtUtc: at,
+	})
+	if err != nil {
+		return err
+	}
+
+	dl := primitives.NewIntegrationEventEnvelopeWith(
+		primitives.NewBaseEventWith(domain.NewEventMessage(p.ids.NewID(), at), ""),
+		"DeadLettered", string(payload), at,
+	)
+	dl.SetRoutingKey(env.Type)
+	dl.CorrelationID = env.CorrelationID
+	dl.CausationID = env.ID.String()
+	return p.bus.Publish(ctx, &dl)
+}
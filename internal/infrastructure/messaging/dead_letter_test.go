@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// captureBus guarda el último envelope publicado.
+type captureBus struct {
+	last *primitives.IntegrationEventEnvelope
+}
+
+func (b *captureBus) Publish(_ context.Context, ev primitives.Event) error {
+	b.last = ev.(*primitives.IntegrationEventEnvelope)
+	return nil
+}
+
+func (b *captureBus) Subscribe(string, abstractions.EventHandler) abstractions.EventBus { return b }
+
+func (b *captureBus) SendCommand(context.Context, primitives.Command) error { return nil }
+
+func TestDeadLetterUsesInjectedClockAndIDs(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := domain.NewSequentialIDGenerator(t.Name())
+	want := domain.NewSequentialIDGenerator(t.Name()).NewID()
+	bus := &captureBus{}
+	p := NewDeadLetterPublisher(bus, domain.NewManualClock(at, time.Second), ids)
+
+	env := testEnvelope("OrderPlacedEvent", `{"orderId":"o-1"}`)
+	if err := p.DeadLetter(context.Background(), env, errors.New("bad order")); err != nil {
+		t.Fatal(err)
+	}
+
+	dl := bus.last
+	if dl.Type != "DeadLettered" || dl.ID != want || !dl.OccurredAtUtc.Equal(at) || dl.RoutingKey != env.Type {
+		t.Fatalf("dead letter = %s %s@%s key %q", dl.Type, dl.ID, dl.OccurredAtUtc, dl.RoutingKey)
+	}
+	var payload deadLetteredPayload
+	if err := json.Unmarshal([]byte(dl.PayloadJSON), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if !payload.DeadLetteredAtUtc.Equal(at) || payload.EnvelopeID != env.ID || payload.Reason != "bad order" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
@@ -0,0 +1,352 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+// KafkaBroker publica y consume envelopes en topics de Kafka. El stream es el
+// topic (ej. "orders.events") y el group es el consumer group. Funciona contra
+// un broker local de un solo nodo (Kafka/Redpanda en docker) porque crea los
+// topics al publicar si no existen.
+type KafkaBroker struct {
+	brokers []string
+	codec   Codec
+
+	mu        sync.Mutex
+	buses     []*kafkaBus
+	producers map[string]*kafkaBus // uno por topic
+}
+
+// codec puede ser nil (EnvelopeCodec).
+func NewKafkaBroker(brokers []string, codec Codec) *KafkaBroker {
+	if codec == nil {
+		codec = EnvelopeCodec{}
+	}
+	return &KafkaBroker{brokers: brokers, codec: codec, producers: map[string]*kafkaBus{}}
+}
+
+func (b *KafkaBroker) Consumer(stream, group string) ConsumerBus {
+	bus := newKafkaBus(b.brokers, b.codec, stream)
+	bus.group = group
+
+	b.mu.Lock()
+	b.buses = append(b.buses, bus)
+	b.mu.Unlock()
+	return bus
+}
+
+// Producer devuelve siempre el mismo bus (y writer) para un topic.
+func (b *KafkaBroker) Producer(stream string) abstractions.EventBus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if bus, ok := b.producers[stream]; ok {
+		return bus
+	}
+	bus := newKafkaBus(b.brokers, b.codec, stream)
+	b.producers[stream] = bus
+	b.buses = append(b.buses, bus)
+	return bus
+}
+
+// Check verifica que haya un broker alcanzable y que los consumidores tengan
+// su reader activo.
+func (b *KafkaBroker) Check(ctx context.Context) error {
+	b.mu.Lock()
+	buses := append([]*kafkaBus(nil), b.buses...)
+	b.mu.Unlock()
+
+	for _, bus := range buses {
+		if bus.group != "" && !bus.consuming() {
+			return fmt.Errorf("kafka consumer %s on %s is not running", bus.group, bus.topic)
+		}
+	}
+
+	var lastErr error
+	for _, addr := range b.brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_ = conn.Close()
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no kafka brokers configured")
+	}
+	return lastErr
+}
+
+func (b *KafkaBroker) Drain(ctx context.Context) error {
+	b.mu.Lock()
+	buses := make([]ConsumerBus, 0, len(b.buses))
+	for _, bus := range b.buses {
+		if bus.group != "" {
+			buses = append(buses, bus)
+		}
+	}
+	b.mu.Unlock()
+	return drainAll(ctx, buses)
+}
+
+func (b *KafkaBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var firstErr error
+	for _, bus := range b.buses {
+		if err := bus.Stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// kafkaBus implementa ConsumerBus sobre un topic.
+type kafkaBus struct {
+	brokers []string
+	codec   Codec
+	topic   string
+	group   string
+
+	subsMu      sync.RWMutex
+	subscribers map[string][]abstractions.EventHandler
+
+	// writer se crea en newKafkaBus (no conecta hasta el primer mensaje), así
+	// Publish y Stop no compiten por inicializarlo.
+	writer kafkaWriter
+
+	readerMu sync.Mutex
+	reader   *kafka.Reader
+
+	// stopFetch cancela FetchMessage sin cancelar el handler en curso.
+	stopFetch context.CancelFunc
+	loops     sync.WaitGroup
+
+	redelivery time.Duration
+}
+
+// kafkaWriter es la parte de *kafka.Writer que usa el bus (en tests, un
+// writer en memoria).
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+func newKafkaBus(brokers []string, codec Codec, topic string) *kafkaBus {
+	return &kafkaBus{
+		brokers:     brokers,
+		codec:       codec,
+		topic:       topic,
+		subscribers: make(map[string][]abstractions.EventHandler),
+		redelivery:  redeliveryDelay,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+// Publish produce el envelope con key = orderId o sku del payload, para que
+// todos los eventos de una misma orden/SKU caigan en la misma partición.
+func (k *kafkaBus) Publish(ctx context.Context, event primitives.Event) error {
+	envelope, err := toEnvelope(event)
+	if err != nil {
+		return err
+	}
+
+	body, err := k.codec.Encode(envelope)
+	if err != nil {
+		return err
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(partitionKey(envelope)),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(k.codec.ContentType())},
+			{Key: "type", Value: []byte(envelope.Type)},
+			{Key: "routingKey", Value: []byte(envelope.GetRoutingKey())},
+		},
+	}
+	otel.GetTextMapPropagator().Inject(ctx, kafkaHeaders{&msg})
+	if err := k.writer.WriteMessages(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "kafka publish failed",
+			"eventType", envelope.Type, "topic", k.topic, "envelopeId", envelope.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (k *kafkaBus) Subscribe(eventName string, handler abstractions.EventHandler) abstractions.EventBus {
+	if handler == nil {
+		return k
+	}
+	k.subsMu.Lock()
+	defer k.subsMu.Unlock()
+	k.subscribers[eventName] = append(k.subscribers[eventName], handler)
+	return k
+}
+
+func (k *kafkaBus) SendCommand(ctx context.Context, cmd primitives.Command) error {
+	return fmt.Errorf("no CommandDispatcher configured for kafka bus")
+}
+
+// StartConsumers arranca un reader del consumer group. Los mensajes de una
+// partición se procesan en orden, uno a la vez.
+func (k *kafkaBus) StartConsumers(ctx context.Context) error {
+	if k.group == "" {
+		return fmt.Errorf("kafka bus for %s is a producer, not a consumer", k.topic)
+	}
+
+	k.readerMu.Lock()
+	defer k.readerMu.Unlock()
+	if k.reader != nil {
+		return nil
+	}
+	k.reader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  k.brokers,
+		GroupID:  k.group,
+		Topic:    k.topic,
+		MinBytes: 1,
+		MaxBytes: 10e6,
+	})
+
+	fetchCtx, stopFetch := context.WithCancel(ctx)
+	k.stopFetch = stopFetch
+
+	slog.Info("kafka consumer started", "topic", k.topic, "group", k.group)
+	k.loops.Add(1)
+	go k.consumeLoop(ctx, fetchCtx, k.reader)
+	return nil
+}
+
+func (k *kafkaBus) consuming() bool {
+	k.readerMu.Lock()
+	defer k.readerMu.Unlock()
+	return k.reader != nil
+}
+
+// Drain deja de pedir mensajes y espera a que termine el que está en curso
+// (incluido su commit).
+func (k *kafkaBus) Drain(ctx context.Context) error {
+	k.readerMu.Lock()
+	if k.stopFetch != nil {
+		k.stopFetch()
+	}
+	k.readerMu.Unlock()
+	return waitGroup(ctx, &k.loops)
+}
+
+func (k *kafkaBus) Stop() error {
+	var firstErr error
+
+	k.readerMu.Lock()
+	if k.reader != nil {
+		if err := k.reader.Close(); err != nil {
+			firstErr = err
+		}
+		k.reader = nil
+	}
+	k.readerMu.Unlock()
+
+	if err := k.writer.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+func (k *kafkaBus) consumeLoop(ctx, fetchCtx context.Context, reader *kafka.Reader) {
+	defer k.loops.Done()
+	for {
+		m, err := reader.FetchMessage(fetchCtx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, io.EOF) {
+				slog.Info("kafka consumer stopped", "topic", k.topic)
+				return
+			}
+			slog.Error("kafka fetch failed", "topic", k.topic, "error", err)
+			select {
+			case <-fetchCtx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		if !k.handleUntilDone(ctx, fetchCtx, m) {
+			// Sin commit: el grupo vuelve a entregar el mensaje.
+			slog.Info("kafka consumer stopped with a message pending", "topic", k.topic, "offset", m.Offset)
+			return
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			slog.Error("kafka commit failed", "topic", k.topic, "offset", m.Offset, "error", err)
+		}
+	}
+}
+
+// handleUntilDone reintenta m hasta que los handlers terminen ok. Kafka no
+// tiene Nack: commitear un offset posterior confirma también éste, así que
+// la partición espera al mensaje. Devuelve false si stop se cancela antes.
+func (k *kafkaBus) handleUntilDone(ctx, stop context.Context, m kafka.Message) bool {
+	for k.handle(ctx, m) != nil {
+		select {
+		case <-stop.Done():
+			return false
+		case <-time.After(k.redelivery):
+		}
+	}
+	return true
+}
+
+// handle devuelve el error del primer handler que falla. Un mensaje que no
+// decodifica se descarta: reintentarlo no lo arregla.
+func (k *kafkaBus) handle(ctx context.Context, m kafka.Message) error {
+	envelope, err := k.codec.Decode(m.Value)
+	if err != nil {
+		slog.ErrorContext(ctx, "kafka message could not be decoded", "topic", k.topic, "offset", m.Offset, "error", err)
+		return nil
+	}
+
+	eventName := envelope.GetRoutingKey()
+	if eventName == "" {
+		eventName = envelope.Type
+	}
+
+	k.subsMu.RLock()
+	handlers := append([]abstractions.EventHandler(nil), k.subscribers[eventName]...)
+	k.subsMu.RUnlock()
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, kafkaHeaders{&m})
+	ctx = envelopeContext(ctx, &envelope)
+	for _, h := range handlers {
+		if err := h.Handle(ctx, &envelope); err != nil {
+			slog.ErrorContext(ctx, "kafka handler failed, retrying",
+				"topic", k.topic, "offset", m.Offset, "handler", fmt.Sprintf("%T", h), "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// partitionKey usa orderId o sku del payload; si no hay, el id del envelope.
+func partitionKey(env primitives.IntegrationEventEnvelope) string {
+	if subject := subjectOf(env); subject != "" {
+		return subject
+	}
+	return env.ID.String()
+}
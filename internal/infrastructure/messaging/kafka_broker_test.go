@@ -0,0 +1,188 @@
+package messaging
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaTestBrokersEnv apunta a un Kafka/Redpanda local (ej. localhost:9092);
+// sin él el test de integración se salta.
+const kafkaTestBrokersEnv = "KAFKA_TEST_BROKERS"
+
+// fakeKafkaWriter guarda los mensajes en vez de mandarlos al broker.
+type fakeKafkaWriter struct {
+	mu     sync.Mutex
+	msgs   []kafka.Message
+	closed bool
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+type handlerFunc func(ctx context.Context, event primitives.Event) error
+
+func (f handlerFunc) Handle(ctx context.Context, event primitives.Event) error { return f(ctx, event) }
+
+func testEnvelope(eventType, payload string) *primitives.IntegrationEventEnvelope {
+	env := primitives.NewIntegrationEventEnvelope(eventType, payload)
+	return &env
+}
+
+func TestKafkaPublishKeysAndHeaders(t *testing.T) {
+	bus := newKafkaBus([]string{"localhost:0"}, CloudEventsCodec{Source: "/inventory"}, "inventory.events")
+	writer := &fakeKafkaWriter{}
+	bus.writer = writer
+
+	orderID := uuid.New()
+	events := []*primitives.IntegrationEventEnvelope{
+		testEnvelope("StockReserved", `{"orderId":"`+orderID.String()+`","lines":[]}`),
+		testEnvelope("CatalogStockAdjusted", `{"sku":"SKU-1","available":3}`),
+		testEnvelope("Heartbeat", `{}`),
+	}
+	for _, ev := range events {
+		if err := bus.Publish(context.Background(), ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(writer.msgs) != 3 {
+		t.Fatalf("messages = %d, want 3", len(writer.msgs))
+	}
+	wantKeys := []string{orderID.String(), "SKU-1", events[2].ID.String()}
+	for i, msg := range writer.msgs {
+		if string(msg.Key) != wantKeys[i] {
+			t.Errorf("message %d key = %q, want %q", i, msg.Key, wantKeys[i])
+		}
+		h := kafkaHeaders{&writer.msgs[i]}
+		if h.Get("content-type") != "application/cloudevents+json" || h.Get("type") != events[i].Type || h.Get("routingKey") != events[i].Type {
+			t.Errorf("message %d headers = %v", i, msg.Headers)
+		}
+		env, err := bus.codec.Decode(msg.Value)
+		if err != nil || env.ID != events[i].ID || env.PayloadJSON != events[i].PayloadJSON {
+			t.Errorf("message %d decodes to %+v, %v", i, env, err)
+		}
+	}
+}
+
+func TestKafkaHandleDispatchesByRoutingKey(t *testing.T) {
+	bus := newKafkaBus(nil, EnvelopeCodec{}, "orders.events")
+	var got []string
+	bus.Subscribe("OrderPlaced", handlerFunc(func(_ context.Context, event primitives.Event) error {
+		got = append(got, event.(*primitives.IntegrationEventEnvelope).PayloadJSON)
+		return nil
+	}))
+
+	for _, ev := range []*primitives.IntegrationEventEnvelope{
+		testEnvelope("OrderPlaced", `{"orderId":"a"}`),
+		testEnvelope("OrderCancelled", `{"orderId":"b"}`),
+	} {
+		ev.SetRoutingKey(ev.Type)
+		body, err := bus.codec.Encode(*ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bus.handle(context.Background(), kafka.Message{Value: body})
+	}
+	// Un mensaje que no decodifica se descarta sin llamar handlers.
+	bus.handle(context.Background(), kafka.Message{Value: []byte("not json")})
+
+	if len(got) != 1 || got[0] != `{"orderId":"a"}` {
+		t.Errorf("handled = %v", got)
+	}
+}
+
+func TestKafkaProducerIsCachedPerTopic(t *testing.T) {
+	b := NewKafkaBroker([]string{"localhost:0"}, nil)
+	first := b.Producer("inventory.events")
+	if b.Producer("inventory.events") != first {
+		t.Error("Producer created a second bus for the same topic")
+	}
+	if b.Producer("other.events") == first {
+		t.Error("Producer reused the bus of another topic")
+	}
+	if len(b.buses) != 2 {
+		t.Errorf("buses = %d, want 2", len(b.buses))
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Con -race, detecta carreras entre el primer Publish y Stop.
+func TestKafkaPublishAndStopConcurrently(t *testing.T) {
+	bus := newKafkaBus([]string{"localhost:0"}, EnvelopeCodec{}, "inventory.events")
+	writer := &fakeKafkaWriter{}
+	bus.writer = writer
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = bus.Publish(context.Background(), testEnvelope("Heartbeat", `{}`))
+		}()
+	}
+	if err := bus.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if !writer.closed {
+		t.Error("Stop did not close the writer")
+	}
+}
+
+// TestKafkaRoundTrip publica y consume contra un broker real de un nodo.
+func TestKafkaRoundTrip(t *testing.T) {
+	addrs := os.Getenv(kafkaTestBrokersEnv)
+	if addrs == "" {
+		t.Skipf("%s not set", kafkaTestBrokersEnv)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	topic := "inventory-test-" + uuid.NewString()
+	b := NewKafkaBroker(strings.Split(addrs, ","), nil)
+	defer b.Close()
+
+	received := make(chan string, 1)
+	consumer := b.Consumer(topic, "inventory-test")
+	consumer.Subscribe("StockReserved", handlerFunc(func(_ context.Context, event primitives.Event) error {
+		received <- event.(*primitives.IntegrationEventEnvelope).PayloadJSON
+		return nil
+	}))
+
+	payload := `{"orderId":"` + uuid.NewString() + `"}`
+	if err := b.Producer(topic).Publish(ctx, testEnvelope("StockReserved", payload)); err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.StartConsumers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if got != payload {
+			t.Errorf("payload = %s, want %s", got, payload)
+		}
+	case <-ctx.Done():
+		t.Fatal("message not consumed")
+	}
+}
@@ -0,0 +1,246 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// memoryQueueSize es el buffer por consumidor antes de que Publish bloquee.
+const memoryQueueSize = 1024
+
+// MemoryBroker es un bus in-process para desarrollo local y pruebas de
+// integración (sin RabbitMQ). Los mensajes se serializan igual que en el
+// broker real, así que los handlers reciben un IntegrationEventEnvelope.
+type MemoryBroker struct {
+	codec      Codec
+	redelivery time.Duration
+
+	mu        sync.RWMutex
+	consumers map[string][]*memoryBus // stream -> buses consumidores
+}
+
+// codec puede ser nil (EnvelopeCodec).
+func NewMemoryBroker(codec Codec) *MemoryBroker {
+	if codec == nil {
+		codec = EnvelopeCodec{}
+	}
+	return &MemoryBroker{
+		codec:      codec,
+		redelivery: redeliveryDelay,
+		consumers:  make(map[string][]*memoryBus),
+	}
+}
+
+func (b *MemoryBroker) Consumer(stream, group string) ConsumerBus {
+	bus := newMemoryBus(b, stream)
+	bus.queue = make(chan memoryMessage, memoryQueueSize)
+
+	b.mu.Lock()
+	b.consumers[stream] = append(b.consumers[stream], bus)
+	b.mu.Unlock()
+	return bus
+}
+
+func (b *MemoryBroker) Producer(stream string) abstractions.EventBus {
+	return newMemoryBus(b, stream)
+}
+
+// Check siempre está ok: el bus vive en el proceso.
+func (b *MemoryBroker) Check(ctx context.Context) error {
+	return nil
+}
+
+func (b *MemoryBroker) Drain(ctx context.Context) error {
+	b.mu.RLock()
+	var buses []ConsumerBus
+	for _, stream := range b.consumers {
+		for _, bus := range stream {
+			buses = append(buses, bus)
+		}
+	}
+	b.mu.RUnlock()
+	return drainAll(ctx, buses)
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, buses := range b.consumers {
+		for _, bus := range buses {
+			_ = bus.Stop()
+		}
+	}
+	b.consumers = make(map[string][]*memoryBus)
+	return nil
+}
+
+// deliver encola el envelope en cada consumidor del stream que tenga
+// handlers para su routing key.
+func (b *MemoryBroker) deliver(ctx context.Context, stream string, env primitives.IntegrationEventEnvelope) error {
+	headers := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+
+	b.mu.RLock()
+	buses := append([]*memoryBus(nil), b.consumers[stream]...)
+	b.mu.RUnlock()
+
+	for _, bus := range buses {
+		if !bus.hasHandlers(env.GetRoutingKey()) {
+			continue
+		}
+		select {
+		case bus.queue <- memoryMessage{env: env, headers: headers}:
+		case <-bus.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// memoryMessage es lo que viaja por la cola: envelope + headers de traza.
+type memoryMessage struct {
+	env     primitives.IntegrationEventEnvelope
+	headers propagation.MapCarrier
+}
+
+// memoryBus implementa ConsumerBus sobre MemoryBroker.
+type memoryBus struct {
+	broker *MemoryBroker
+	stream string
+
+	subsMu      sync.RWMutex
+	subscribers map[string][]abstractions.EventHandler
+
+	queue     chan memoryMessage
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	loops     sync.WaitGroup
+}
+
+func newMemoryBus(broker *MemoryBroker, stream string) *memoryBus {
+	return &memoryBus{
+		broker:      broker,
+		stream:      stream,
+		subscribers: make(map[string][]abstractions.EventHandler),
+		done:        make(chan struct{}),
+	}
+}
+
+func (m *memoryBus) Publish(ctx context.Context, event primitives.Event) error {
+	envelope, err := toEnvelope(event)
+	if err != nil {
+		return err
+	}
+
+	// Round-trip por el codec, igual que en el cable; además evita compartir
+	// memoria con el publicador.
+	body, err := m.broker.codec.Encode(envelope)
+	if err != nil {
+		return err
+	}
+	copied, err := m.broker.codec.Decode(body)
+	if err != nil {
+		return err
+	}
+
+	return m.broker.deliver(ctx, m.stream, copied)
+}
+
+func (m *memoryBus) Subscribe(eventName string, handler abstractions.EventHandler) abstractions.EventBus {
+	if handler == nil {
+		return m
+	}
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+	m.subscribers[eventName] = append(m.subscribers[eventName], handler)
+	return m
+}
+
+func (m *memoryBus) SendCommand(ctx context.Context, cmd primitives.Command) error {
+	return fmt.Errorf("no CommandDispatcher configured for memory bus")
+}
+
+func (m *memoryBus) StartConsumers(ctx context.Context) error {
+	if m.queue == nil {
+		return fmt.Errorf("memory bus for %s is a producer, not a consumer", m.stream)
+	}
+	m.startOnce.Do(func() {
+		m.loops.Add(1)
+		go m.consumeLoop(ctx)
+	})
+	return nil
+}
+
+// Drain descarta lo que quede en la cola (es in-process, no hay redelivery)
+// y espera al handler en curso.
+func (m *memoryBus) Drain(ctx context.Context) error {
+	_ = m.Stop()
+	return waitGroup(ctx, &m.loops)
+}
+
+func (m *memoryBus) Stop() error {
+	m.stopOnce.Do(func() { close(m.done) })
+	return nil
+}
+
+func (m *memoryBus) hasHandlers(eventName string) bool {
+	m.subsMu.RLock()
+	defer m.subsMu.RUnlock()
+	return len(m.subscribers[eventName]) > 0
+}
+
+func (m *memoryBus) consumeLoop(ctx context.Context) {
+	defer m.loops.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.done:
+			return
+		case msg := <-m.queue:
+			msgCtx := otel.GetTextMapPropagator().Extract(ctx, msg.headers)
+			// Como el requeue de RabbitMQ: si un handler falla el mensaje se
+			// vuelve a entregar hasta que pase o el bus se detenga.
+			for m.handle(msgCtx, msg.env) != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-m.done:
+					return
+				case <-time.After(m.broker.redelivery):
+				}
+			}
+		}
+	}
+}
+
+// handle ejecuta los handlers en orden y devuelve el error del primero que
+// falla.
+func (m *memoryBus) handle(ctx context.Context, env primitives.IntegrationEventEnvelope) error {
+	eventName := env.GetRoutingKey()
+
+	m.subsMu.RLock()
+	handlers := append([]abstractions.EventHandler(nil), m.subscribers[eventName]...)
+	m.subsMu.RUnlock()
+
+	ctx = envelopeContext(ctx, &env)
+	for _, h := range handlers {
+		if err := h.Handle(ctx, &env); err != nil {
+			slog.ErrorContext(ctx, "memory bus handler failed, redelivering",
+				"stream", m.stream, "handler", fmt.Sprintf("%T", h), "error", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+)
+
+// RabbitMqBroker crea buses sobre exchanges topic de RabbitMQ.
+type RabbitMqBroker struct {
+	uri            string
+	prefetch       int
+	producerPrefix string
+	codec          Codec
+
+	mu        sync.Mutex
+	producers map[string]*rabbitBus
+	consumers []*rabbitBus
+}
+
+// producerPrefix es el prefijo de cola de los Producer; codec puede ser nil
+// (EnvelopeCodec).
+func NewRabbitMqBroker(uri string, prefetch int, producerPrefix string, codec Codec) *RabbitMqBroker {
+	if codec == nil {
+		codec = EnvelopeCodec{}
+	}
+	return &RabbitMqBroker{
+		uri:            uri,
+		prefetch:       prefetch,
+		producerPrefix: producerPrefix,
+		codec:          codec,
+		producers:      make(map[string]*rabbitBus),
+	}
+}
+
+func (b *RabbitMqBroker) options(exchange, queuePrefix string) rabbitOptions {
+	return rabbitOptions{
+		URI:          b.uri,
+		ExchangeName: exchange,
+		QueuePrefix:  queuePrefix,
+		Prefetch:     b.prefetch,
+	}
+}
+
+func (b *RabbitMqBroker) Consumer(stream, group string) ConsumerBus {
+	bus := newRabbitBus(b.options(stream, group), b.codec)
+
+	b.mu.Lock()
+	b.consumers = append(b.consumers, bus)
+	b.mu.Unlock()
+	return bus
+}
+
+// Producer reutiliza un bus por exchange.
+func (b *RabbitMqBroker) Producer(stream string) abstractions.EventBus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if bus, ok := b.producers[stream]; ok {
+		return bus
+	}
+	bus := newRabbitBus(b.options(stream, b.producerPrefix), b.codec)
+	b.producers[stream] = bus
+	return bus
+}
+
+// Check verifica que la conexión de cada consumidor siga abierta. El bus no
+// reconecta consumidores, así que una conexión cerrada deja de recibir mensajes.
+func (b *RabbitMqBroker) Check(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, bus := range b.consumers {
+		if !bus.connected() {
+			return fmt.Errorf("rabbitmq consumer %s on %s is not connected", bus.opts.QueuePrefix, bus.opts.ExchangeName)
+		}
+	}
+	return nil
+}
+
+func (b *RabbitMqBroker) Drain(ctx context.Context) error {
+	b.mu.Lock()
+	buses := make([]ConsumerBus, 0, len(b.consumers))
+	for _, bus := range b.consumers {
+		buses = append(buses, bus)
+	}
+	b.mu.Unlock()
+	return drainAll(ctx, buses)
+}
+
+func (b *RabbitMqBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var firstErr error
+	for _, bus := range b.consumers {
+		if err := bus.Stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, bus := range b.producers {
+		if err := bus.Stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
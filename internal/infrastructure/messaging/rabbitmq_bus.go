@@ -0,0 +1,285 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"go.opentelemetry.io/otel"
+)
+
+// rabbitOptions es la configuración de un bus sobre un exchange topic.
+type rabbitOptions struct {
+	URI          string
+	ExchangeName string
+	QueuePrefix  string
+	Prefetch     int
+}
+
+// rabbitBus implementa ConsumerBus sobre RabbitMQ con la misma topología que
+// RabbitMqEventBus de la librería (exchange topic, una cola durable por
+// evento "<prefix>.<evento>"), pero serializando con un Codec.
+type rabbitBus struct {
+	opts  rabbitOptions
+	codec Codec
+
+	subsMu      sync.RWMutex
+	subscribers map[string][]abstractions.EventHandler
+
+	connMu sync.Mutex
+	conn   *amqp.Connection
+	ch     *amqp.Channel
+
+	// draining se cierra en Drain; loops cuenta los consumeLoop vivos.
+	drainOnce sync.Once
+	draining  chan struct{}
+	loops     sync.WaitGroup
+}
+
+func newRabbitBus(opts rabbitOptions, codec Codec) *rabbitBus {
+	return &rabbitBus{
+		opts:        opts,
+		codec:       codec,
+		subscribers: make(map[string][]abstractions.EventHandler),
+		draining:    make(chan struct{}),
+	}
+}
+
+func (b *rabbitBus) Publish(ctx context.Context, event primitives.Event) error {
+	envelope, err := toEnvelope(event)
+	if err != nil {
+		return err
+	}
+
+	body, err := b.codec.Encode(envelope)
+	if err != nil {
+		slog.ErrorContext(ctx, "rabbitmq encode failed", "eventType", envelope.Type, "error", err)
+		return err
+	}
+
+	ch, err := b.channel()
+	if err != nil {
+		return err
+	}
+
+	if err := ch.PublishWithContext(
+		ctx,
+		b.opts.ExchangeName,
+		envelope.GetRoutingKey(),
+		false,
+		false,
+		b.publishing(ctx, envelope, body),
+	); err != nil {
+		slog.ErrorContext(ctx, "rabbitmq publish failed",
+			"eventType", envelope.Type, "exchange", b.opts.ExchangeName, "envelopeId", envelope.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// publishing arma el mensaje AMQP: content type del codec, id y tipo del
+// envelope en las propiedades y el contexto de traza en los headers.
+func (b *rabbitBus) publishing(ctx context.Context, envelope primitives.IntegrationEventEnvelope, body []byte) amqp.Publishing {
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, amqpHeaders(headers))
+	return amqp.Publishing{
+		Headers:      headers,
+		ContentType:  b.codec.ContentType(),
+		DeliveryMode: amqp.Persistent,
+		MessageId:    envelope.ID.String(),
+		Type:         envelope.Type,
+		Body:         body,
+	}
+}
+
+func (b *rabbitBus) Subscribe(eventName string, handler abstractions.EventHandler) abstractions.EventBus {
+	if handler == nil {
+		return b
+	}
+	b.subsMu.Lock()
+	defer b.subsMu.Unlock()
+	b.subscribers[eventName] = append(b.subscribers[eventName], handler)
+	return b
+}
+
+func (b *rabbitBus) SendCommand(ctx context.Context, cmd primitives.Command) error {
+	return fmt.Errorf("no CommandDispatcher configured for rabbitmq bus")
+}
+
+// StartConsumers declara y bindea una cola por evento suscrito y arranca un
+// consumidor por cola.
+func (b *rabbitBus) StartConsumers(ctx context.Context) error {
+	ch, err := b.channel()
+	if err != nil {
+		return err
+	}
+
+	b.subsMu.RLock()
+	defer b.subsMu.RUnlock()
+
+	for eventName, handlers := range b.subscribers {
+		if len(handlers) == 0 {
+			continue
+		}
+
+		queueName := fmt.Sprintf("%s.%s", b.opts.QueuePrefix, eventName)
+		if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+			slog.Error("rabbitmq queue declare failed", "queue", queueName, "error", err)
+			return err
+		}
+		if err := ch.QueueBind(queueName, eventName, b.opts.ExchangeName, false, nil); err != nil {
+			slog.Error("rabbitmq queue bind failed",
+				"queue", queueName, "exchange", b.opts.ExchangeName, "error", err)
+			return err
+		}
+
+		deliveries, err := ch.Consume(queueName, "", false, false, false, false, nil)
+		if err != nil {
+			slog.Error("rabbitmq consume failed", "queue", queueName, "error", err)
+			return err
+		}
+
+		slog.Info("rabbitmq consumer started",
+			"queue", queueName, "exchange", b.opts.ExchangeName, "routingKey", eventName)
+		b.loops.Add(1)
+		go b.consumeLoop(ctx, eventName, deliveries)
+	}
+	return nil
+}
+
+// Drain corta los consumeLoop después del mensaje en curso. Los mensajes ya
+// entregados por prefetch quedan sin Ack y RabbitMQ los reencola al cerrar
+// la conexión en Stop.
+func (b *rabbitBus) Drain(ctx context.Context) error {
+	b.drainOnce.Do(func() { close(b.draining) })
+	return waitGroup(ctx, &b.loops)
+}
+
+func (b *rabbitBus) Stop() error {
+	b.connMu.Lock()
+	defer b.connMu.Unlock()
+
+	var firstErr error
+	if b.ch != nil {
+		if err := b.ch.Close(); err != nil {
+			firstErr = err
+		}
+		b.ch = nil
+	}
+	if b.conn != nil {
+		if err := b.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		b.conn = nil
+	}
+	return firstErr
+}
+
+// connected indica si la conexión y el canal siguen abiertos.
+func (b *rabbitBus) connected() bool {
+	b.connMu.Lock()
+	defer b.connMu.Unlock()
+	return b.conn != nil && !b.conn.IsClosed() && b.ch != nil && !b.ch.IsClosed()
+}
+
+// channel abre (o reabre) la conexión, el canal y declara el exchange.
+func (b *rabbitBus) channel() (*amqp.Channel, error) {
+	b.connMu.Lock()
+	defer b.connMu.Unlock()
+
+	if b.conn != nil && !b.conn.IsClosed() && b.ch != nil && !b.ch.IsClosed() {
+		return b.ch, nil
+	}
+	if b.ch != nil {
+		_ = b.ch.Close()
+		b.ch = nil
+	}
+	if b.conn != nil {
+		_ = b.conn.Close()
+		b.conn = nil
+	}
+
+	conn, err := amqp.Dial(b.opts.URI)
+	if err != nil {
+		slog.Error("rabbitmq dial failed", "exchange", b.opts.ExchangeName, "error", err)
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(b.opts.ExchangeName, "topic", true, false, false, false, nil); err != nil {
+		_ = ch.Close()
+		_ = conn.Close()
+		slog.Error("rabbitmq exchange declare failed", "exchange", b.opts.ExchangeName, "error", err)
+		return nil, err
+	}
+	if b.opts.Prefetch > 0 {
+		if err := ch.Qos(b.opts.Prefetch, 0, false); err != nil {
+			_ = ch.Close()
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	b.conn = conn
+	b.ch = ch
+	slog.Info("rabbitmq connected", "exchange", b.opts.ExchangeName)
+	return ch, nil
+}
+
+func (b *rabbitBus) consumeLoop(ctx context.Context, eventName string, deliveries <-chan amqp.Delivery) {
+	defer b.loops.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("rabbitmq consumer stopped", "eventName", eventName)
+			return
+		case <-b.draining:
+			slog.Info("rabbitmq consumer drained", "eventName", eventName)
+			return
+		case d, ok := <-deliveries:
+			if !ok {
+				slog.Warn("rabbitmq delivery channel closed", "eventName", eventName)
+				return
+			}
+			b.handleDelivery(ctx, eventName, d)
+		}
+	}
+}
+
+// handleDelivery decodifica (envelope o CloudEvents) y manda a los handlers.
+// Si todos terminan ok se hace Ack; si uno falla, Nack con requeue. Un
+// mensaje que no decodifica no se arregla reentregándolo: Nack sin requeue.
+func (b *rabbitBus) handleDelivery(ctx context.Context, eventName string, d amqp.Delivery) {
+	envelope, err := b.codec.Decode(d.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "rabbitmq message could not be decoded, nack without requeue",
+			"eventName", eventName, "messageId", d.MessageId, "error", err)
+		_ = d.Nack(false, false)
+		return
+	}
+
+	b.subsMu.RLock()
+	handlers := append([]abstractions.EventHandler(nil), b.subscribers[eventName]...)
+	b.subsMu.RUnlock()
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, amqpHeaders(d.Headers))
+	ctx = envelopeContext(ctx, &envelope)
+	for _, h := range handlers {
+		if err := h.Handle(ctx, &envelope); err != nil {
+			slog.ErrorContext(ctx, "rabbitmq handler failed, nack with requeue",
+				"handler", fmt.Sprintf("%T", h), "error", err)
+			_ = d.Nack(false, true)
+			return
+		}
+	}
+	if err := d.Ack(false); err != nil {
+		slog.ErrorContext(ctx, "rabbitmq ack failed", "error", err)
+	}
+}
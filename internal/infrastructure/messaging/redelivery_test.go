@@ -0,0 +1,163 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+// poisonFixture es un RetryingHandler cuyo handler falla siempre con un
+// error permanente y cuya cuarentena está caída hasta que se limpie el
+// FailOn: el caso en que Reject falla y el bus tiene que reentregar.
+type poisonFixture struct {
+	store   *memory.Store
+	repo    *memory.QuarantineRepository
+	calls   atomic.Int32
+	handler *application.RetryingHandler
+}
+
+func newPoisonFixture(t *testing.T) *poisonFixture {
+	t.Helper()
+	f := &poisonFixture{store: memory.NewStore()}
+	f.repo = memory.NewQuarantineRepository(f.store)
+	f.store.FailOn("QuarantineRepository.Insert", errors.New("database is down"))
+	quarantine := application.NewQuarantineService(f.repo, nil,
+		domain.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Second),
+		domain.NewSequentialIDGenerator(t.Name()))
+	inner := handlerFunc(func(context.Context, primitives.Event) error {
+		f.calls.Add(1)
+		return application.Permanent(errors.New("bad order"))
+	})
+	f.handler = application.NewRetryingHandler(inner, application.RetryPolicy{MaxAttempts: 1}, quarantine)
+	return f
+}
+
+func (f *poisonFixture) quarantined(t *testing.T) int {
+	t.Helper()
+	msgs, err := f.repo.List(context.Background(), false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(msgs)
+}
+
+// fakeAcknowledger registra cómo terminó una entrega de RabbitMQ.
+type fakeAcknowledger struct {
+	acks, nacks, requeues int
+}
+
+func (a *fakeAcknowledger) Ack(uint64, bool) error { a.acks++; return nil }
+
+func (a *fakeAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.nacks++
+	if requeue {
+		a.requeues++
+	}
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(_ uint64, requeue bool) error { return a.Nack(0, false, requeue) }
+
+func TestRabbitRequeuesWhenQuarantineFails(t *testing.T) {
+	f := newPoisonFixture(t)
+	bus := newRabbitBus(rabbitOptions{}, EnvelopeCodec{})
+	bus.Subscribe("OrderPlacedEvent", f.handler)
+	body, err := bus.codec.Encode(*testEnvelope("OrderPlacedEvent", `{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ack := &fakeAcknowledger{}
+	bus.handleDelivery(context.Background(), "OrderPlacedEvent", amqp.Delivery{Acknowledger: ack, Body: body})
+	if ack.requeues != 1 || ack.acks != 0 {
+		t.Fatalf("quarantine down: acks %d, nacks %d, requeues %d; want one requeue", ack.acks, ack.nacks, ack.requeues)
+	}
+
+	f.store.FailOn("QuarantineRepository.Insert", nil)
+	bus.handleDelivery(context.Background(), "OrderPlacedEvent", amqp.Delivery{Acknowledger: ack, Body: body})
+	if ack.acks != 1 || f.quarantined(t) != 1 {
+		t.Errorf("after recovery: acks %d, quarantined %d; want 1 and 1", ack.acks, f.quarantined(t))
+	}
+
+	// Lo que no decodifica no se reentrega.
+	ack = &fakeAcknowledger{}
+	bus.handleDelivery(context.Background(), "OrderPlacedEvent", amqp.Delivery{Acknowledger: ack, Body: []byte("not json")})
+	if ack.nacks != 1 || ack.requeues != 0 {
+		t.Errorf("undecodable: nacks %d, requeues %d; want 1 and 0", ack.nacks, ack.requeues)
+	}
+}
+
+func TestKafkaRetriesWhenQuarantineFails(t *testing.T) {
+	f := newPoisonFixture(t)
+	bus := newKafkaBus(nil, EnvelopeCodec{}, "orders.events")
+	bus.redelivery = time.Millisecond
+	bus.Subscribe("OrderPlacedEvent", f.handler)
+	env := testEnvelope("OrderPlacedEvent", `{}`)
+	env.SetRoutingKey(env.Type)
+	body, err := bus.codec.Encode(*env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := kafka.Message{Value: body}
+
+	// Detenido mientras la cuarentena sigue caída: no hay commit.
+	stop, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if bus.handleUntilDone(context.Background(), stop, m) {
+		t.Fatal("handleUntilDone = true with the quarantine down, the offset would be committed")
+	}
+	if f.calls.Load() < 2 {
+		t.Errorf("handler ran %d times, want it retried", f.calls.Load())
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { f.store.FailOn("QuarantineRepository.Insert", nil) })
+	if !bus.handleUntilDone(context.Background(), context.Background(), m) || f.quarantined(t) != 1 {
+		t.Errorf("after recovery: quarantined %d, want 1", f.quarantined(t))
+	}
+}
+
+func TestMemoryBrokerRedeliversWhenQuarantineFails(t *testing.T) {
+	f := newPoisonFixture(t)
+	broker := NewMemoryBroker(nil)
+	broker.redelivery = time.Millisecond
+	defer broker.Close()
+
+	consumer := broker.Consumer("orders.events", "inventory")
+	consumer.Subscribe("OrderPlacedEvent", f.handler)
+	if err := consumer.StartConsumers(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	env := testEnvelope("OrderPlacedEvent", `{}`)
+	env.SetRoutingKey(env.Type)
+	if err := broker.Producer("orders.events").Publish(context.Background(), env); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { return f.calls.Load() >= 3 })
+	if n := f.quarantined(t); n != 0 {
+		t.Fatalf("quarantined %d with the quarantine down", n)
+	}
+	f.store.FailOn("QuarantineRepository.Insert", nil)
+	waitFor(t, func() bool { return f.quarantined(t) == 1 })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,59 @@
+package messaging
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+)
+
+// amqpHeaders adapta los headers AMQP a propagation.TextMapCarrier para
+// propagar traceparent/tracestate.
+type amqpHeaders amqp.Table
+
+func (h amqpHeaders) Get(key string) string {
+	v, _ := h[key].(string)
+	return v
+}
+
+func (h amqpHeaders) Set(key, value string) {
+	h[key] = value
+}
+
+func (h amqpHeaders) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// kafkaHeaders adapta los headers de un kafka.Message.
+type kafkaHeaders struct {
+	msg *kafka.Message
+}
+
+func (h kafkaHeaders) Get(key string) string {
+	for _, hdr := range h.msg.Headers {
+		if hdr.Key == key {
+			return string(hdr.Value)
+		}
+	}
+	return ""
+}
+
+func (h kafkaHeaders) Set(key, value string) {
+	for i, hdr := range h.msg.Headers {
+		if hdr.Key == key {
+			h.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	h.msg.Headers = append(h.msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (h kafkaHeaders) Keys() []string {
+	keys := make([]string, 0, len(h.msg.Headers))
+	for _, hdr := range h.msg.Headers {
+		keys = append(keys, hdr.Key)
+	}
+	return keys
+}
@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/application"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/config"
+)
+
+type EventBusPair struct {
+	OrdersConsumer ConsumerBus
+	Producer       abstractions.EventBus
+}
+
+// Consumer para orders.events + Producer para inventory.events (nombres
+// por defecto, configurables en messaging.exchanges / messaging.queues)
+func NewEventBusPair(
+	broker Broker,
+	cfg config.MessagingConfig,
+) EventBusPair {
+	return EventBusPair{
+		OrdersConsumer: broker.Consumer(cfg.Exchanges.Orders, cfg.Queues.Orders),
+		Producer:       broker.Producer(cfg.Exchanges.Inventory),
+	}
+}
+
+// Producers dedicados por tipo de evento (tabla de ruteo del outbox).
+// Los tipos que comparten exchange comparten bus.
+func NewRoutedProducers(
+	broker Broker,
+	routes map[string]string,
+) map[string]abstractions.EventBus {
+	result := make(map[string]abstractions.EventBus, len(routes))
+	for eventType, exchange := range routes {
+		result[eventType] = broker.Producer(exchange)
+	}
+	return result
+}
+
+// Consumer para catalog.events
+func NewCatalogEventBus(
+	broker Broker,
+	cfg config.MessagingConfig,
+) ConsumerBus {
+	return broker.Consumer(cfg.Exchanges.Catalog, cfg.Queues.Catalog)
+}
+
+func RegisterOrderSubscriptions(
+	ctx context.Context,
+	bus ConsumerBus,
+	orderPlacedHandler application.EventHandler,
+	orderCancelledHandler application.EventHandler,
+) error {
+	bus.Subscribe("OrderPlacedEvent", orderPlacedHandler)
+	bus.Subscribe("OrderCancelledEvent", orderCancelledHandler)
+	bus.Subscribe("OrderRejectedEvent", orderCancelledHandler)
+
+	if err := bus.StartConsumers(ctx); err != nil {
+		slog.Error("failed to start orders consumers", "error", err)
+		return err
+	}
+	return nil
+}
+
+func RegisterCatalogSubscriptions(
+	ctx context.Context,
+	bus ConsumerBus,
+	productCreatedHandler application.EventHandler,
+) error {
+	bus.Subscribe("ProductCreated", productCreatedHandler)
+
+	if err := bus.StartConsumers(ctx); err != nil {
+		slog.Error("failed to start catalog consumers", "error", err)
+		return err
+	}
+	return nil
+}
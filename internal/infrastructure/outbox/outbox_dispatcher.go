@@ -3,32 +3,39 @@ package outbox
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"time"
 
-	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
 	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/logging"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/tracing"
 )
 
 type Dispatcher struct {
 	repo      domain.OutboxRepository
-	eventBus  abstractions.EventBus
+	router    *Router
 	maxRetry  int
 	batchSize int
+	clock     domain.Clock
 }
 
 func NewDispatcher(
 	repo domain.OutboxRepository,
-	eventBus abstractions.EventBus,
+	router *Router,
 	maxRetry, batchSize int,
+	clock domain.Clock,
 ) *Dispatcher {
 	return &Dispatcher{
 		repo:      repo,
-		eventBus:  eventBus,
+		router:    router,
 		maxRetry:  maxRetry,
 		batchSize: batchSize,
+		clock:     clock,
 	}
 }
 
@@ -44,13 +51,14 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
 	processed := 0
 	for i := range msgs {
 		msg := &msgs[i]
+		msgCtx := logging.With(ctx, "outboxId", msg.ID.String(), logging.KeyEventType, msg.Type)
 
 		var generic map[string]interface{}
 		if err := json.Unmarshal([]byte(msg.PayloadJSON), &generic); err != nil {
-			log.Printf("Outbox: failed to unmarshal payload: %v", err)
+			slog.ErrorContext(msgCtx, "outbox payload is not valid JSON", "error", err)
 			msg.RetryCount++
 			if err := d.repo.Save(ctx, *msg); err != nil {
-				log.Printf("Outbox: failed to save message: %v", err)
+				slog.ErrorContext(msgCtx, "outbox save failed", "error", err)
 			}
 			continue
 		}
@@ -58,24 +66,63 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
 		eventType := msg.Type // ej. "StockReserved" / "StockReservationFailed"
 		payloadStr := msg.PayloadJSON
 
-		// Envelope estándar
-		envelope := primitives.NewIntegrationEventEnvelope(eventType, payloadStr)
+		// Envelope estándar. Su ID es el del mensaje del outbox, igual en
+		// cada reintento, para que el consumidor pueda deduplicar.
+		at := d.clock.Now()
+		envelope := primitives.NewIntegrationEventEnvelopeWith(
+			primitives.NewBaseEventWith(domain.NewEventMessage(msg.ID, at), ""),
+			eventType, payloadStr, at,
+		)
 
 		envelope.SetRoutingKey(eventType)
+		msgCtx = logging.With(msgCtx, logging.KeyEnvelopeID, envelope.ID.String())
 
-		if err := d.eventBus.Publish(ctx, &envelope); err != nil {
-			log.Printf("Outbox: failed to publish %s: %v", msg.Type, err)
+		// Continuamos la traza del request/mensaje que originó el evento.
+		spanCtx, span := tracing.Start(
+			tracing.Extract(msgCtx, msg.TraceContext),
+			"outbox.dispatch "+eventType,
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				attribute.String("messaging.message.id", envelope.ID.String()),
+				attribute.String("outbox.id", msg.ID.String()),
+			),
+		)
+
+		bus := d.router.BusFor(eventType)
+		start := time.Now()
+		err := bus.Publish(spanCtx, &envelope)
+		metrics.ObserveDispatch(eventType, time.Since(start), err)
+		tracing.End(span, err)
+		if err != nil {
+			slog.ErrorContext(msgCtx, "outbox publish failed", "retryCount", msg.RetryCount+1, "error", err)
 			msg.RetryCount++
 		} else {
-			now := time.Now().UTC().Unix()
+			now := d.clock.Now().Unix()
 			msg.ProcessedAtUtc = &now
 			processed++
 		}
 
 		if err := d.repo.Save(ctx, *msg); err != nil {
-			log.Printf("Outbox: failed to save message: %v", err)
+			slog.ErrorContext(msgCtx, "outbox save failed", "error", err)
 		}
 	}
 
 	return processed, nil
 }
+
+// Flush despacha batches hasta que no quede nada publicable o venza ctx.
+// Se usa al apagar el servicio para no dejar eventos esperando al próximo
+// arranque.
+func (d *Dispatcher) Flush(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := d.DispatchOnce(ctx)
+		total += n
+		if err != nil || n == 0 {
+			return total, err
+		}
+	}
+}
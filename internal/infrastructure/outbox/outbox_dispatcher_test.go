@@ -0,0 +1,299 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/primitives"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+// fakeBus guarda lo publicado y el ID de cada intento; failTypes hace
+// fallar esos tipos de evento.
+type fakeBus struct {
+	mu        sync.Mutex
+	published []*primitives.IntegrationEventEnvelope
+	attempts  []uuid.UUID
+	failTypes map[string]bool
+}
+
+func (b *fakeBus) Publish(ctx context.Context, ev primitives.Event) error {
+	env := ev.(*primitives.IntegrationEventEnvelope)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts = append(b.attempts, env.ID)
+	if b.failTypes[env.Type] {
+		return errors.New("broker down")
+	}
+	b.published = append(b.published, env)
+	return nil
+}
+
+func (b *fakeBus) Subscribe(string, abstractions.EventHandler) abstractions.EventBus { return b }
+
+func (b *fakeBus) SendCommand(context.Context, primitives.Command) error { return nil }
+
+func (b *fakeBus) types() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []string
+	for _, env := range b.published {
+		out = append(out, env.Type)
+	}
+	return out
+}
+
+func TestDispatchOnce(t *testing.T) {
+	const maxRetry = 3
+
+	cases := []struct {
+		name string
+		// msgs se insertan en orden, con OccurredAtUtc creciente.
+		msgs          []domain.OutboxMessage
+		failTypes     map[string]bool
+		batchSize     int
+		wantProcessed int
+		wantDefault   []string
+		wantRouted    []string
+		wantRetries   map[string]int // por tipo
+		wantPending   int
+	}{
+		{
+			name: "publishes in order and marks processed",
+			msgs: []domain.OutboxMessage{
+				{Type: "StockReserved", PayloadJSON: `{"orderId":"x"}`},
+				{Type: "StockReservationFailed", PayloadJSON: `{}`},
+			},
+			batchSize:     10,
+			wantProcessed: 2,
+			wantDefault:   []string{"StockReserved", "StockReservationFailed"},
+		},
+		{
+			name: "routes event types to their bus",
+			msgs: []domain.OutboxMessage{
+				{Type: "CatalogStockAdjusted", PayloadJSON: `{}`},
+				{Type: "StockReserved", PayloadJSON: `{}`},
+			},
+			batchSize:     10,
+			wantProcessed: 2,
+			wantDefault:   []string{"StockReserved"},
+			wantRouted:    []string{"CatalogStockAdjusted"},
+		},
+		{
+			name: "failed publish increments retry and stays pending",
+			msgs: []domain.OutboxMessage{
+				{Type: "StockReserved", PayloadJSON: `{}`},
+				{Type: "StockReservationFailed", PayloadJSON: `{}`},
+			},
+			failTypes:     map[string]bool{"StockReserved": true},
+			batchSize:     10,
+			wantProcessed: 1,
+			wantDefault:   []string{"StockReservationFailed"},
+			wantRetries:   map[string]int{"StockReserved": 1},
+			wantPending:   1,
+		},
+		{
+			name: "invalid JSON is not published",
+			msgs: []domain.OutboxMessage{
+				{Type: "StockReserved", PayloadJSON: `not json`},
+			},
+			batchSize:   10,
+			wantRetries: map[string]int{"StockReserved": 1},
+			wantPending: 1,
+		},
+		{
+			name: "messages at max retry are skipped",
+			msgs: []domain.OutboxMessage{
+				{Type: "StockReserved", PayloadJSON: `{}`, RetryCount: maxRetry},
+				{Type: "StockReservationFailed", PayloadJSON: `{}`},
+			},
+			batchSize:     10,
+			wantProcessed: 1,
+			wantDefault:   []string{"StockReservationFailed"},
+			wantRetries:   map[string]int{"StockReserved": maxRetry},
+		},
+		{
+			name: "respects the batch size",
+			msgs: []domain.OutboxMessage{
+				{Type: "StockReserved", PayloadJSON: `{}`},
+				{Type: "StockReservationFailed", PayloadJSON: `{}`},
+			},
+			batchSize:     1,
+			wantProcessed: 1,
+			wantDefault:   []string{"StockReserved"},
+			wantPending:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := memory.NewStore()
+			repo := memory.NewOutboxRepository(store)
+			for i, msg := range tc.msgs {
+				msg.ID = uuid.New()
+				msg.OccurredAtUtc = int64(1_700_000_000 + i)
+				if err := repo.Insert(context.Background(), msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			defaultBus := &fakeBus{failTypes: tc.failTypes}
+			routedBus := &fakeBus{failTypes: tc.failTypes}
+			router := NewRouter(defaultBus).Route("CatalogStockAdjusted", routedBus)
+			d := NewDispatcher(repo, router, maxRetry, tc.batchSize, testClock())
+
+			n, err := d.DispatchOnce(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tc.wantProcessed {
+				t.Errorf("processed = %d, want %d", n, tc.wantProcessed)
+			}
+			assertTypes(t, "default bus", defaultBus.types(), tc.wantDefault)
+			assertTypes(t, "routed bus", routedBus.types(), tc.wantRouted)
+
+			for _, msg := range store.OutboxMessages() {
+				if got, want := msg.RetryCount, tc.wantRetries[msg.Type]; got != want {
+					t.Errorf("%s retryCount = %d, want %d", msg.Type, got, want)
+				}
+			}
+			stats, _ := repo.GetPendingStats(context.Background(), maxRetry)
+			if stats.Pending != tc.wantPending {
+				t.Errorf("pending = %d, want %d", stats.Pending, tc.wantPending)
+			}
+		})
+	}
+}
+
+func TestFlushDrainsAllBatches(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	for i := 0; i < 5; i++ {
+		if err := repo.Insert(context.Background(), domain.OutboxMessage{
+			Type:          "StockReserved",
+			PayloadJSON:   `{}`,
+			OccurredAtUtc: int64(1_700_000_000 + i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus := &fakeBus{}
+	d := NewDispatcher(repo, NewRouter(bus), 3, 2, testClock())
+
+	n, err := d.Flush(context.Background())
+	if err != nil || n != 5 {
+		t.Fatalf("Flush = %d, %v; want 5", n, err)
+	}
+	if len(bus.types()) != 5 {
+		t.Errorf("published %d messages, want 5", len(bus.types()))
+	}
+}
+
+func TestDispatchUsesInjectedClockAndOutboxIDs(t *testing.T) {
+	publish := func() []*primitives.IntegrationEventEnvelope {
+		store := memory.NewStore()
+		repo := memory.NewOutboxRepository(store)
+		for i := 0; i < 3; i++ {
+			if err := repo.Insert(context.Background(), domain.OutboxMessage{
+				ID:            uuid.NewSHA1(uuid.NameSpaceOID, []byte{byte(i)}),
+				Type:          "StockReserved",
+				PayloadJSON:   `{}`,
+				OccurredAtUtc: int64(1_700_000_000 + i),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		bus := &fakeBus{}
+		d := NewDispatcher(repo, NewRouter(bus), 3, 10, testClock())
+		if _, err := d.DispatchOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range store.OutboxMessages() {
+			if msg.ProcessedAtUtc == nil || !fromTestClock(time.Unix(*msg.ProcessedAtUtc, 0)) {
+				t.Errorf("processedAtUtc = %v, want from injected clock", msg.ProcessedAtUtc)
+			}
+		}
+		return bus.published
+	}
+
+	first, second := publish(), publish()
+	if len(first) != 3 || len(second) != 3 {
+		t.Fatalf("published %d and %d envelopes, want 3", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].ID != second[i].ID || !first[i].OccurredAtUtc.Equal(second[i].OccurredAtUtc) {
+			t.Errorf("envelope %d differs between runs: %s@%s vs %s@%s", i,
+				first[i].ID, first[i].OccurredAtUtc, second[i].ID, second[i].OccurredAtUtc)
+		}
+		if want := uuid.NewSHA1(uuid.NameSpaceOID, []byte{byte(i)}); first[i].ID != want {
+			t.Errorf("envelope %d id = %s, want the outbox id %s", i, first[i].ID, want)
+		}
+		if !fromTestClock(first[i].OccurredAtUtc) {
+			t.Errorf("envelope %d occurredAtUtc = %s, want from injected clock", i, first[i].OccurredAtUtc)
+		}
+	}
+}
+
+func TestDispatchKeepsEnvelopeIDAcrossRetries(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	id := uuid.NewSHA1(uuid.NameSpaceOID, []byte("retry"))
+	if err := repo.Insert(context.Background(), domain.OutboxMessage{
+		ID: id, Type: "StockReserved", PayloadJSON: `{}`, OccurredAtUtc: 1_700_000_000,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	bus := &fakeBus{failTypes: map[string]bool{"StockReserved": true}}
+	d := NewDispatcher(repo, NewRouter(bus), 3, 10, testClock())
+
+	for i := 0; i < 2; i++ {
+		if n, err := d.DispatchOnce(context.Background()); err != nil || n != 0 {
+			t.Fatalf("failing dispatch = %d, %v", n, err)
+		}
+	}
+	bus.failTypes = nil
+	if n, err := d.DispatchOnce(context.Background()); err != nil || n != 1 {
+		t.Fatalf("dispatch after recovery = %d, %v", n, err)
+	}
+
+	if len(bus.attempts) != 3 {
+		t.Fatalf("attempts = %v, want 3", bus.attempts)
+	}
+	for i, got := range bus.attempts {
+		if got != id {
+			t.Errorf("attempt %d envelope id = %s, want the outbox id %s", i, got, id)
+		}
+	}
+}
+
+var testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testClock() *domain.ManualClock {
+	return domain.NewManualClock(testStart, time.Second)
+}
+
+// fromTestClock indica si t salió de testClock y no del reloj real.
+func fromTestClock(t time.Time) bool {
+	return !t.Before(testStart) && t.Before(testStart.Add(time.Hour))
+}
+
+func assertTypes(t *testing.T, bus string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s published %v, want %v", bus, got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s published %v, want %v", bus, got, want)
+			return
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// BacklogCheck devuelve un check de readiness que falla cuando el outbox
+// acumula más de maxBacklog pendientes o el más viejo supera maxAge.
+// Un límite en cero no se evalúa. La edad se mide contra clock.
+func BacklogCheck(
+	repo domain.OutboxRepository,
+	maxRetry, maxBacklog int,
+	maxAge time.Duration,
+	clock domain.Clock,
+) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stats, err := repo.GetPendingStats(ctx, maxRetry)
+		if err != nil {
+			return err
+		}
+		if maxBacklog > 0 && stats.Pending > maxBacklog {
+			return fmt.Errorf("outbox backlog %d exceeds %d", stats.Pending, maxBacklog)
+		}
+		if maxAge > 0 && stats.Pending > 0 {
+			age := clock.Now().Sub(time.Unix(stats.OldestOccurredAtUtc, 0))
+			if age > maxAge {
+				return fmt.Errorf("oldest pending outbox message is %s old, exceeds %s",
+					age.Truncate(time.Second), maxAge)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/infrastructure/memory"
+)
+
+func TestBacklogCheckMeasuresAgeWithClock(t *testing.T) {
+	repo := memory.NewOutboxRepository(memory.NewStore())
+	if err := repo.Insert(context.Background(), domain.OutboxMessage{
+		ID: uuid.New(), Type: "StockReserved", PayloadJSON: `{}`, OccurredAtUtc: testStart.Unix(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clock := domain.NewManualClock(testStart.Add(time.Minute), 0)
+	check := BacklogCheck(repo, 3, 0, 2*time.Minute, clock)
+
+	if err := check(context.Background()); err != nil {
+		t.Errorf("1m old message = %v, want ready", err)
+	}
+	clock.Advance(2 * time.Minute)
+	if err := check(context.Background()); err == nil {
+		t.Error("3m old message = nil, want not ready")
+	}
+}
@@ -0,0 +1,35 @@
+package outbox
+
+import (
+	"github.com/rodolfodevapp/eventshop-messaging-go/core/abstractions"
+)
+
+// Router decide a qué bus (exchange) se publica cada tipo de evento del outbox.
+// Los tipos sin ruta explícita van al bus por defecto.
+type Router struct {
+	defaultBus abstractions.EventBus
+	routes     map[string]abstractions.EventBus
+}
+
+func NewRouter(defaultBus abstractions.EventBus) *Router {
+	return &Router{
+		defaultBus: defaultBus,
+		routes:     make(map[string]abstractions.EventBus),
+	}
+}
+
+// Route asigna un bus dedicado a un tipo de evento (ej. "CatalogStockAdjusted").
+func (r *Router) Route(eventType string, bus abstractions.EventBus) *Router {
+	if bus != nil {
+		r.routes[eventType] = bus
+	}
+	return r
+}
+
+// BusFor devuelve el bus destino para el tipo de evento.
+func (r *Router) BusFor(eventType string) abstractions.EventBus {
+	if bus, ok := r.routes[eventType]; ok {
+		return bus
+	}
+	return r.defaultBus
+}
@@ -2,40 +2,62 @@ package outbox
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 )
 
 type Scheduler struct {
 	dispatcher *Dispatcher
 	interval   time.Duration
+
+	stop chan struct{}
+	done chan struct{}
 }
 
 func NewScheduler(d *Dispatcher, intervalSec int) *Scheduler {
 	return &Scheduler{
 		dispatcher: d,
 		interval:   time.Duration(intervalSec) * time.Second,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
 	}
 }
 
 func (s *Scheduler) Start(ctx context.Context) {
 	go func() {
+		defer close(s.done)
+
 		ticker := time.NewTicker(s.interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
-				log.Printf("Outbox scheduler stopped")
+				slog.Info("outbox scheduler stopped")
+				return
+			case <-s.stop:
+				slog.Info("outbox scheduler stopped")
 				return
 			case <-ticker.C:
 				n, err := s.dispatcher.DispatchOnce(ctx)
 				if err != nil {
-					log.Printf("Outbox dispatch error: %v", err)
+					slog.Error("outbox dispatch failed", "error", err)
 				} else if n > 0 {
-					log.Printf("Outbox dispatch processed %d messages", n)
+					slog.Info("outbox dispatch completed", "processed", n)
 				}
 			}
 		}
 	}()
 }
+
+// Stop detiene el ticker y espera a que termine el batch en curso, o hasta
+// que venza ctx. Solo se debe llamar una vez, después de Start.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
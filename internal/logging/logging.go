@@ -0,0 +1,129 @@
+// Package logging configura log/slog y propaga campos de correlación
+// (orderId, sku, envelopeId, correlationId, requestId) vía context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Claves estándar de los campos de correlación.
+const (
+	KeyOrderID       = "orderId"
+	KeySku           = "sku"
+	KeyEnvelopeID    = "envelopeId"
+	KeyEventType     = "eventType"
+	KeyCorrelationID = "correlationId"
+	KeyRequestID     = "requestId"
+	KeyTraceID       = "traceId"
+	KeySpanID        = "spanId"
+)
+
+// New crea el logger raíz. format: "json" (default) o "text";
+// level: debug, info, warn, error.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+type ctxKey struct{}
+
+// With agrega campos que se loggean en todo registro hecho con este ctx.
+func With(ctx context.Context, args ...any) context.Context {
+	if len(args) == 0 {
+		return ctx
+	}
+	r := slog.Record{}
+	r.Add(args...)
+	added := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		added = append(added, a)
+		return true
+	})
+
+	// Un campo repetido reemplaza al anterior.
+	merged := make([]slog.Attr, 0, len(attrsFrom(ctx))+len(added))
+	for _, a := range attrsFrom(ctx) {
+		if !containsKey(added, a.Key) {
+			merged = append(merged, a)
+		}
+	}
+	merged = append(merged, added...)
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+// Attr devuelve el valor de un campo de correlación del ctx ("" si no hay).
+func Attr(ctx context.Context, key string) string {
+	for _, a := range attrsFrom(ctx) {
+		if a.Key == key {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
+
+func containsKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler agrega al registro los campos guardados con With y el
+// traceId/spanId del span activo.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := attrsFrom(ctx)
+	sc := trace.SpanContextFromContext(ctx)
+	if len(attrs) > 0 || sc.IsValid() {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+		if sc.IsValid() {
+			r.AddAttrs(
+				slog.String(KeyTraceID, sc.TraceID().String()),
+				slog.String(KeySpanID, sc.SpanID().String()),
+			)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
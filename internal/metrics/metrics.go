@@ -0,0 +1,174 @@
+// Package metrics define las métricas Prometheus del servicio. Las capas
+// usan los helpers de este paquete en vez de tocar los collectors directo.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "inventory"
+
+// Razones de reservación fallida.
+const (
+	ReasonNoLines           = "no_lines"
+	ReasonSkuNotFound       = "sku_not_found"
+	ReasonInsufficientStock = "insufficient_stock"
+	ReasonInvalidQuantity   = "invalid_quantity"
+)
+
+var (
+	reservations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_total",
+		Help:      "Order reservations by result and failure reason.",
+	}, []string{"result", "reason"})
+
+	releases = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "releases_total",
+		Help:      "Reservations released after order cancellation/rejection.",
+	})
+
+	dispatchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbox_dispatch_duration_seconds",
+		Help:      "Time to publish one outbox message to the broker.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type"})
+
+	dispatchFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_dispatch_failures_total",
+		Help:      "Outbox messages that failed to publish.",
+	}, []string{"event_type"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "consumer_handler_duration_seconds",
+		Help:      "Duration of each inbound handler attempt by event type and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type", "result"})
+
+	quarantined = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_quarantined_total",
+		Help:      "Inbound messages moved to quarantine.",
+	}, []string{"event_type", "permanent"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	feedSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stock_feed_subscribers",
+		Help:      "Open stock change subscriptions (SSE and gRPC WatchStock).",
+	})
+
+	feedCoalesced = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_feed_coalesced_total",
+		Help:      "Stock changes replaced by a newer one before a slow subscriber read them.",
+	})
+)
+
+func ReservationSucceeded() {
+	reservations.WithLabelValues("succeeded", "").Inc()
+}
+
+func ReservationFailed(reason string) {
+	reservations.WithLabelValues("failed", reason).Inc()
+}
+
+func ReservationReleased() {
+	releases.Inc()
+}
+
+func ObserveDispatch(eventType string, d time.Duration, err error) {
+	dispatchDuration.WithLabelValues(eventType).Observe(d.Seconds())
+	if err != nil {
+		dispatchFailures.WithLabelValues(eventType).Inc()
+	}
+}
+
+func ObserveHandler(eventType string, d time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	handlerDuration.WithLabelValues(eventType, result).Observe(d.Seconds())
+}
+
+func MessageQuarantined(eventType string, permanent bool) {
+	p := "false"
+	if permanent {
+		p = "true"
+	}
+	quarantined.WithLabelValues(eventType, p).Inc()
+}
+
+func ObserveHTTP(route, method string, status int, d time.Duration) {
+	httpDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+func StockFeedSubscribers(n int) {
+	feedSubscribers.Set(float64(n))
+}
+
+func StockFeedCoalesced() {
+	feedCoalesced.Inc()
+}
+
+// RegisterDBStats publica las estadísticas del pool de database/sql.
+func RegisterDBStats(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "inventory"))
+}
+
+// OutboxStatsFunc devuelve mensajes pendientes y la edad del más viejo.
+type OutboxStatsFunc func(ctx context.Context) (pending int, oldestAge time.Duration, err error)
+
+// RegisterOutboxStats consulta el backlog del outbox en cada scrape.
+func RegisterOutboxStats(fn OutboxStatsFunc) {
+	prometheus.MustRegister(&outboxCollector{stats: fn})
+}
+
+var (
+	outboxBacklogDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "outbox", "backlog"),
+		"Pending (unprocessed, retryable) outbox messages.", nil, nil)
+	outboxOldestDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "outbox", "oldest_pending_age_seconds"),
+		"Age of the oldest pending outbox message (0 when empty).", nil, nil)
+)
+
+type outboxCollector struct {
+	stats OutboxStatsFunc
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxBacklogDesc
+	ch <- outboxOldestDesc
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	pending, age, err := c.stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(outboxBacklogDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(outboxBacklogDesc, prometheus.GaugeValue, float64(pending))
+	ch <- prometheus.MustNewConstMetric(outboxOldestDesc, prometheus.GaugeValue, age.Seconds())
+}
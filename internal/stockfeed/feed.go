@@ -0,0 +1,216 @@
+// Package stockfeed es el feed en proceso de cambios de stock. Lo alimenta
+// Repository (decorador de domain.StockItemRepository) y lo consumen el SSE
+// de /api/inventory/stream y WatchStock de gRPC.
+//
+// Solo ve las mutaciones de esta instancia; con varias réplicas cada cliente
+// recibe los cambios que pasan por la réplica a la que está conectado.
+package stockfeed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/metrics"
+)
+
+var (
+	ErrTooManySubscribers = errors.New("too many stock feed subscribers")
+	ErrClosed             = errors.New("stock feed closed")
+)
+
+// Change es el nivel de stock de un SKU después de una mutación.
+type Change struct {
+	Sku       string
+	Available int
+	Reserved  int
+	UpdatedAt time.Time
+}
+
+func ChangeOf(item *domain.StockItem) Change {
+	return Change{
+		Sku:       item.Sku,
+		Available: item.Available,
+		Reserved:  item.Reserved,
+		UpdatedAt: item.UpdatedAtUtc,
+	}
+}
+
+// Feed reparte los cambios a los suscriptores de cada SKU. Publish nunca se
+// bloquea por un suscriptor lento: cada suscripción guarda solo el último
+// cambio pendiente por SKU (los intermedios se descartan), así la memoria
+// queda acotada por la cantidad de SKUs suscritos.
+type Feed struct {
+	maxSubscribers int
+
+	mu     sync.Mutex
+	bySku  map[string]map[*Subscription]struct{}
+	count  int
+	closed bool
+}
+
+// NewFeed crea un feed; maxSubscribers <= 0 es sin límite.
+func NewFeed(maxSubscribers int) *Feed {
+	return &Feed{
+		maxSubscribers: maxSubscribers,
+		bySku:          map[string]map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe registra una suscripción a skus. Hay que llamar Close al
+// terminar.
+func (f *Feed) Subscribe(skus []string) (*Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, ErrClosed
+	}
+	if f.maxSubscribers > 0 && f.count >= f.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	sub := &Subscription{
+		feed:    f,
+		skus:    skus,
+		pending: map[string]Change{},
+		ready:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	for _, sku := range skus {
+		subs, ok := f.bySku[sku]
+		if !ok {
+			subs = map[*Subscription]struct{}{}
+			f.bySku[sku] = subs
+		}
+		subs[sub] = struct{}{}
+	}
+	f.count++
+	metrics.StockFeedSubscribers(f.count)
+	return sub, nil
+}
+
+// Publish entrega los cambios a los suscriptores de cada SKU.
+func (f *Feed) Publish(changes ...Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, c := range changes {
+		for sub := range f.bySku[c.Sku] {
+			sub.push(c)
+		}
+	}
+}
+
+// Close cierra todas las suscripciones y rechaza las nuevas, para que los
+// streams abiertos no frenen el apagado.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	f.closed = true
+	subs := map[*Subscription]struct{}{}
+	for _, bySub := range f.bySku {
+		for sub := range bySub {
+			subs[sub] = struct{}{}
+		}
+	}
+	f.mu.Unlock()
+
+	for sub := range subs {
+		sub.Close()
+	}
+}
+
+func (f *Feed) unsubscribe(sub *Subscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, sku := range sub.skus {
+		subs := f.bySku[sku]
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(f.bySku, sku)
+		}
+	}
+	f.count--
+	metrics.StockFeedSubscribers(f.count)
+}
+
+// Subscription recibe los cambios de un conjunto de SKUs.
+type Subscription struct {
+	feed *Feed
+	skus []string
+
+	mu      sync.Mutex
+	pending map[string]Change
+	order   []string // orden de llegada de los SKUs pendientes
+	ready   chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (s *Subscription) push(c Change) {
+	s.mu.Lock()
+	if _, ok := s.pending[c.Sku]; ok {
+		metrics.StockFeedCoalesced()
+	} else {
+		s.order = append(s.order, c.Sku)
+	}
+	s.pending[c.Sku] = c
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Done se cierra con Close (de la suscripción o del feed).
+func (s *Subscription) Done() <-chan struct{} { return s.done }
+
+// Ready avisa que hay cambios para leer con Pending.
+func (s *Subscription) Ready() <-chan struct{} { return s.ready }
+
+// Pending devuelve y vacía el último cambio de cada SKU, en el orden en
+// que llegaron.
+func (s *Subscription) Pending() []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.order) == 0 {
+		return nil
+	}
+	out := make([]Change, 0, len(s.order))
+	for _, sku := range s.order {
+		out = append(out, s.pending[sku])
+	}
+	s.order = s.order[:0]
+	clear(s.pending)
+	return out
+}
+
+// Next espera hasta que haya cambios y los devuelve como Pending.
+func (s *Subscription) Next(ctx context.Context) ([]Change, error) {
+	for {
+		if out := s.Pending(); len(out) > 0 {
+			return out, nil
+		}
+		select {
+		case <-s.ready:
+		case <-s.done:
+			return nil, ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close da de baja la suscripción. Es seguro llamarlo más de una vez.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.feed.unsubscribe(s)
+		close(s.done)
+	})
+}
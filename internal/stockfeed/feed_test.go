@@ -0,0 +1,107 @@
+package stockfeed
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionCoalescesPerSku(t *testing.T) {
+	feed := NewFeed(0)
+	sub, err := feed.Subscribe([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// Nadie lee mientras se publica: Publish no se bloquea y queda solo el
+	// último nivel de cada SKU, en el orden en que llegó cada uno.
+	for i := 1; i <= 100; i++ {
+		feed.Publish(Change{Sku: "B", Available: i}, Change{Sku: "C", Available: i})
+	}
+	feed.Publish(Change{Sku: "A", Available: 7}, Change{Sku: "B", Available: 0, Reserved: 3})
+
+	select {
+	case <-sub.Ready():
+	default:
+		t.Fatal("Ready not signalled")
+	}
+	want := []Change{{Sku: "B", Reserved: 3}, {Sku: "A", Available: 7}}
+	if got := sub.Pending(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pending = %+v, want %+v", got, want)
+	}
+	if got := sub.Pending(); got != nil {
+		t.Errorf("second Pending = %+v, want nil", got)
+	}
+
+	feed.Publish(Change{Sku: "A", Available: 8})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if got, err := sub.Next(ctx); err != nil || !reflect.DeepEqual(got, []Change{{Sku: "A", Available: 8}}) {
+		t.Errorf("Next = %+v, %v", got, err)
+	}
+}
+
+func TestFeedLimitsSubscribers(t *testing.T) {
+	feed := NewFeed(2)
+	first, err := feed.Subscribe([]string{"A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feed.Subscribe([]string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feed.Subscribe([]string{"C"}); !errors.Is(err, ErrTooManySubscribers) {
+		t.Fatalf("third Subscribe = %v, want ErrTooManySubscribers", err)
+	}
+
+	// Cerrar libera el lugar, una sola vez aunque se cierre dos veces.
+	first.Close()
+	first.Close()
+	if _, err := feed.Subscribe([]string{"C"}); err != nil {
+		t.Fatalf("Subscribe after Close = %v", err)
+	}
+	if _, err := feed.Subscribe([]string{"D"}); !errors.Is(err, ErrTooManySubscribers) {
+		t.Errorf("Subscribe over the limit = %v, want ErrTooManySubscribers", err)
+	}
+}
+
+func TestFeedCloseEndsSubscriptions(t *testing.T) {
+	feed := NewFeed(0)
+	sub, err := feed.Subscribe([]string{"A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := make(chan error, 1)
+	go func() {
+		_, err := sub.Next(context.Background())
+		next <- err
+	}()
+
+	feed.Close()
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed by Feed.Close")
+	}
+	select {
+	case err := <-next:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Next = %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Next still waiting after Feed.Close")
+	}
+
+	sub.Close() // el stream cierra igual su suscripción al salir
+	if _, err := feed.Subscribe([]string{"A"}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close = %v, want ErrClosed", err)
+	}
+	feed.Publish(Change{Sku: "A", Available: 1})
+	if got := sub.Pending(); got != nil {
+		t.Errorf("closed subscription got %+v", got)
+	}
+}
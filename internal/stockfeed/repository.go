@@ -0,0 +1,31 @@
+package stockfeed
+
+import (
+	"context"
+
+	"github.com/RodolfoDevApp/eventshop-inventory-go/internal/domain"
+)
+
+// Repository publica en el feed cada UpsertMany que se confirma; dentro de
+// una unidad de trabajo, recién cuando se confirma la unidad.
+type Repository struct {
+	domain.StockItemRepository
+	feed *Feed
+}
+
+func NewRepository(inner domain.StockItemRepository, feed *Feed) *Repository {
+	return &Repository{StockItemRepository: inner, feed: feed}
+}
+
+func (r *Repository) UpsertMany(ctx context.Context, items []*domain.StockItem) error {
+	if err := r.StockItemRepository.UpsertMany(ctx, items); err != nil {
+		return err
+	}
+
+	changes := make([]Change, 0, len(items))
+	for _, item := range items {
+		changes = append(changes, ChangeOf(item))
+	}
+	domain.AfterCommit(ctx, func() { r.feed.Publish(changes...) })
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package tracing configura OpenTelemetry y serializa el contexto de traza
+// para que viaje por el outbox.
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+
+	tracerName = "github.com/RodolfoDevApp/eventshop-inventory-go"
+)
+
+// Setup registra el TracerProvider global según el exporter:
+//   - none: sin exportar (los spans igual propagan contexto)
+//   - stdout / file: JSON por span, útil offline
+//   - otlp: OTLP/HTTP, configurado con las env OTEL_EXPORTER_OTLP_*
+//
+// Devuelve la función de shutdown que hace flush de los spans pendientes.
+func Setup(ctx context.Context, exporter, filePath, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		spanExporter sdktrace.SpanExporter
+		closer       io.Closer
+		err          error
+	)
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		f, ferr := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if ferr != nil {
+			return nil, ferr
+		}
+		closer = f
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Tracer devuelve el tracer del servicio.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start abre un span hijo del ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End cierra el span registrando el error si lo hay.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject serializa el contexto de traza del ctx (traceparent/tracestate) como
+// JSON para persistirlo junto al mensaje del outbox. Vacío si no hay traza.
+func Inject(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Extract restaura en ctx el contexto de traza guardado con Inject.
+func Extract(ctx context.Context, serialized string) context.Context {
+	if serialized == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{}
+	if err := json.Unmarshal([]byte(serialized), &carrier); err != nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}